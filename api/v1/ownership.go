@@ -0,0 +1,55 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// +kubebuilder:rbac:groups=authorization.k8s.io,resources=subjectaccessreviews,verbs=create
+
+// isSelfService reports whether the requester belongs to any of the given self-service groups.
+func isSelfService(userInfo authenticationv1.UserInfo, groups []string) bool {
+	return slices.ContainsFunc(userInfo.Groups, func(group string) bool {
+		return slices.Contains(groups, group)
+	})
+}
+
+// ownedBy reports whether the requester owns the user, either because it created the user or because it authenticates
+// as the user's service account.
+func (r *User) ownedBy(userInfo authenticationv1.UserInfo) bool {
+	if creator, found := r.Annotations[UserCreatorAnnotation]; found && creator == userInfo.Username {
+		return true
+	}
+
+	return userInfo.Username == fmt.Sprintf("system:serviceaccount:%s:%s", r.ServiceAccountNamespace(), r.Name)
+}
+
+// accessAllowed asks the api server whether the requester may perform the given action, by creating a
+// SubjectAccessReview.
+func accessAllowed(ctx context.Context, c client.Client, userInfo authenticationv1.UserInfo, attributes *authorizationv1.ResourceAttributes) (bool, error) {
+	extra := make(map[string]authorizationv1.ExtraValue, len(userInfo.Extra))
+	for key, value := range userInfo.Extra {
+		extra[key] = authorizationv1.ExtraValue(value)
+	}
+
+	review := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:               userInfo.Username,
+			Groups:             userInfo.Groups,
+			UID:                userInfo.UID,
+			Extra:              extra,
+			ResourceAttributes: attributes,
+		},
+	}
+
+	if err := c.Create(ctx, review); err != nil {
+		return false, fmt.Errorf("could not review access of '%s': %w", userInfo.Username, err)
+	}
+
+	return review.Status.Allowed, nil
+}
@@ -0,0 +1,29 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// validateQuota rejects creating another object in a namespace which already holds max objects of the same kind. The
+// list is only used to count the objects, and is filled from the given reader.
+func validateQuota(ctx context.Context, reader client.Reader, list client.ObjectList, resource string, obj client.Object, max int) error {
+	if max == 0 {
+		return nil
+	}
+
+	if err := reader.List(ctx, list, client.InNamespace(obj.GetNamespace())); err != nil {
+		return fmt.Errorf("could not count %s in namespace '%s': %w", resource, obj.GetNamespace(), err)
+	}
+
+	if count := meta.LenList(list); count >= max {
+		return apierrors.NewForbidden(GroupVersion.WithResource(resource).GroupResource(), obj.GetName(),
+			fmt.Errorf("namespace '%s' already has the maximum of %d %s", obj.GetNamespace(), max, resource))
+	}
+
+	return nil
+}
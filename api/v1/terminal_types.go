@@ -1,19 +1,317 @@
 package v1
 
 import (
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
 // NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
 
+// TerminalSetupJob is a one-time job run to completion before the terminal is started.
+type TerminalSetupJob struct {
+	Image string `json:"image"`
+
+	// Command overrides the entrypoint of the setup image.
+	Command []string `json:"command,omitempty"`
+}
+
+// PersistentHome is a volume mounted as the terminal user's home directory, so work survives terminal restarts.
+type PersistentHome struct {
+	Size resource.Quantity `json:"size"`
+
+	// StorageClassName is the storage class of the home volume, defaulting to the cluster default.
+	StorageClassName *string `json:"storageClassName,omitempty"`
+
+	// MountPath is where the home volume is mounted in the terminal container, defaulting to the terminal's working
+	// directory, or /root if the terminal has none.
+	MountPath string `json:"mountPath,omitempty"`
+
+	// RetainVolume keeps the home volume when the terminal is deleted.
+	RetainVolume bool `json:"retainVolume,omitempty"`
+}
+
+// ImagePullBackOffAction is the action taken on a terminal whose image cannot be pulled.
+// +kubebuilder:validation:Enum=Fail;Delete
+type ImagePullBackOffAction string
+
+const (
+	// ImagePullBackOffFail marks the terminal as failed.
+	ImagePullBackOffFail ImagePullBackOffAction = "Fail"
+
+	// ImagePullBackOffDelete deletes the terminal.
+	ImagePullBackOffDelete ImagePullBackOffAction = "Delete"
+)
+
+// ImagePullBackOffPolicy configures what happens to a terminal whose pod is stuck failing to pull its image.
+type ImagePullBackOffPolicy struct {
+	Action ImagePullBackOffAction `json:"action"`
+
+	// Timeout is how long the terminal pod may fail to pull its image before the action is taken, defaulting to 5
+	// minutes.
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// TerminalProbe tunes the readiness and liveness probes checking the terminal's ssh port.
+type TerminalProbe struct {
+	// Disabled removes the probes, for images which do not run an ssh server.
+	Disabled bool `json:"disabled,omitempty"`
+
+	// InitialDelaySeconds is how long after the container starts before it is first probed.
+	// +kubebuilder:validation:Minimum=0
+	InitialDelaySeconds int32 `json:"initialDelaySeconds,omitempty"`
+
+	// PeriodSeconds is how often the container is probed, defaulting to 10 seconds.
+	// +kubebuilder:validation:Minimum=0
+	PeriodSeconds int32 `json:"periodSeconds,omitempty"`
+}
+
+// TerminalIngress exposes the terminal service through an Ingress, for example for browser based ssh clients.
+type TerminalIngress struct {
+	// Host is the host the ingress serves the terminal at.
+	Host string `json:"host"`
+
+	// Path is the path the ingress serves the terminal at, defaulting to /.
+	Path string `json:"path,omitempty"`
+
+	// IngressClassName is the class of the ingress, defaulting to the cluster default.
+	IngressClassName *string `json:"ingressClassName,omitempty"`
+}
+
+// TerminalNetworkPolicy isolates the terminal pods, only allowing ssh connections in and the given traffic out.
+type TerminalNetworkPolicy struct {
+	// Egress is the traffic the terminal pods may send. When empty all egress is denied.
+	Egress []networkingv1.NetworkPolicyEgressRule `json:"egress,omitempty"`
+}
+
 // TerminalSpec defines the desired state of Terminal
 type TerminalSpec struct {
-	Image string `json:"image"`
+	// Image is the terminal container image. When empty the operator's default image is used.
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// ImagePullPolicy is the pull policy of the terminal container. When empty the operator's default pull policy is
+	// used.
+	// +kubebuilder:validation:Enum=Always;IfNotPresent;Never
+	// +optional
+	ImagePullPolicy corev1.PullPolicy `json:"imagePullPolicy,omitempty"`
+
+	// WorkingDir is the working directory of the terminal container, and where a persistent home is mounted unless it
+	// sets its own mount path. When empty the image's working directory is used, and persistent homes are mounted at
+	// /root.
+	// +optional
+	WorkingDir string `json:"workingDir,omitempty"`
+
+	// User is the name of the marina User in the terminal's namespace who the terminal belongs to. The terminal pod
+	// runs as the user's assigned UID, and the user's credentials secret is mounted into the terminal container. The
+	// webhook only allows users owned by the requester setting the user, or which that requester may update, so
+	// terminals cannot be used to read another user's credentials.
+	User string `json:"user,omitempty"`
+
+	// Probe tunes the readiness and liveness probes of the terminal container, which check that its ssh port is
+	// accepting connections. The probes are installed when RunSshd is set or Probe is given, so images which start
+	// their own ssh server opt in by setting Probe. They are not used for terminals which run to completion.
+	Probe *TerminalProbe `json:"probe,omitempty"`
+
+	// InitContainers run to completion before the terminal container starts, for example to seed the home directory.
+	// Their images and security contexts are held to the same rules as the terminal container.
+	InitContainers []corev1.Container `json:"initContainers,omitempty"`
+
+	// Sidecars run alongside the terminal container, for example to ship logs or record sessions. They are not used
+	// for terminals which run to completion, since they would keep the terminal from completing. Their images and
+	// security contexts are held to the same rules as the terminal container.
+	Sidecars []corev1.Container `json:"sidecars,omitempty"`
+
+	// ServiceAccountName is the service account the terminal pod runs as, and must be allowed by the operator. When
+	// empty the pod runs as the namespace's default service account, or the service account of the terminal's user if
+	// the operator is configured to use it.
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+
+	// RunSshd starts sshd on the terminal's port before the terminal container goes to sleep, for images which do not
+	// start sshd themselves. The image must provide /usr/sbin/sshd. It cannot be used with terminals which run to
+	// completion or use a pod template, since they do not use the operator's command.
+	RunSshd bool `json:"runSshd,omitempty"`
+
+	// RunToCompletion backs the terminal with a Job rather than a Deployment, so the terminal runs once and is not
+	// restarted. Changing it replaces the terminal's Job or Deployment with the other.
+	RunToCompletion bool `json:"runToCompletion,omitempty"`
+
+	// RestartPolicy is the restart policy of the pod of a terminal which runs to completion, defaulting to Never. Pods
+	// of other terminals are always restarted.
+	// +kubebuilder:validation:Enum=Never;OnFailure
+	RestartPolicy corev1.RestartPolicy `json:"restartPolicy,omitempty"`
+
+	// TerminationGracePeriodSeconds is how long the terminal pod is given to shut down once it is stopped, for example
+	// to flush session recordings, defaulting to the Kubernetes default of 30 seconds.
+	// +kubebuilder:validation:Minimum=0
+	TerminationGracePeriodSeconds *int64 `json:"terminationGracePeriodSeconds,omitempty"`
+
+	// PodAnnotations are added to the terminal pod template, for example to configure Vault agent injection.
+	PodAnnotations map[string]string `json:"podAnnotations,omitempty"`
+
+	// PodLabels are added to the terminal pod template. They may not override the labels used to select the pod.
+	PodLabels map[string]string `json:"podLabels,omitempty"`
+
+	// Frozen scales the terminal down to zero replicas without deleting it, so it can be thawed later.
+	Frozen bool `json:"frozen,omitempty"`
+
+	// Replicas is the number of terminal pods to run behind the terminal service, defaulting to 1.
+	// +kubebuilder:validation:Minimum=0
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// ServiceAnnotations are added to the terminal service, taking precedence over any operator defaults.
+	ServiceAnnotations map[string]string `json:"serviceAnnotations,omitempty"`
+
+	// Port is the port sshd listens on in the terminal container, defaulting to 22. The terminal service always
+	// exposes ssh on port 22.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	Port int32 `json:"port,omitempty"`
+
+	// ServiceType is the type of the terminal service, defaulting to ClusterIP.
+	// +kubebuilder:validation:Enum=ClusterIP;NodePort
+	ServiceType corev1.ServiceType `json:"serviceType,omitempty"`
+
+	// RuntimeClassName is the RuntimeClass used to run the terminal pod. Any pod overhead defined by the
+	// RuntimeClass is applied to the pod.
+	RuntimeClassName *string `json:"runtimeClassName,omitempty"`
+
+	// Capabilities are added to the terminal container, and must be allowed by the operator.
+	Capabilities []corev1.Capability `json:"capabilities,omitempty"`
+
+	// SecurityContext is the security context of the terminal container. Any capabilities are added to it. Capabilities
+	// it adds must be allowed by the operator, and it may not make the container privileged or allow privilege
+	// escalation.
+	SecurityContext *corev1.SecurityContext `json:"securityContext,omitempty"`
+
+	// PodSecurityContext is the security context of the terminal pod. When the terminal belongs to a user, the
+	// user's uid takes precedence.
+	PodSecurityContext *corev1.PodSecurityContext `json:"podSecurityContext,omitempty"`
+
+	// RunAsNonRoot requires the terminal to run as a non-root user, with all capabilities other than those the
+	// terminal adds dropped, no privilege escalation and the runtime default seccomp profile. Anything set explicitly
+	// in the security contexts takes precedence. The terminal image must run as a non-root user unless the terminal
+	// belongs to a user or sets a uid in its pod security context.
+	RunAsNonRoot bool `json:"runAsNonRoot,omitempty"`
+
+	// ReadinessGates are additional conditions which must be true for the terminal pod to be ready, allowing
+	// external controllers to gate readiness.
+	ReadinessGates []corev1.PodReadinessGate `json:"readinessGates,omitempty"`
+
+	// Env is added to the terminal container's environment, and may reference secrets or config maps.
+	Env []corev1.EnvVar `json:"env,omitempty"`
+
+	// Volumes are added to the terminal pod, for example to share a dataset or a config map of scripts. Only configMap,
+	// secret, emptyDir, persistentVolumeClaim and projected volumes are allowed. The names home and credentials are
+	// reserved for the volumes managed by the operator.
+	Volumes []corev1.Volume `json:"volumes,omitempty"`
+
+	// VolumeMounts are added to the terminal container, and must each mount one of the terminal's volumes.
+	VolumeMounts []corev1.VolumeMount `json:"volumeMounts,omitempty"`
+
+	// PersistentHome provisions a volume for the terminal's home directory.
+	PersistentHome *PersistentHome `json:"persistentHome,omitempty"`
+
+	// Resources are the compute resources of the terminal container. When empty the operator defaults are used.
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// PodTemplateRef selects a ConfigMap key holding a full pod template (as yaml) to use as the base of the terminal
+	// pod, for pods too customized to describe with the other fields. Only the labels and annotations managed by the
+	// operator are applied on top of it. Its containers and volumes are held to the same rules as the terminal's own,
+	// and it may not use the host's namespaces.
+	PodTemplateRef *corev1.ConfigMapKeySelector `json:"podTemplateRef,omitempty"`
+
+	// SetupJob is run to completion before the terminal pod is created, for example to provision a database schema.
+	SetupJob *TerminalSetupJob `json:"setupJob,omitempty"`
+
+	// PreviewImage runs in a separate single replica deployment and service alongside the terminal, for example to
+	// try out a new shell image before rolling it out. It is ignored for terminals which run to completion.
+	PreviewImage string `json:"previewImage,omitempty"`
+
+	// ShutdownWebhookURL is sent a POST request with the terminal's final status when the terminal is deleted,
+	// overriding the operator's default shutdown webhook. It must be an http(s) url on a host allowed by the operator.
+	ShutdownWebhookURL string `json:"shutdownWebhookURL,omitempty"`
+
+	// Ingress exposes the terminal service through an Ingress. It is ignored for terminals which run to completion.
+	Ingress *TerminalIngress `json:"ingress,omitempty"`
+
+	// NetworkPolicy isolates the terminal pods with a NetworkPolicy. When unset the terminal pods are not isolated.
+	NetworkPolicy *TerminalNetworkPolicy `json:"networkPolicy,omitempty"`
+
+	// ImagePullBackOffPolicy is applied when the terminal pod cannot pull its image. When unset the terminal is left
+	// as is.
+	ImagePullBackOffPolicy *ImagePullBackOffPolicy `json:"imagePullBackOffPolicy,omitempty"`
+
+	// SchedulingGates hold the terminal pod pending until they are removed, for example by an external controller
+	// waiting for quota to become available.
+	SchedulingGates []corev1.PodSchedulingGate `json:"schedulingGates,omitempty"`
+
+	// IdleTimeout deletes the terminal once no ssh session has been active for this long. Activity is measured from
+	// the terminal's LastActivityTime status, falling back to its creation time.
+	IdleTimeout *metav1.Duration `json:"idleTimeout,omitempty"`
+
+	// TTL deletes the terminal once it has existed for this long, regardless of whether it is in use.
+	TTL *metav1.Duration `json:"ttl,omitempty"`
 }
 
+const (
+	// TerminalResetAnnotation requests the operator delete and recreate the terminal's children, giving the terminal a
+	// clean slate without deleting it. The annotation is removed once the reset is complete.
+	TerminalResetAnnotation = "marina.io/reset"
+
+	// TerminalChildNamespaceAnnotation recorded the namespace the terminal's children are created in before it moved to
+	// the terminal's status. Since it can be set by anyone able to edit the terminal, it is only honoured when it names
+	// the operator's configured child namespace.
+	TerminalChildNamespaceAnnotation = "marina.io/child-namespace"
+
+	// TerminalPausedAnnotation stops the operator from creating, updating or deleting anything for the terminal while
+	// set to "true", for example to pin the terminal's children during an operator upgrade. Only the terminal's status
+	// is kept up to date, and a deleted terminal is not finalized until the annotation is removed.
+	TerminalPausedAnnotation = "marina.io/paused"
+
+	// TerminalConditionGated is true while the terminal pod is held pending by its scheduling gates.
+	TerminalConditionGated = "Gated"
+
+	// TerminalConditionFailed is true when the terminal cannot be reconciled, for example because its image is not
+	// allowed.
+	TerminalConditionFailed = "Failed"
+
+	// TerminalConditionSetupComplete is true once the terminal's setup job has completed.
+	TerminalConditionSetupComplete = "SetupComplete"
+
+	// TerminalConditionAvailable is true once all of the terminal's pods are available. It is not set for terminals
+	// which run to completion.
+	TerminalConditionAvailable = "Available"
+
+	// TerminalConditionPaused is true while the terminal has the TerminalPausedAnnotation.
+	TerminalConditionPaused = "Paused"
+)
+
 // TerminalStatus defines the observed state of Terminal
 type TerminalStatus struct {
+	// ServiceName is the name of the service exposing the terminal.
+	ServiceName string `json:"serviceName,omitempty"`
+
+	// Endpoint is the in-cluster host:port at which the terminal accepts ssh connections.
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// NodePort is the port on each node at which the terminal accepts ssh connections when using a NodePort service.
+	NodePort int32 `json:"nodePort,omitempty"`
+
+	// ChildNamespace is the namespace the terminal's children are created in when it differs from the terminal's own
+	// namespace. It is set by the operator when the terminal is first reconciled.
+	ChildNamespace string `json:"childNamespace,omitempty"`
+
+	// Conditions describe the current state of the terminal.
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// LastActivityTime is when an ssh session was last active in the terminal. It is reported from inside the
+	// terminal pod, for example by an activity sidecar, and is never written by the operator.
+	LastActivityTime *metav1.Time `json:"lastActivityTime,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -0,0 +1,387 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// log is for logging in this package.
+var terminallog = logf.Log.WithName("terminal-resource")
+
+// SetupWebhookWithManager will setup the manager to manage the webhooks
+func (r *Terminal) SetupWebhookWithManager(mgr ctrl.Manager, defaulter *TerminalCustomDefaulter, validator *TerminalCustomValidator) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		WithDefaulter(defaulter).
+		WithValidator(validator).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/mutate-core-marina-io-v1-terminal,mutating=true,failurePolicy=fail,sideEffects=None,groups=core.marina.io,resources=terminals,verbs=create;update,versions=v1,name=mterminal.marina.io,admissionReviewVersions=v1
+
+// TerminalCustomDefaulter fills in the image of terminals which do not specify one and pins latest images to a known
+// digest.
+type TerminalCustomDefaulter struct {
+	// DefaultImage is the image used by terminals which do not specify one.
+	DefaultImage string
+
+	// LatestDigests maps image repositories (ex docker.io/library/busybox) to the digest their latest tag is pinned to.
+	LatestDigests map[string]string
+}
+
+var _ webhook.CustomDefaulter = &TerminalCustomDefaulter{}
+
+// latestRepository returns the repository of the given image if it refers to the latest tag, either explicitly or by
+// omitting a tag or digest.
+func latestRepository(image string) (string, bool) {
+	if strings.Contains(image, "@") {
+		return "", false
+	}
+
+	// a colon before the last slash belongs to a registry port rather than a tag
+	slash := strings.LastIndex(image, "/")
+	colon := strings.LastIndex(image, ":")
+
+	if colon <= slash {
+		return image, true
+	}
+
+	if image[colon+1:] == "latest" {
+		return image[:colon], true
+	}
+
+	return "", false
+}
+
+// Default implements webhook.CustomDefaulter so a webhook will be registered for the type
+func (d *TerminalCustomDefaulter) Default(ctx context.Context, obj runtime.Object) error {
+	terminal, ok := obj.(*Terminal)
+	if !ok {
+		return fmt.Errorf("expected a Terminal but got a %T", obj)
+	}
+
+	terminallog.Info("default", "name", terminal.Name)
+
+	if terminal.Spec.Image == "" {
+		terminal.Spec.Image = d.DefaultImage
+	}
+
+	if repository, ok := latestRepository(terminal.Spec.Image); ok {
+		if digest, found := d.LatestDigests[repository]; found {
+			terminal.Spec.Image = repository + "@" + digest
+		}
+	}
+
+	return nil
+}
+
+// +kubebuilder:webhook:path=/validate-core-marina-io-v1-terminal,mutating=false,failurePolicy=fail,sideEffects=None,groups=core.marina.io,resources=terminals,verbs=create;update,versions=v1,name=vterminal.marina.io,admissionReviewVersions=v1
+
+// TerminalCustomValidator validates terminal images against the operator's allowed and denied image patterns, ensures
+// terminals only belong to users their requester owns, and limits how many terminals each namespace may hold. Patterns
+// use path.Match syntax (ex docker.io/library/*).
+// +kubebuilder:object:generate=false
+type TerminalCustomValidator struct {
+	// AllowedImages are the image patterns terminals may use. When empty any image not denied is allowed.
+	AllowedImages []string
+
+	// DeniedImages are the image patterns terminals may not use, taking precedence over AllowedImages.
+	DeniedImages []string
+
+	// Reader counts the terminals already in a namespace and fetches the users terminals belong to. It should be backed
+	// by the manager's cache, since every terminal creation is counted.
+	Reader client.Reader
+
+	// Authorizer reviews whether requesters may update the users their terminals belong to, which lets requesters who
+	// are not self-service clients create terminals for users they do not own. When nil terminals may only belong to
+	// users owned by their requester.
+	Authorizer client.Client
+
+	// SelfServiceGroups are the groups identifying self-service clients, which may only create terminals for users
+	// they own.
+	SelfServiceGroups []string
+
+	// MaxPerNamespace is the most terminals a namespace may hold. Namespaces are not limited when 0.
+	MaxPerNamespace int
+}
+
+var _ webhook.CustomValidator = &TerminalCustomValidator{}
+
+// MatchesAnyImage reports whether the image matches any of the given patterns, which use path.Match syntax (ex
+// docker.io/library/*).
+func MatchesAnyImage(patterns []string, image string) (bool, error) {
+	for _, pattern := range patterns {
+		matched, err := path.Match(pattern, image)
+		if err != nil {
+			return false, fmt.Errorf("invalid image pattern '%s': %w", pattern, err)
+		}
+
+		if matched {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (v *TerminalCustomValidator) validateImage(image string) error {
+	if image == "" {
+		return fmt.Errorf("terminal image is required")
+	}
+
+	if denied, err := MatchesAnyImage(v.DeniedImages, image); err != nil {
+		return err
+	} else if denied {
+		return fmt.Errorf("image '%s' is denied", image)
+	}
+
+	if len(v.AllowedImages) == 0 {
+		return nil
+	}
+
+	if allowed, err := MatchesAnyImage(v.AllowedImages, image); err != nil {
+		return err
+	} else if !allowed {
+		return fmt.Errorf("image '%s' is not allowed", image)
+	}
+
+	return nil
+}
+
+// Images returns the images of every container the terminal runs, including its preview and setup job.
+func (r *Terminal) Images() []string {
+	images := []string{r.Spec.Image}
+
+	for _, container := range slices.Concat(r.Spec.InitContainers, r.Spec.Sidecars) {
+		images = append(images, container.Image)
+	}
+
+	if r.Spec.PreviewImage != "" {
+		images = append(images, r.Spec.PreviewImage)
+	}
+
+	if r.Spec.SetupJob != nil {
+		images = append(images, r.Spec.SetupJob.Image)
+	}
+
+	return images
+}
+
+// validateImages validates the image of the terminal container, of each of its additional containers, of its preview
+// and of its setup job.
+func (v *TerminalCustomValidator) validateImages(terminal *Terminal) error {
+	if err := v.validateImage(terminal.Spec.Image); err != nil {
+		return err
+	}
+
+	for _, container := range slices.Concat(terminal.Spec.InitContainers, terminal.Spec.Sidecars) {
+		if err := v.validateImage(container.Image); err != nil {
+			return fmt.Errorf("container '%s': %w", container.Name, err)
+		}
+	}
+
+	if terminal.Spec.PreviewImage != "" {
+		if err := v.validateImage(terminal.Spec.PreviewImage); err != nil {
+			return fmt.Errorf("preview: %w", err)
+		}
+	}
+
+	if terminal.Spec.SetupJob != nil {
+		if err := v.validateImage(terminal.Spec.SetupJob.Image); err != nil {
+			return fmt.Errorf("setup job: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// validateCommand ensures sshd is only requested for terminals which use the operator's command. Terminals which run to
+// completion run their image entrypoint instead, and terminals with a pod template run the template's command, so
+// sshd would silently never start.
+func validateCommand(terminal *Terminal) error {
+	if !terminal.Spec.RunSshd {
+		return nil
+	}
+
+	var errs field.ErrorList
+	path := field.NewPath("spec", "runSshd")
+
+	if terminal.Spec.RunToCompletion {
+		errs = append(errs, field.Invalid(path, true, "cannot be used with runToCompletion, which runs the image entrypoint instead"))
+	}
+
+	if terminal.Spec.PodTemplateRef != nil {
+		errs = append(errs, field.Invalid(path, true, "cannot be used with podTemplateRef, which runs the pod template's command instead"))
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return apierrors.NewInvalid(GroupVersion.WithKind("Terminal").GroupKind(), terminal.Name, errs)
+}
+
+// validateUser ensures the requester owns the user a terminal belongs to, since the terminal runs with the user's uid
+// and service account and mounts the user's credentials. Users are only checked when they are first set, so terminals
+// are not rejected once they exist.
+func (v *TerminalCustomValidator) validateUser(ctx context.Context, terminal *Terminal, old *Terminal) error {
+	if terminal.Spec.User == "" || (old != nil && old.Spec.User == terminal.Spec.User) {
+		return nil
+	}
+
+	req, err := admission.RequestFromContext(ctx)
+	if err != nil {
+		return fmt.Errorf("could not get admission request: %w", err)
+	}
+
+	path := field.NewPath("spec", "user")
+
+	user := &User{}
+	if err := v.Reader.Get(ctx, client.ObjectKey{Name: terminal.Spec.User, Namespace: terminal.Namespace}, user); apierrors.IsNotFound(err) {
+		return apierrors.NewInvalid(GroupVersion.WithKind("Terminal").GroupKind(), terminal.Name, field.ErrorList{
+			field.NotFound(path, terminal.Spec.User),
+		})
+	} else if err != nil {
+		return fmt.Errorf("could not fetch terminal user: %w", err)
+	}
+
+	if user.ownedBy(req.UserInfo) {
+		return nil
+	}
+
+	if v.Authorizer != nil && !isSelfService(req.UserInfo, v.SelfServiceGroups) {
+		allowed, err := accessAllowed(ctx, v.Authorizer, req.UserInfo, &authorizationv1.ResourceAttributes{
+			Namespace: user.Namespace,
+			Verb:      "update",
+			Group:     GroupVersion.Group,
+			Resource:  "users",
+			Name:      user.Name,
+		})
+		if err != nil {
+			return err
+		}
+
+		if allowed {
+			return nil
+		}
+	}
+
+	return apierrors.NewInvalid(GroupVersion.WithKind("Terminal").GroupKind(), terminal.Name, field.ErrorList{
+		field.Forbidden(path, fmt.Sprintf("'%s' does not own user '%s'", req.UserInfo.Username, user.Name)),
+	})
+}
+
+// VolumeSourceAllowed reports whether terminals may use the given volume source. Only config maps, secrets, empty dirs,
+// persistent volume claims and projected volumes are allowed, since other sources (ex hostPath) can expose the node.
+func VolumeSourceAllowed(source corev1.VolumeSource) bool {
+	source.ConfigMap = nil
+	source.Secret = nil
+	source.EmptyDir = nil
+	source.PersistentVolumeClaim = nil
+	source.Projected = nil
+
+	return source == corev1.VolumeSource{}
+}
+
+// validateVolumes rejects terminals with volume sources they are not allowed to use.
+func validateVolumes(terminal *Terminal) error {
+	var errs field.ErrorList
+
+	for i, volume := range terminal.Spec.Volumes {
+		if !VolumeSourceAllowed(volume.VolumeSource) {
+			errs = append(errs, field.Forbidden(field.NewPath("spec", "volumes").Index(i), "only configMap, secret, emptyDir, persistentVolumeClaim and projected volumes are allowed"))
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return apierrors.NewInvalid(GroupVersion.WithKind("Terminal").GroupKind(), terminal.Name, errs)
+}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type
+func (v *TerminalCustomValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	terminal, ok := obj.(*Terminal)
+	if !ok {
+		return nil, fmt.Errorf("expected a Terminal but got a %T", obj)
+	}
+
+	terminallog.Info("validate create", "name", terminal.Name)
+
+	if err := validateCommand(terminal); err != nil {
+		return nil, err
+	}
+
+	if err := validateVolumes(terminal); err != nil {
+		return nil, err
+	}
+
+	if err := validateQuota(ctx, v.Reader, &TerminalList{}, "terminals", terminal, v.MaxPerNamespace); err != nil {
+		return nil, err
+	}
+
+	if err := v.validateUser(ctx, terminal, nil); err != nil {
+		return nil, err
+	}
+
+	return nil, v.validateImages(terminal)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type
+func (v *TerminalCustomValidator) ValidateUpdate(ctx context.Context, oldObj runtime.Object, newObj runtime.Object) (admission.Warnings, error) {
+	old, ok := oldObj.(*Terminal)
+	if !ok {
+		return nil, fmt.Errorf("expected a Terminal but got a %T", oldObj)
+	}
+
+	terminal, ok := newObj.(*Terminal)
+	if !ok {
+		return nil, fmt.Errorf("expected a Terminal but got a %T", newObj)
+	}
+
+	terminallog.Info("validate update", "name", terminal.Name)
+
+	if err := v.validateUser(ctx, terminal, old); err != nil {
+		return nil, err
+	}
+
+	// terminals being deleted are still updated to remove their finalizers, so they are not held to the rules below
+	if terminal.GetDeletionTimestamp() != nil {
+		return nil, nil
+	}
+
+	if err := validateCommand(terminal); err != nil {
+		return nil, err
+	}
+
+	if err := validateVolumes(terminal); err != nil {
+		return nil, err
+	}
+
+	// images are only checked when they change, so tightening the allowed images does not strand existing terminals
+	if slices.Equal(old.Images(), terminal.Images()) {
+		return nil, nil
+	}
+
+	return nil, v.validateImages(terminal)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type
+func (v *TerminalCustomValidator) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
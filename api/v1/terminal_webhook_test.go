@@ -0,0 +1,368 @@
+package v1
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	admissionv1 "k8s.io/api/admission/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+var _ = Describe("Terminal Webhook", func() {
+	var defaulter *TerminalCustomDefaulter
+	var validator *TerminalCustomValidator
+	var terminal *Terminal
+
+	BeforeEach(func() {
+		defaulter = &TerminalCustomDefaulter{
+			DefaultImage: "docker.io/library/busybox",
+			LatestDigests: map[string]string{
+				"docker.io/library/busybox": "sha256:50aa4698fa6262977cff89181b2664b99d8a56dbca847bf62f2ef04854597cf8",
+			},
+		}
+
+		validator = &TerminalCustomValidator{
+			AllowedImages: []string{"docker.io/library/*"},
+			DeniedImages:  []string{"docker.io/library/nginx*"},
+		}
+
+		terminal = &Terminal{
+			ObjectMeta: metav1.ObjectMeta{Name: "terminal-test", Namespace: "marina-system"},
+		}
+	})
+
+	When("a terminal is defaulted", func() {
+		It("should use the default image when none is set", func() {
+			defaulter.LatestDigests = nil
+
+			err := defaulter.Default(context.Background(), terminal)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(terminal.Spec.Image).To(Equal("docker.io/library/busybox"))
+		})
+
+		It("should pin an untagged image to its latest digest", func() {
+			err := defaulter.Default(context.Background(), terminal)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(terminal.Spec.Image).To(Equal("docker.io/library/busybox@sha256:50aa4698fa6262977cff89181b2664b99d8a56dbca847bf62f2ef04854597cf8"))
+		})
+
+		It("should pin a latest image to its digest", func() {
+			terminal.Spec.Image = "docker.io/library/busybox:latest"
+
+			err := defaulter.Default(context.Background(), terminal)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(terminal.Spec.Image).To(Equal("docker.io/library/busybox@sha256:50aa4698fa6262977cff89181b2664b99d8a56dbca847bf62f2ef04854597cf8"))
+		})
+
+		It("should not change a tagged image", func() {
+			terminal.Spec.Image = "docker.io/library/busybox:1.36.0"
+
+			err := defaulter.Default(context.Background(), terminal)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(terminal.Spec.Image).To(Equal("docker.io/library/busybox:1.36.0"))
+		})
+
+		It("should not mistake a registry port for a tag", func() {
+			defaulter.LatestDigests["localhost:5000/busybox"] = "sha256:abc"
+			terminal.Spec.Image = "localhost:5000/busybox"
+
+			err := defaulter.Default(context.Background(), terminal)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(terminal.Spec.Image).To(Equal("localhost:5000/busybox@sha256:abc"))
+		})
+	})
+
+	When("a terminal is created", func() {
+		It("should reject a terminal without an image", func() {
+			_, err := validator.ValidateCreate(context.Background(), terminal)
+			Expect(err).To(MatchError(ContainSubstring("required")))
+		})
+
+		It("should allow an allowed image", func() {
+			terminal.Spec.Image = "docker.io/library/busybox:1.36.0"
+
+			_, err := validator.ValidateCreate(context.Background(), terminal)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should reject an image which is not allowed", func() {
+			terminal.Spec.Image = "quay.io/evil/busybox:1.36.0"
+
+			_, err := validator.ValidateCreate(context.Background(), terminal)
+			Expect(err).To(MatchError(ContainSubstring("not allowed")))
+		})
+
+		It("should reject a denied image even if it is allowed", func() {
+			terminal.Spec.Image = "docker.io/library/nginx:1.27"
+
+			_, err := validator.ValidateCreate(context.Background(), terminal)
+			Expect(err).To(MatchError(ContainSubstring("denied")))
+		})
+
+		It("should allow any image not denied when no images are allowed", func() {
+			validator.AllowedImages = nil
+			terminal.Spec.Image = "quay.io/evil/busybox:1.36.0"
+
+			_, err := validator.ValidateCreate(context.Background(), terminal)
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	When("a terminal runs sshd", func() {
+		BeforeEach(func() {
+			terminal.Spec.Image = "docker.io/library/busybox:1.36.0"
+			terminal.Spec.RunSshd = true
+		})
+
+		It("should allow a terminal using the operator's command", func() {
+			_, err := validator.ValidateCreate(context.Background(), terminal)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should reject a terminal which runs to completion", func() {
+			terminal.Spec.RunToCompletion = true
+
+			_, err := validator.ValidateCreate(context.Background(), terminal)
+			Expect(err).To(MatchError(ContainSubstring("runToCompletion")))
+		})
+
+		It("should reject a terminal with a pod template", func() {
+			old := terminal.DeepCopy()
+			terminal.Spec.PodTemplateRef = &corev1.ConfigMapKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "terminal-template"},
+				Key:                  "template.yaml",
+			}
+
+			_, err := validator.ValidateUpdate(context.Background(), old, terminal)
+			Expect(err).To(MatchError(ContainSubstring("podTemplateRef")))
+		})
+	})
+
+	When("a terminal has additional volumes", func() {
+		BeforeEach(func() {
+			terminal.Spec.Image = "docker.io/library/busybox:1.36.0"
+			terminal.Spec.Volumes = []corev1.Volume{
+				{
+					Name: "dataset",
+					VolumeSource: corev1.VolumeSource{
+						PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "dataset"},
+					},
+				},
+			}
+		})
+
+		It("should allow a persistent volume claim", func() {
+			_, err := validator.ValidateCreate(context.Background(), terminal)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should reject a host path", func() {
+			old := terminal.DeepCopy()
+			terminal.Spec.Volumes[0].VolumeSource = corev1.VolumeSource{
+				HostPath: &corev1.HostPathVolumeSource{Path: "/var/run/docker.sock"},
+			}
+
+			_, err := validator.ValidateUpdate(context.Background(), old, terminal)
+			Expect(err).To(MatchError(ContainSubstring("spec.volumes[0]")))
+		})
+	})
+
+	When("a namespace is limited to a number of terminals", func() {
+		BeforeEach(func() {
+			scheme := runtime.NewScheme()
+			Expect(AddToScheme(scheme)).To(Succeed())
+
+			existing := &Terminal{
+				ObjectMeta: metav1.ObjectMeta{Name: "terminal-existing", Namespace: "marina-system"},
+			}
+
+			validator.Reader = fake.NewClientBuilder().WithScheme(scheme).WithObjects(existing).Build()
+			terminal.Spec.Image = "docker.io/library/busybox:1.36.0"
+		})
+
+		It("should allow a terminal within the limit", func() {
+			validator.MaxPerNamespace = 2
+
+			_, err := validator.ValidateCreate(context.Background(), terminal)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should reject a terminal beyond the limit", func() {
+			validator.MaxPerNamespace = 1
+
+			_, err := validator.ValidateCreate(context.Background(), terminal)
+			Expect(apierrors.IsForbidden(err)).To(BeTrue())
+		})
+
+		It("should only count terminals in the same namespace", func() {
+			validator.MaxPerNamespace = 1
+			terminal.Namespace = "marina-other"
+
+			_, err := validator.ValidateCreate(context.Background(), terminal)
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	When("a terminal belongs to a user", func() {
+		var allowed bool
+
+		BeforeEach(func() {
+			scheme := runtime.NewScheme()
+			Expect(AddToScheme(scheme)).To(Succeed())
+			Expect(authorizationv1.AddToScheme(scheme)).To(Succeed())
+
+			user := &User{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "bilbo",
+					Namespace:   "marina-system",
+					Annotations: map[string]string{UserCreatorAnnotation: "gandalf"},
+				},
+			}
+
+			allowed = false
+			validator.Reader = fake.NewClientBuilder().WithScheme(scheme).WithObjects(user).Build()
+			validator.Authorizer = fake.NewClientBuilder().WithScheme(scheme).WithInterceptorFuncs(interceptor.Funcs{
+				Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+					review := obj.(*authorizationv1.SubjectAccessReview)
+					Expect(review.Spec.ResourceAttributes.Resource).To(Equal("users"))
+					Expect(review.Spec.ResourceAttributes.Name).To(Equal("bilbo"))
+
+					review.Status.Allowed = allowed
+					return nil
+				},
+			}).Build()
+			validator.SelfServiceGroups = []string{"portal"}
+
+			terminal.Spec.Image = "docker.io/library/busybox:1.36.0"
+			terminal.Spec.User = "bilbo"
+		})
+
+		It("should allow the user's creator", func() {
+			_, err := validator.ValidateCreate(contextForRequest(admissionv1.Create, "gandalf", nil), terminal)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should allow the user's service account", func() {
+			_, err := validator.ValidateCreate(contextForRequest(admissionv1.Create, "system:serviceaccount:marina-system:bilbo", nil), terminal)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should allow a requester who may update the user", func() {
+			allowed = true
+
+			_, err := validator.ValidateCreate(contextForRequest(admissionv1.Create, "elrond", nil), terminal)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should reject a requester who does not own the user", func() {
+			old := terminal.DeepCopy()
+			old.Spec.User = ""
+
+			_, err := validator.ValidateUpdate(contextForRequest(admissionv1.Update, "saruman", nil), old, terminal)
+			Expect(err).To(MatchError(ContainSubstring("'saruman' does not own user 'bilbo'")))
+		})
+
+		It("should reject a self-service client who may update the user", func() {
+			allowed = true
+
+			_, err := validator.ValidateCreate(contextForRequest(admissionv1.Create, "saruman", nil, "portal"), terminal)
+			Expect(err).To(MatchError(ContainSubstring("'saruman' does not own user 'bilbo'")))
+		})
+
+		It("should reject a user which does not exist", func() {
+			terminal.Spec.User = "smeagol"
+
+			_, err := validator.ValidateCreate(contextForRequest(admissionv1.Create, "gandalf", nil), terminal)
+			Expect(err).To(MatchError(ContainSubstring("spec.user: Not found")))
+		})
+
+		It("should reject switching a terminal to a user the requester does not own", func() {
+			old := terminal.DeepCopy()
+			old.Spec.User = "frodo"
+
+			_, err := validator.ValidateUpdate(contextForRequest(admissionv1.Update, "frodo", nil), old, terminal)
+			Expect(err).To(MatchError(ContainSubstring("'frodo' does not own user 'bilbo'")))
+		})
+
+		It("should not check a user which has not changed", func() {
+			_, err := validator.ValidateUpdate(contextForRequest(admissionv1.Update, "saruman", nil), terminal.DeepCopy(), terminal)
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	When("a terminal image is updated", func() {
+		It("should reject a denied image", func() {
+			old := terminal.DeepCopy()
+			old.Spec.Image = "docker.io/library/busybox:1.36.0"
+			terminal.Spec.Image = "docker.io/library/nginx:1.27"
+
+			_, err := validator.ValidateUpdate(context.Background(), old, terminal)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should reject a denied init container image", func() {
+			terminal.Spec.Image = "docker.io/library/busybox:1.36.0"
+			old := terminal.DeepCopy()
+			terminal.Spec.InitContainers = []corev1.Container{{Name: "setup", Image: "docker.io/library/nginx:1.27"}}
+
+			_, err := validator.ValidateUpdate(context.Background(), old, terminal)
+			Expect(err).To(MatchError(ContainSubstring("container 'setup'")))
+		})
+
+		It("should reject a denied sidecar image", func() {
+			terminal.Spec.Image = "docker.io/library/busybox:1.36.0"
+			terminal.Spec.Sidecars = []corev1.Container{{Name: "proxy", Image: "docker.io/library/nginx:1.27"}}
+
+			_, err := validator.ValidateCreate(context.Background(), terminal)
+			Expect(err).To(MatchError(ContainSubstring("container 'proxy'")))
+		})
+
+		It("should reject a denied preview image", func() {
+			terminal.Spec.Image = "docker.io/library/busybox:1.36.0"
+			old := terminal.DeepCopy()
+			terminal.Spec.PreviewImage = "docker.io/library/nginx:1.27"
+
+			_, err := validator.ValidateUpdate(context.Background(), old, terminal)
+			Expect(err).To(MatchError(ContainSubstring("preview")))
+		})
+
+		It("should reject a denied setup job image", func() {
+			terminal.Spec.Image = "docker.io/library/busybox:1.36.0"
+			terminal.Spec.SetupJob = &TerminalSetupJob{Image: "docker.io/library/nginx:1.27"}
+
+			_, err := validator.ValidateCreate(context.Background(), terminal)
+			Expect(err).To(MatchError(ContainSubstring("setup job")))
+		})
+
+		It("should not recheck an image which has not changed", func() {
+			terminal.Spec.Image = "docker.io/library/busybox:1.36.0"
+			old := terminal.DeepCopy()
+			terminal.Labels = map[string]string{"team": "fellowship"}
+			validator.DeniedImages = []string{"docker.io/library/busybox*"}
+
+			_, err := validator.ValidateUpdate(context.Background(), old, terminal)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should allow a terminal being deleted to drop its finalizers after its image is denied", func() {
+			terminal.Spec.Image = "docker.io/library/busybox:1.36.0"
+			terminal.Finalizers = []string{"marina.io.deployment/finalizer"}
+			terminal.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+			old := terminal.DeepCopy()
+			terminal.Finalizers = nil
+			validator.AllowedImages = []string{"quay.io/fellowship/*"}
+
+			_, err := validator.ValidateUpdate(context.Background(), old, terminal)
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+})
@@ -1,21 +1,122 @@
 package v1
 
 import (
+	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
 // NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
 
+// AccessSchedule is a recurring window during which a user's roles are granted.
+type AccessSchedule struct {
+	// Schedule is a standard 5 field cron schedule (minute, hour, day of month, month, day of week) at which each
+	// access window opens.
+	Schedule string `json:"schedule"`
+
+	// Duration is how long each access window stays open.
+	Duration metav1.Duration `json:"duration"`
+}
+
 // UserSpec defines the desired state of User
 type UserSpec struct {
-	Name     string   `json:"name"`
-	Password []byte   `json:"password"`
+	// Name is the user's linux username, which must be a valid DNS-1123 label. When empty the name of the User is used.
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// Password is the user's plaintext password. It is hashed into the user's credentials secret and cleared once
+	// reconciled, so setting it again rotates the password.
+	Password []byte   `json:"password,omitempty"`
 	Roles    []string `json:"roles,omitempty"`
+
+	// AuthorizedKeys are the OpenSSH public keys the user may authenticate with, in authorized_keys format. They are
+	// written to the user's credentials secret.
+	AuthorizedKeys []string `json:"authorizedKeys,omitempty"`
+
+	// ClusterRoles are bound to the user cluster wide. The operator must be allowed to bind each of them, see the
+	// README.
+	ClusterRoles []string `json:"clusterRoles,omitempty"`
+
+	// InlineRoles are the rules of a role created for the user in the user's namespace, named "<name>-inline", for
+	// permissions which no existing role grants. Requesters may only grant permissions they already hold, so inline
+	// roles are only granted when the user webhook is enabled.
+	InlineRoles []rbacv1.PolicyRule `json:"inlineRoles,omitempty"`
+
+	// GrantNamespace is the namespace the user's roles are bound in, defaulting to the user's namespace. This allows
+	// users to be kept in a central namespace while granting them access to workload namespaces.
+	GrantNamespace string `json:"grantNamespace,omitempty"`
+
+	// ProvisionNamespace creates a namespace dedicated to the user, named "marina-user-<name>", which the user's service
+	// account is created in and which the user administers. The namespace is deleted along with the user, and cannot be
+	// enabled or disabled once the user is created.
+	ProvisionNamespace bool `json:"provisionNamespace,omitempty"`
+
+	// OIDCGroups are the OIDC groups the user belongs to, recorded for consumption by the cluster's auth layer.
+	OIDCGroups []string `json:"oidcGroups,omitempty"`
+
+	// ExpiresAt is when the user is deleted. Shortly before expiring the user is suspended, revoking their roles until
+	// they are either deleted or their expiry is extended.
+	ExpiresAt *metav1.Time `json:"expiresAt,omitempty"`
+
+	// AccessSchedule limits when the user's roles are granted, for example to business hours. Outside of the schedule
+	// the user's roles are revoked until the next window opens. When unset the user's roles are always granted.
+	AccessSchedule *AccessSchedule `json:"accessSchedule,omitempty"`
+}
+
+// RoleGrant records the identity which requested a role be granted to a user.
+type RoleGrant struct {
+	Role      string `json:"role"`
+	Requester string `json:"requester"`
 }
 
+const (
+	// UserConditionReady is true once the user's service account and role bindings have been reconciled, and false
+	// while the user's roles are revoked.
+	UserConditionReady = "Ready"
+
+	// UserConditionFailed is true once the user has failed to reconcile too many times in a row. The user is then
+	// retried less often, and the condition is removed once it reconciles.
+	UserConditionFailed = "Failed"
+
+	// UserConditionPaused is true while the user is paused by the UserPausedAnnotation.
+	UserConditionPaused = "Paused"
+
+	// UserConditionExpiring is true while the user has an expiry, and reports when the user expires.
+	UserConditionExpiring = "Expiring"
+
+	// UserPausedAnnotation stops the operator from creating, updating or deleting anything for the user while set to
+	// "true", for example to pin the user's children during an operator upgrade. Only the user's status is kept up to
+	// date, and a deleted user is not finalized until the annotation is removed. Users which are suspended, expired, or
+	// outside their access schedule are still reconciled, so pausing a user cannot extend their access. Only admins may
+	// set it when webhooks are enabled.
+	UserPausedAnnotation = "marina.io/paused"
+)
+
 // UserStatus defines the observed state of User
 type UserStatus struct {
+	// RoleGrants lists who requested each of the user's roles.
+	RoleGrants []RoleGrant `json:"roleGrants,omitempty"`
+
+	// PasswordRotatedAt is the last time the user's password was rotated.
+	PasswordRotatedAt *metav1.Time `json:"passwordRotatedAt,omitempty"`
+
+	// UID is the unique numeric id assigned to the user, which their terminals run as. It is also used as the user's
+	// group id.
+	UID int64 `json:"uid,omitempty"`
+
+	// Suspended is true while the user's roles are revoked ahead of their expiry.
+	Suspended bool `json:"suspended,omitempty"`
+
+	// ServiceAccountName is the name of the user's service account.
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+
+	// RoleBindings are the names of the role bindings created for the user.
+	RoleBindings []string `json:"roleBindings,omitempty"`
+
+	// Conditions describe the current state of the user.
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -0,0 +1,681 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const (
+	// UserRoleGrantsAnnotation holds a json object mapping each of the user's roles to the identity which requested it.
+	UserRoleGrantsAnnotation = "marina.io/role-grants"
+
+	// UserCreatorAnnotation records the identity which created the user. Like the role grants, it is set by the
+	// webhook and cannot be changed by requesters.
+	UserCreatorAnnotation = "marina.io/creator"
+)
+
+// log is for logging in this package.
+var userlog = logf.Log.WithName("user-resource")
+
+// SetupWebhookWithManager will setup the manager to manage the webhooks
+func (r *User) SetupWebhookWithManager(mgr ctrl.Manager, validator *UserCustomValidator) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		WithDefaulter(&UserCustomDefaulter{}).
+		WithValidator(validator).
+		Complete()
+}
+
+// RoleGrants returns the role grants recorded in the user's annotations.
+func (r *User) RoleGrants() (map[string]string, error) {
+	grants := make(map[string]string)
+
+	raw, found := r.Annotations[UserRoleGrantsAnnotation]
+	if !found {
+		return grants, nil
+	}
+
+	if err := json.Unmarshal([]byte(raw), &grants); err != nil {
+		return nil, fmt.Errorf("could not parse role grants: %w", err)
+	}
+
+	return grants, nil
+}
+
+// ProvisionedNamespaceName returns the name of the namespace provisioned for the user when ProvisionNamespace is set.
+func (r *User) ProvisionedNamespaceName() string {
+	return "marina-user-" + r.Name
+}
+
+// Username returns the user's linux username, falling back to the name of the User for users created without one,
+// for example while webhooks are disabled.
+func (r *User) Username() string {
+	if r.Spec.Name == "" {
+		return r.Name
+	}
+
+	return r.Spec.Name
+}
+
+// ServiceAccountNamespace returns the namespace of the user's service account, which is the user's provisioned
+// namespace when it has one.
+func (r *User) ServiceAccountNamespace() string {
+	if r.Spec.ProvisionNamespace {
+		return r.ProvisionedNamespaceName()
+	}
+
+	return r.Namespace
+}
+
+// +kubebuilder:webhook:path=/mutate-core-marina-io-v1-user,mutating=true,failurePolicy=fail,sideEffects=None,groups=core.marina.io,resources=users,verbs=create;update,versions=v1,name=muser.marina.io,admissionReviewVersions=v1
+
+// UserCustomDefaulter defaults the username of a User to its name, and records the identity creating it and requesting
+// each role granted to it.
+type UserCustomDefaulter struct{}
+
+var _ webhook.CustomDefaulter = &UserCustomDefaulter{}
+
+// Default implements webhook.CustomDefaulter so a webhook will be registered for the type
+func (d *UserCustomDefaulter) Default(ctx context.Context, obj runtime.Object) error {
+	user, ok := obj.(*User)
+	if !ok {
+		return fmt.Errorf("expected a User but got a %T", obj)
+	}
+
+	req, err := admission.RequestFromContext(ctx)
+	if err != nil {
+		return fmt.Errorf("could not get admission request: %w", err)
+	}
+
+	userlog.Info("default", "name", user.Name, "requester", req.UserInfo.Username)
+
+	if user.Spec.Name == "" {
+		user.Spec.Name = user.Name
+	}
+
+	// the existing grants and creator are taken from the stored object so requesters cannot forge the annotations
+	previous := make(map[string]string)
+	creator := req.UserInfo.Username
+	if req.Operation == admissionv1.Update && len(req.OldObject.Raw) > 0 {
+		old := &User{}
+		if err := json.Unmarshal(req.OldObject.Raw, old); err != nil {
+			return fmt.Errorf("could not decode old user: %w", err)
+		}
+
+		if previous, err = old.RoleGrants(); err != nil {
+			return err
+		}
+
+		creator = old.Annotations[UserCreatorAnnotation]
+	}
+
+	grants := make(map[string]string, len(user.Spec.Roles))
+	for _, role := range user.Spec.Roles {
+		if requester, found := previous[role]; found {
+			grants[role] = requester
+		} else {
+			grants[role] = req.UserInfo.Username
+		}
+	}
+
+	raw, err := json.Marshal(grants)
+	if err != nil {
+		return fmt.Errorf("could not encode role grants: %w", err)
+	}
+
+	if user.Annotations == nil {
+		user.Annotations = make(map[string]string)
+	}
+	user.Annotations[UserRoleGrantsAnnotation] = string(raw)
+
+	// users created before their creator was recorded have no creator
+	if creator == "" {
+		delete(user.Annotations, UserCreatorAnnotation)
+	} else {
+		user.Annotations[UserCreatorAnnotation] = creator
+	}
+
+	return nil
+}
+
+// +kubebuilder:webhook:path=/validate-core-marina-io-v1-user,mutating=false,failurePolicy=fail,sideEffects=None,groups=core.marina.io,resources=users,verbs=create;update,versions=v1,name=vuser.marina.io,admissionReviewVersions=v1
+
+// UserCustomValidator restricts self-service clients to the Users they own and the roles and cluster roles
+// pre-approved by an admin, reviews the inline roles, roles and cluster roles granted to a User, and
+// limits how many users each namespace may hold.
+// +kubebuilder:object:generate=false
+type UserCustomValidator struct {
+	// SelfServiceGroups are the groups identifying self-service clients. When empty no requester is restricted.
+	SelfServiceGroups []string
+
+	// SelfServiceRoles are the roles self-service clients may add to a user.
+	SelfServiceRoles []string
+
+	// SelfServiceClusterRoles are the cluster roles self-service clients may add to a user.
+	SelfServiceClusterRoles []string
+
+	// Authorizer reviews whether requesters hold the permissions they grant through inline roles, and whether they may
+	// bind the roles and cluster roles they add. When nil none of them may be set, except for the roles and cluster
+	// roles approved for self-service clients.
+	Authorizer client.Client
+
+	// Reader counts the users already in a namespace. It should be backed by the manager's cache, since every user
+	// creation is counted.
+	Reader client.Reader
+
+	// MaxPerNamespace is the most users a namespace may hold. Namespaces are not limited when 0.
+	MaxPerNamespace int
+}
+
+var _ webhook.CustomValidator = &UserCustomValidator{}
+
+// validateSelfService restricts self-service clients to updating the users they own, adding only pre-approved roles and
+// cluster roles, and leaving the fields reserved for admins alone.
+func (v *UserCustomValidator) validateSelfService(ctx context.Context, user *User, old *User) error {
+	req, err := admission.RequestFromContext(ctx)
+	if err != nil {
+		return fmt.Errorf("could not get admission request: %w", err)
+	}
+
+	if !isSelfService(req.UserInfo, v.SelfServiceGroups) {
+		return nil
+	}
+
+	if old != nil && !old.ownedBy(req.UserInfo) {
+		return apierrors.NewForbidden(GroupVersion.WithResource("users").GroupResource(), user.Name,
+			fmt.Errorf("self-service clients may only update users they own"))
+	}
+
+	if err := validateAdminFields(user, old); err != nil {
+		return err
+	}
+
+	var previous []string
+	var previousClusterRoles []string
+	if old != nil {
+		previous = old.Spec.Roles
+		previousClusterRoles = old.Spec.ClusterRoles
+	}
+
+	for _, role := range user.Spec.Roles {
+		// roles already on the user were approved when they were added
+		if slices.Contains(previous, role) {
+			continue
+		}
+
+		if !slices.Contains(v.SelfServiceRoles, role) {
+			return fmt.Errorf("role '%s' is not approved for self-service", role)
+		}
+	}
+
+	for _, clusterRole := range user.Spec.ClusterRoles {
+		if slices.Contains(previousClusterRoles, clusterRole) {
+			continue
+		}
+
+		if !slices.Contains(v.SelfServiceClusterRoles, clusterRole) {
+			return fmt.Errorf("cluster role '%s' is not approved for self-service", clusterRole)
+		}
+	}
+
+	return nil
+}
+
+// validateAdminFields rejects setting or changing the fields reserved for admins, which control where and for how long
+// the user's roles are granted, which groups the user authenticates with, and whether the operator manages the user at
+// all.
+func validateAdminFields(user *User, old *User) error {
+	previous := &User{}
+	if old != nil {
+		previous = old
+	}
+
+	var errs field.ErrorList
+	spec := field.NewPath("spec")
+	msg := "may only be changed by admins"
+
+	if user.Spec.GrantNamespace != previous.Spec.GrantNamespace {
+		errs = append(errs, field.Forbidden(spec.Child("grantNamespace"), msg))
+	}
+
+	if user.Spec.ProvisionNamespace != previous.Spec.ProvisionNamespace {
+		errs = append(errs, field.Forbidden(spec.Child("provisionNamespace"), msg))
+	}
+
+	if !equality.Semantic.DeepEqual(user.Spec.ExpiresAt, previous.Spec.ExpiresAt) {
+		errs = append(errs, field.Forbidden(spec.Child("expiresAt"), msg))
+	}
+
+	if !slices.Equal(user.Spec.OIDCGroups, previous.Spec.OIDCGroups) {
+		errs = append(errs, field.Forbidden(spec.Child("oidcGroups"), msg))
+	}
+
+	if !equality.Semantic.DeepEqual(user.Spec.AccessSchedule, previous.Spec.AccessSchedule) {
+		errs = append(errs, field.Forbidden(spec.Child("accessSchedule"), msg))
+	}
+
+	if user.Annotations[UserPausedAnnotation] != previous.Annotations[UserPausedAnnotation] {
+		errs = append(errs, field.Forbidden(field.NewPath("metadata", "annotations").Key(UserPausedAnnotation), msg))
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return apierrors.NewInvalid(GroupVersion.WithKind("User").GroupKind(), user.Name, errs)
+}
+
+// reviewAccess returns a forbidden error for the given path unless the requester may perform the given action.
+func (v *UserCustomValidator) reviewAccess(ctx context.Context, userInfo authenticationv1.UserInfo, path *field.Path, attributes authorizationv1.ResourceAttributes) (*field.Error, error) {
+	if v.Authorizer == nil {
+		return field.Forbidden(path, "cannot be reviewed"), nil
+	}
+
+	allowed, err := accessAllowed(ctx, v.Authorizer, userInfo, &attributes)
+	if err != nil {
+		return nil, err
+	}
+
+	if !allowed {
+		return field.Forbidden(path, fmt.Sprintf("'%s' may not %s", userInfo.Username, describeAttributes(attributes))), nil
+	}
+
+	return nil, nil
+}
+
+// validateRoles ensures the requester may create role bindings in the namespace the user's roles are bound in and may
+// bind each of the roles there, since the operator binds them with its own permissions. Every role is reviewed when the
+// grant namespace changes, otherwise only the roles added to the user. Self-service clients are not reviewed for the
+// roles an admin approved for them, and may not change the grant namespace at all.
+func (v *UserCustomValidator) validateRoles(ctx context.Context, user *User, old *User) error {
+	req, err := admission.RequestFromContext(ctx)
+	if err != nil {
+		return fmt.Errorf("could not get admission request: %w", err)
+	}
+
+	previous := &User{}
+	if old != nil {
+		previous = old
+	}
+
+	namespace := user.Spec.GrantNamespace
+	path := field.NewPath("spec", "grantNamespace")
+	if namespace == "" {
+		namespace = user.Namespace
+		path = field.NewPath("spec", "roles")
+	}
+
+	grantNamespaceChanged := user.Spec.GrantNamespace != previous.Spec.GrantNamespace
+	selfService := isSelfService(req.UserInfo, v.SelfServiceGroups)
+
+	var reviewed []int
+	for i, role := range user.Spec.Roles {
+		// roles already on the user were reviewed when they were added, unless they are now bound elsewhere
+		if !grantNamespaceChanged && slices.Contains(previous.Spec.Roles, role) {
+			continue
+		}
+
+		if selfService && slices.Contains(v.SelfServiceRoles, role) {
+			continue
+		}
+
+		reviewed = append(reviewed, i)
+	}
+
+	if len(reviewed) == 0 && (!grantNamespaceChanged || user.Spec.GrantNamespace == "") {
+		return nil
+	}
+
+	var errs field.ErrorList
+
+	fieldErr, err := v.reviewAccess(ctx, req.UserInfo, path, authorizationv1.ResourceAttributes{
+		Namespace: namespace,
+		Verb:      "create",
+		Group:     rbacv1.GroupName,
+		Resource:  "rolebindings",
+	})
+	if err != nil {
+		return err
+	}
+
+	if fieldErr != nil {
+		errs = append(errs, fieldErr)
+	}
+
+	for _, i := range reviewed {
+		fieldErr, err := v.reviewAccess(ctx, req.UserInfo, field.NewPath("spec", "roles").Index(i), authorizationv1.ResourceAttributes{
+			Namespace: namespace,
+			Verb:      "bind",
+			Group:     rbacv1.GroupName,
+			Resource:  "roles",
+			Name:      user.Spec.Roles[i],
+		})
+		if err != nil {
+			return err
+		}
+
+		if fieldErr != nil {
+			errs = append(errs, fieldErr)
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return apierrors.NewInvalid(GroupVersion.WithKind("User").GroupKind(), user.Name, errs)
+}
+
+// validateClusterRoles ensures the requester may bind each cluster role it adds to the user, since the operator binds
+// them cluster wide with its own permissions. Self-service clients are not reviewed for the cluster roles an admin
+// approved for them.
+func (v *UserCustomValidator) validateClusterRoles(ctx context.Context, user *User, old *User) error {
+	req, err := admission.RequestFromContext(ctx)
+	if err != nil {
+		return fmt.Errorf("could not get admission request: %w", err)
+	}
+
+	var previous []string
+	if old != nil {
+		previous = old.Spec.ClusterRoles
+	}
+
+	selfService := isSelfService(req.UserInfo, v.SelfServiceGroups)
+
+	var errs field.ErrorList
+	path := field.NewPath("spec", "clusterRoles")
+
+	for i, clusterRole := range user.Spec.ClusterRoles {
+		// cluster roles already on the user were reviewed when they were added
+		if slices.Contains(previous, clusterRole) || (selfService && slices.Contains(v.SelfServiceClusterRoles, clusterRole)) {
+			continue
+		}
+
+		fieldErr, err := v.reviewAccess(ctx, req.UserInfo, path.Index(i), authorizationv1.ResourceAttributes{
+			Verb:     "bind",
+			Group:    rbacv1.GroupName,
+			Resource: "clusterroles",
+			Name:     clusterRole,
+		})
+		if err != nil {
+			return err
+		}
+
+		if fieldErr != nil {
+			errs = append(errs, fieldErr)
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return apierrors.NewInvalid(GroupVersion.WithKind("User").GroupKind(), user.Name, errs)
+}
+
+// validateInlineRoles ensures the requester already holds every permission it grants through the user's inline roles,
+// mirroring the escalation check the api server applies to roles. The api server only checks the rules against the
+// operator's own permissions, so the operator would otherwise grant any permission it holds on the requester's behalf.
+func (v *UserCustomValidator) validateInlineRoles(ctx context.Context, user *User, old *User) error {
+	if old != nil && equality.Semantic.DeepEqual(user.Spec.InlineRoles, old.Spec.InlineRoles) {
+		return nil
+	}
+
+	if len(user.Spec.InlineRoles) == 0 {
+		return nil
+	}
+
+	req, err := admission.RequestFromContext(ctx)
+	if err != nil {
+		return fmt.Errorf("could not get admission request: %w", err)
+	}
+
+	var errs field.ErrorList
+	path := field.NewPath("spec", "inlineRoles")
+
+	for i, rule := range user.Spec.InlineRoles {
+		if len(rule.NonResourceURLs) > 0 {
+			errs = append(errs, field.Forbidden(path.Index(i).Child("nonResourceURLs"), "roles may not grant non-resource urls"))
+			continue
+		}
+
+		if v.Authorizer == nil {
+			errs = append(errs, field.Forbidden(path.Index(i), "inline roles cannot be reviewed"))
+			continue
+		}
+
+		names := rule.ResourceNames
+		if len(names) == 0 {
+			names = []string{""}
+		}
+
+		for _, attributes := range ruleAttributes(user.Namespace, rule, names) {
+			allowed, err := accessAllowed(ctx, v.Authorizer, req.UserInfo, &attributes)
+			if err != nil {
+				return err
+			}
+
+			if !allowed {
+				errs = append(errs, field.Forbidden(path.Index(i), fmt.Sprintf("'%s' may not %s", req.UserInfo.Username, describeAttributes(attributes))))
+				break
+			}
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return apierrors.NewInvalid(GroupVersion.WithKind("User").GroupKind(), user.Name, errs)
+}
+
+// ruleAttributes expands a policy rule into the attributes of each action it allows in the given namespace.
+func ruleAttributes(namespace string, rule rbacv1.PolicyRule, names []string) []authorizationv1.ResourceAttributes {
+	var attributes []authorizationv1.ResourceAttributes
+
+	for _, group := range rule.APIGroups {
+		for _, resource := range rule.Resources {
+			resource, subresource, _ := strings.Cut(resource, "/")
+
+			for _, verb := range rule.Verbs {
+				for _, name := range names {
+					attributes = append(attributes, authorizationv1.ResourceAttributes{
+						Namespace:   namespace,
+						Verb:        verb,
+						Group:       group,
+						Resource:    resource,
+						Subresource: subresource,
+						Name:        name,
+					})
+				}
+			}
+		}
+	}
+
+	return attributes
+}
+
+// describeAttributes describes the action of the given attributes for error messages (ex get secrets/foo).
+func describeAttributes(attributes authorizationv1.ResourceAttributes) string {
+	resource := attributes.Resource
+	if attributes.Subresource != "" {
+		resource += "/" + attributes.Subresource
+	}
+
+	if attributes.Group != "" {
+		resource += "." + attributes.Group
+	}
+
+	if attributes.Name != "" {
+		resource += "/" + attributes.Name
+	}
+
+	return attributes.Verb + " " + resource
+}
+
+// validateUniqueEntries returns an error for each entry in the list which duplicates an earlier entry.
+func validateUniqueEntries(path *field.Path, entries []string) field.ErrorList {
+	var errs field.ErrorList
+
+	for i, entry := range entries {
+		if slices.Contains(entries[:i], entry) {
+			errs = append(errs, field.Duplicate(path.Index(i), entry))
+		}
+	}
+
+	return errs
+}
+
+// validateAuthorizedKeys ensures each entry is a single OpenSSH public key. Entries may not span lines, since that
+// would let one entry add others to the user's authorized_keys.
+func validateAuthorizedKeys(path *field.Path, keys []string) field.ErrorList {
+	var errs field.ErrorList
+
+	for i, key := range keys {
+		if strings.ContainsAny(key, "\r\n") {
+			errs = append(errs, field.Invalid(path.Index(i), key, "must be a single line"))
+			continue
+		}
+
+		if _, _, _, _, err := ssh.ParseAuthorizedKey([]byte(key)); err != nil {
+			errs = append(errs, field.Invalid(path.Index(i), key, fmt.Sprintf("must be an OpenSSH public key: %s", err)))
+		}
+	}
+
+	return errs
+}
+
+// validateSpec rejects users whose username cannot be used as a linux username, or who list the same role or cluster
+// role more than once, since each would be bound under the same name. The username is only checked when it changes, so
+// users created before it was validated can still be updated.
+func validateSpec(user *User, old *User) error {
+	var errs field.ErrorList
+
+	if old == nil || old.Username() != user.Username() {
+		for _, msg := range validation.IsDNS1123Label(user.Username()) {
+			errs = append(errs, field.Invalid(field.NewPath("spec", "name"), user.Username(), msg))
+		}
+	}
+
+	errs = append(errs, validateUniqueEntries(field.NewPath("spec", "roles"), user.Spec.Roles)...)
+	errs = append(errs, validateUniqueEntries(field.NewPath("spec", "clusterRoles"), user.Spec.ClusterRoles)...)
+	errs = append(errs, validateAuthorizedKeys(field.NewPath("spec", "authorizedKeys"), user.Spec.AuthorizedKeys)...)
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return apierrors.NewInvalid(GroupVersion.WithKind("User").GroupKind(), user.Name, errs)
+}
+
+// validateImmutable rejects changes to fields which cannot change once a user is created. Provisioning a namespace
+// moves the user's service account, which would orphan the service account and its bindings.
+func validateImmutable(old *User, user *User) error {
+	var errs field.ErrorList
+
+	if user.Spec.ProvisionNamespace != old.Spec.ProvisionNamespace {
+		errs = append(errs, field.Forbidden(field.NewPath("spec", "provisionNamespace"), "field is immutable"))
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return apierrors.NewInvalid(GroupVersion.WithKind("User").GroupKind(), user.Name, errs)
+}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type
+func (v *UserCustomValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	user, ok := obj.(*User)
+	if !ok {
+		return nil, fmt.Errorf("expected a User but got a %T", obj)
+	}
+
+	userlog.Info("validate create", "name", user.Name)
+
+	if err := validateSpec(user, nil); err != nil {
+		return nil, err
+	}
+
+	if err := validateQuota(ctx, v.Reader, &UserList{}, "users", user, v.MaxPerNamespace); err != nil {
+		return nil, err
+	}
+
+	if err := v.validateInlineRoles(ctx, user, nil); err != nil {
+		return nil, err
+	}
+
+	if err := v.validateSelfService(ctx, user, nil); err != nil {
+		return nil, err
+	}
+
+	if err := v.validateRoles(ctx, user, nil); err != nil {
+		return nil, err
+	}
+
+	return nil, v.validateClusterRoles(ctx, user, nil)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type
+func (v *UserCustomValidator) ValidateUpdate(ctx context.Context, oldObj runtime.Object, newObj runtime.Object) (admission.Warnings, error) {
+	old, ok := oldObj.(*User)
+	if !ok {
+		return nil, fmt.Errorf("expected a User but got a %T", oldObj)
+	}
+
+	user, ok := newObj.(*User)
+	if !ok {
+		return nil, fmt.Errorf("expected a User but got a %T", newObj)
+	}
+
+	userlog.Info("validate update", "name", user.Name)
+
+	// users being deleted are still updated to remove their finalizers, so they are not held to the spec validation
+	if user.GetDeletionTimestamp() == nil {
+		if err := validateSpec(user, old); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := validateImmutable(old, user); err != nil {
+		return nil, err
+	}
+
+	if err := v.validateInlineRoles(ctx, user, old); err != nil {
+		return nil, err
+	}
+
+	if err := v.validateSelfService(ctx, user, old); err != nil {
+		return nil, err
+	}
+
+	if err := v.validateRoles(ctx, user, old); err != nil {
+		return nil, err
+	}
+
+	return nil, v.validateClusterRoles(ctx, user, old)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type
+func (v *UserCustomValidator) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
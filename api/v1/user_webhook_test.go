@@ -0,0 +1,501 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func contextForRequest(operation admissionv1.Operation, requester string, old *User, groups ...string) context.Context {
+	req := admission.Request{
+		AdmissionRequest: admissionv1.AdmissionRequest{
+			Operation: operation,
+			UserInfo: authenticationv1.UserInfo{
+				Username: requester,
+				Groups:   groups,
+			},
+		},
+	}
+
+	if old != nil {
+		raw, err := json.Marshal(old)
+		Expect(err).NotTo(HaveOccurred())
+		req.OldObject = runtime.RawExtension{Raw: raw}
+	}
+
+	return admission.NewContextWithRequest(context.Background(), req)
+}
+
+// authorizerDenying returns a client whose SubjectAccessReviews allow every action except those in denied, as described
+// by describeAttributes.
+func authorizerDenying(denied map[string]bool) client.Client {
+	scheme := runtime.NewScheme()
+	Expect(authorizationv1.AddToScheme(scheme)).To(Succeed())
+
+	return fake.NewClientBuilder().WithScheme(scheme).WithInterceptorFuncs(interceptor.Funcs{
+		Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+			review := obj.(*authorizationv1.SubjectAccessReview)
+			review.Status.Allowed = !denied[describeAttributes(*review.Spec.ResourceAttributes)]
+			return nil
+		},
+	}).Build()
+}
+
+var _ = Describe("User Webhook", func() {
+	var defaulter *UserCustomDefaulter
+	var user *User
+
+	BeforeEach(func() {
+		defaulter = &UserCustomDefaulter{}
+
+		user = &User{
+			ObjectMeta: metav1.ObjectMeta{Name: "user-test", Namespace: "marina-system"},
+			Spec: UserSpec{
+				Name:     "bilbo",
+				Password: []byte("baggins"),
+				Roles:    []string{"SomeRole"},
+			},
+		}
+	})
+
+	When("a user is created", func() {
+		It("should record the requester of each role", func() {
+			err := defaulter.Default(contextForRequest(admissionv1.Create, "gandalf", nil), user)
+			Expect(err).NotTo(HaveOccurred())
+
+			grants, err := user.RoleGrants()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(grants).To(Equal(map[string]string{"SomeRole": "gandalf"}))
+		})
+
+		It("should record the creator of a new user", func() {
+			user.Annotations = map[string]string{UserCreatorAnnotation: "saruman"}
+
+			err := defaulter.Default(contextForRequest(admissionv1.Create, "gandalf", nil), user)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(user.Annotations).To(HaveKeyWithValue(UserCreatorAnnotation, "gandalf"))
+		})
+
+		It("should keep the recorded creator on update", func() {
+			old := user.DeepCopy()
+			old.Annotations = map[string]string{UserCreatorAnnotation: "gandalf"}
+			user.Annotations = map[string]string{UserCreatorAnnotation: "saruman"}
+
+			err := defaulter.Default(contextForRequest(admissionv1.Update, "saruman", old), user)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(user.Annotations).To(HaveKeyWithValue(UserCreatorAnnotation, "gandalf"))
+		})
+
+		It("should ignore grants set by the requester", func() {
+			user.Annotations = map[string]string{
+				UserRoleGrantsAnnotation: `{"SomeRole":"saruman"}`,
+			}
+
+			err := defaulter.Default(contextForRequest(admissionv1.Create, "gandalf", nil), user)
+			Expect(err).NotTo(HaveOccurred())
+
+			grants, err := user.RoleGrants()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(grants).To(Equal(map[string]string{"SomeRole": "gandalf"}))
+		})
+	})
+
+	When("a user is created without a username", func() {
+		It("should default the username to the name of the user", func() {
+			user.Spec.Name = ""
+
+			err := defaulter.Default(contextForRequest(admissionv1.Create, "gandalf", nil), user)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(user.Spec.Name).To(Equal("user-test"))
+		})
+
+		It("should keep an explicit username", func() {
+			err := defaulter.Default(contextForRequest(admissionv1.Create, "gandalf", nil), user)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(user.Spec.Name).To(Equal("bilbo"))
+		})
+	})
+
+	When("a user is updated with a new role", func() {
+		It("should only record the requester of the new role", func() {
+			old := user.DeepCopy()
+			old.Annotations = map[string]string{
+				UserRoleGrantsAnnotation: `{"SomeRole":"gandalf"}`,
+			}
+
+			user.Spec.Roles = append(user.Spec.Roles, "AnotherRole")
+
+			err := defaulter.Default(contextForRequest(admissionv1.Update, "elrond", old), user)
+			Expect(err).NotTo(HaveOccurred())
+
+			grants, err := user.RoleGrants()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(grants).To(Equal(map[string]string{
+				"SomeRole":    "gandalf",
+				"AnotherRole": "elrond",
+			}))
+		})
+	})
+})
+
+var _ = Describe("User Validating Webhook", func() {
+	var validator *UserCustomValidator
+	var user *User
+	var denied map[string]bool
+
+	BeforeEach(func() {
+		denied = map[string]bool{}
+
+		validator = &UserCustomValidator{
+			SelfServiceGroups:       []string{"marina:self-service"},
+			SelfServiceRoles:        []string{"TerminalViewer"},
+			SelfServiceClusterRoles: []string{"view"},
+			Authorizer:              authorizerDenying(denied),
+		}
+
+		user = &User{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "user-test",
+				Namespace:   "marina-system",
+				Annotations: map[string]string{UserCreatorAnnotation: "frodo"},
+			},
+			Spec: UserSpec{
+				Name:     "bilbo",
+				Password: []byte("baggins"),
+			},
+		}
+	})
+
+	It("should allow a self-service client to add an approved role", func() {
+		user.Spec.Roles = []string{"TerminalViewer"}
+
+		_, err := validator.ValidateCreate(contextForRequest(admissionv1.Create, "frodo", nil, "marina:self-service"), user)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should reject a self-service client adding a role which is not approved", func() {
+		old := user.DeepCopy()
+		user.Spec.Roles = []string{"TerminalViewer", "ClusterAdmin"}
+
+		_, err := validator.ValidateUpdate(contextForRequest(admissionv1.Update, "frodo", old, "marina:self-service"), old, user)
+		Expect(err).To(MatchError(ContainSubstring("not approved")))
+	})
+
+	It("should allow a self-service client to keep roles which are not approved", func() {
+		user.Spec.Roles = []string{"ClusterAdmin"}
+		old := user.DeepCopy()
+		user.Spec.Roles = append(user.Spec.Roles, "TerminalViewer")
+
+		_, err := validator.ValidateUpdate(contextForRequest(admissionv1.Update, "frodo", old, "marina:self-service"), old, user)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should allow a self-service client to add an approved cluster role", func() {
+		user.Spec.ClusterRoles = []string{"view"}
+
+		_, err := validator.ValidateCreate(contextForRequest(admissionv1.Create, "frodo", nil, "marina:self-service"), user)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should reject a self-service client adding a cluster role which is not approved", func() {
+		old := user.DeepCopy()
+		user.Spec.ClusterRoles = []string{"cluster-admin"}
+
+		_, err := validator.ValidateUpdate(contextForRequest(admissionv1.Update, "frodo", old, "marina:self-service"), old, user)
+		Expect(err).To(MatchError(ContainSubstring("cluster role 'cluster-admin' is not approved")))
+	})
+
+	It("should reject a self-service client updating a user it does not own", func() {
+		old := user.DeepCopy()
+		user.Spec.Roles = []string{"TerminalViewer"}
+
+		_, err := validator.ValidateUpdate(contextForRequest(admissionv1.Update, "sam", old, "marina:self-service"), old, user)
+		Expect(err).To(MatchError(ContainSubstring("may only update users they own")))
+	})
+
+	It("should reject a self-service client granting roles in another namespace", func() {
+		user.Spec.GrantNamespace = "mordor"
+
+		_, err := validator.ValidateCreate(contextForRequest(admissionv1.Create, "frodo", nil, "marina:self-service"), user)
+		Expect(err).To(MatchError(ContainSubstring("spec.grantNamespace")))
+	})
+
+	It("should reject a self-service client extending the expiry of a user", func() {
+		user.Spec.ExpiresAt = &metav1.Time{Time: time.Now()}
+		old := user.DeepCopy()
+		user.Spec.ExpiresAt = &metav1.Time{Time: time.Now().Add(time.Hour)}
+
+		_, err := validator.ValidateUpdate(contextForRequest(admissionv1.Update, "frodo", old, "marina:self-service"), old, user)
+		Expect(err).To(MatchError(ContainSubstring("spec.expiresAt")))
+	})
+
+	It("should reject a self-service client adding oidc groups to a user", func() {
+		old := user.DeepCopy()
+		user.Spec.OIDCGroups = []string{"cluster-admins"}
+
+		_, err := validator.ValidateUpdate(contextForRequest(admissionv1.Update, "frodo", old, "marina:self-service"), old, user)
+		Expect(err).To(MatchError(ContainSubstring("spec.oidcGroups")))
+	})
+
+	It("should reject a self-service client pausing a user", func() {
+		old := user.DeepCopy()
+		user.Annotations[UserPausedAnnotation] = "true"
+
+		_, err := validator.ValidateUpdate(contextForRequest(admissionv1.Update, "frodo", old, "marina:self-service"), old, user)
+		Expect(err).To(MatchError(ContainSubstring(UserPausedAnnotation)))
+	})
+
+	It("should allow other clients to change admin fields", func() {
+		old := user.DeepCopy()
+		user.Spec.ProvisionNamespace = false
+		user.Spec.OIDCGroups = []string{"rangers"}
+		user.Spec.AccessSchedule = &AccessSchedule{Schedule: "0 9 * * 1-5", Duration: metav1.Duration{Duration: 8 * time.Hour}}
+		user.Annotations[UserPausedAnnotation] = "true"
+
+		_, err := validator.ValidateUpdate(contextForRequest(admissionv1.Update, "gandalf", old, "system:masters"), old, user)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	When("inline roles are granted", func() {
+		var allowed map[string]bool
+
+		BeforeEach(func() {
+			scheme := runtime.NewScheme()
+			Expect(authorizationv1.AddToScheme(scheme)).To(Succeed())
+
+			allowed = map[string]bool{"get secrets": true}
+			validator.Authorizer = fake.NewClientBuilder().WithScheme(scheme).WithInterceptorFuncs(interceptor.Funcs{
+				Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+					review := obj.(*authorizationv1.SubjectAccessReview)
+					Expect(review.Spec.User).To(Equal("frodo"))
+					Expect(review.Spec.ResourceAttributes.Namespace).To(Equal("marina-system"))
+
+					review.Status.Allowed = allowed[describeAttributes(*review.Spec.ResourceAttributes)]
+					return nil
+				},
+			}).Build()
+
+			user.Spec.InlineRoles = []rbacv1.PolicyRule{
+				{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"get"}},
+			}
+		})
+
+		It("should allow permissions the requester holds", func() {
+			_, err := validator.ValidateCreate(contextForRequest(admissionv1.Create, "frodo", nil), user)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should reject permissions the requester does not hold", func() {
+			old := user.DeepCopy()
+			user.Spec.InlineRoles[0].Verbs = []string{"get", "delete"}
+
+			_, err := validator.ValidateUpdate(contextForRequest(admissionv1.Update, "frodo", old, "marina:self-service"), old, user)
+			Expect(err).To(MatchError(ContainSubstring("'frodo' may not delete secrets")))
+		})
+
+		It("should reject non-resource urls", func() {
+			user.Spec.InlineRoles = []rbacv1.PolicyRule{{NonResourceURLs: []string{"/metrics"}, Verbs: []string{"get"}}}
+
+			_, err := validator.ValidateCreate(contextForRequest(admissionv1.Create, "frodo", nil), user)
+			Expect(err).To(MatchError(ContainSubstring("spec.inlineRoles[0].nonResourceURLs")))
+		})
+
+		It("should not review inline roles which have not changed", func() {
+			allowed = nil
+			old := user.DeepCopy()
+			user.Spec.Roles = []string{"TerminalViewer"}
+
+			_, err := validator.ValidateUpdate(contextForRequest(admissionv1.Update, "frodo", old, "marina:self-service"), old, user)
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	It("should not restrict other clients", func() {
+		user.Spec.Roles = []string{"ClusterAdmin"}
+
+		_, err := validator.ValidateCreate(contextForRequest(admissionv1.Create, "gandalf", nil, "system:masters"), user)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should reject a requester adding a role it may not bind", func() {
+		denied["bind roles.rbac.authorization.k8s.io/ClusterAdmin"] = true
+		user.Spec.Roles = []string{"TerminalViewer", "ClusterAdmin"}
+
+		_, err := validator.ValidateCreate(contextForRequest(admissionv1.Create, "saruman", nil), user)
+		Expect(err).To(MatchError(ContainSubstring("spec.roles[1]: Forbidden: 'saruman' may not bind roles.rbac.authorization.k8s.io/ClusterAdmin")))
+	})
+
+	It("should reject a requester granting roles in a namespace it may not create role bindings in", func() {
+		denied["create rolebindings.rbac.authorization.k8s.io"] = true
+		user.Spec.GrantNamespace = "kube-system"
+
+		_, err := validator.ValidateCreate(contextForRequest(admissionv1.Create, "saruman", nil), user)
+		Expect(err).To(MatchError(ContainSubstring("spec.grantNamespace: Forbidden: 'saruman' may not create rolebindings")))
+	})
+
+	It("should review every role when the grant namespace changes", func() {
+		user.Spec.Roles = []string{"ClusterAdmin"}
+		old := user.DeepCopy()
+		user.Spec.GrantNamespace = "kube-system"
+		denied["bind roles.rbac.authorization.k8s.io/ClusterAdmin"] = true
+
+		_, err := validator.ValidateUpdate(contextForRequest(admissionv1.Update, "saruman", old), old, user)
+		Expect(err).To(MatchError(ContainSubstring("spec.roles[0]: Forbidden")))
+	})
+
+	It("should not review roles already on the user", func() {
+		user.Spec.Roles = []string{"ClusterAdmin"}
+		old := user.DeepCopy()
+		user.Spec.Roles = append(user.Spec.Roles, "TerminalViewer")
+		denied["bind roles.rbac.authorization.k8s.io/ClusterAdmin"] = true
+
+		_, err := validator.ValidateUpdate(contextForRequest(admissionv1.Update, "gandalf", old), old, user)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should allow a requester to add a cluster role it may bind", func() {
+		user.Spec.ClusterRoles = []string{"edit"}
+
+		_, err := validator.ValidateCreate(contextForRequest(admissionv1.Create, "gandalf", nil), user)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should reject a requester adding a cluster role it may not bind", func() {
+		denied["bind clusterroles.rbac.authorization.k8s.io/cluster-admin"] = true
+		user.Spec.ClusterRoles = []string{"edit", "cluster-admin"}
+
+		_, err := validator.ValidateCreate(contextForRequest(admissionv1.Create, "saruman", nil), user)
+		Expect(err).To(MatchError(ContainSubstring("spec.clusterRoles[1]: Forbidden: 'saruman' may not bind clusterroles.rbac.authorization.k8s.io/cluster-admin")))
+	})
+
+	It("should reject cluster roles when they cannot be reviewed", func() {
+		validator.Authorizer = nil
+		user.Spec.ClusterRoles = []string{"edit"}
+
+		_, err := validator.ValidateCreate(contextForRequest(admissionv1.Create, "gandalf", nil), user)
+		Expect(err).To(MatchError(ContainSubstring("spec.clusterRoles[0]: Forbidden")))
+	})
+
+	It("should not review cluster roles already on the user", func() {
+		user.Spec.ClusterRoles = []string{"cluster-admin"}
+		old := user.DeepCopy()
+		user.Spec.ClusterRoles = append(user.Spec.ClusterRoles, "edit")
+		denied["bind clusterroles.rbac.authorization.k8s.io/cluster-admin"] = true
+
+		_, err := validator.ValidateUpdate(contextForRequest(admissionv1.Update, "gandalf", old), old, user)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should reject usernames which are not valid linux usernames", func() {
+		user.Spec.Name = "Bilbo.Baggins"
+
+		_, err := validator.ValidateCreate(contextForRequest(admissionv1.Create, "gandalf", nil, "system:masters"), user)
+		Expect(err).To(MatchError(ContainSubstring(`spec.name: Invalid value: "Bilbo.Baggins"`)))
+	})
+
+	It("should allow updates to users with a username from before it was validated", func() {
+		user.Spec.Name = "Bilbo.Baggins"
+		old := user.DeepCopy()
+		user.Spec.Roles = []string{"TerminalViewer"}
+
+		_, err := validator.ValidateUpdate(contextForRequest(admissionv1.Update, "gandalf", old, "system:masters"), old, user)
+		Expect(err).NotTo(HaveOccurred())
+
+		user.Spec.Name = "Frodo.Baggins"
+
+		_, err = validator.ValidateUpdate(contextForRequest(admissionv1.Update, "gandalf", old, "system:masters"), old, user)
+		Expect(err).To(MatchError(ContainSubstring(`spec.name: Invalid value: "Frodo.Baggins"`)))
+	})
+
+	It("should reject duplicate roles", func() {
+		user.Spec.Roles = []string{"TerminalViewer", "ClusterAdmin", "TerminalViewer"}
+
+		_, err := validator.ValidateCreate(contextForRequest(admissionv1.Create, "gandalf", nil, "system:masters"), user)
+		Expect(err).To(MatchError(ContainSubstring(`spec.roles[2]: Duplicate value: "TerminalViewer"`)))
+	})
+
+	It("should reject duplicate cluster roles", func() {
+		old := user.DeepCopy()
+		user.Spec.ClusterRoles = []string{"view", "view"}
+
+		_, err := validator.ValidateUpdate(contextForRequest(admissionv1.Update, "gandalf", old, "system:masters"), old, user)
+		Expect(err).To(MatchError(ContainSubstring(`spec.clusterRoles[1]: Duplicate value: "view"`)))
+	})
+
+	It("should reject authorized keys which are not OpenSSH public keys", func() {
+		user.Spec.AuthorizedKeys = []string{
+			"ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIJh/IXTQ5wGPpOjzL0VvMrrKJStj8v0EM5KS5iWqxeAL bilbo@shire",
+			"ssh-ed25519 not-a-key bilbo@shire",
+		}
+
+		_, err := validator.ValidateCreate(contextForRequest(admissionv1.Create, "gandalf", nil, "system:masters"), user)
+		Expect(err).To(MatchError(ContainSubstring("spec.authorizedKeys[1]")))
+		Expect(err).NotTo(MatchError(ContainSubstring("spec.authorizedKeys[0]")))
+	})
+
+	It("should reject authorized keys which span multiple lines", func() {
+		user.Spec.AuthorizedKeys = []string{
+			"ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIJh/IXTQ5wGPpOjzL0VvMrrKJStj8v0EM5KS5iWqxeAL bilbo@shire\ncommand=\"sh\" ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIJh/IXTQ5wGPpOjzL0VvMrrKJStj8v0EM5KS5iWqxeAL",
+		}
+
+		_, err := validator.ValidateCreate(contextForRequest(admissionv1.Create, "gandalf", nil, "system:masters"), user)
+		Expect(err).To(MatchError(ContainSubstring("must be a single line")))
+	})
+
+	It("should reject enabling namespace provisioning for an existing user", func() {
+		old := user.DeepCopy()
+		user.Spec.ProvisionNamespace = true
+
+		_, err := validator.ValidateUpdate(contextForRequest(admissionv1.Update, "gandalf", old, "system:masters"), old, user)
+		Expect(err).To(MatchError(ContainSubstring("spec.provisionNamespace: Forbidden")))
+	})
+
+	When("a namespace is limited to a number of users", func() {
+		BeforeEach(func() {
+			scheme := runtime.NewScheme()
+			Expect(AddToScheme(scheme)).To(Succeed())
+
+			existing := &User{
+				ObjectMeta: metav1.ObjectMeta{Name: "user-existing", Namespace: "marina-system"},
+			}
+
+			validator.Reader = fake.NewClientBuilder().WithScheme(scheme).WithObjects(existing).Build()
+		})
+
+		It("should allow a user which reaches the limit", func() {
+			validator.MaxPerNamespace = 2
+
+			_, err := validator.ValidateCreate(contextForRequest(admissionv1.Create, "gandalf", nil, "system:masters"), user)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should reject a user beyond the limit", func() {
+			validator.MaxPerNamespace = 1
+
+			_, err := validator.ValidateCreate(contextForRequest(admissionv1.Create, "gandalf", nil, "system:masters"), user)
+			Expect(apierrors.IsForbidden(err)).To(BeTrue())
+		})
+
+		It("should not limit updates to existing users", func() {
+			validator.MaxPerNamespace = 1
+			old := user.DeepCopy()
+
+			_, err := validator.ValidateUpdate(contextForRequest(admissionv1.Update, "gandalf", old, "system:masters"), old, user)
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+})
@@ -17,31 +17,58 @@ limitations under the License.
 package cmd
 
 import (
+	"context"
 	"crypto/tls"
 	"fmt"
+	"net/http"
 	"os"
+	"strings"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/record"
 
+	"golang.org/x/crypto/bcrypt"
+	k8scorev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	corev1 "github.com/joshmeranda/marina-operator/api/v1"
 	"github.com/joshmeranda/marina-operator/internal/controller"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/urfave/cli/v2"
+	"go.uber.org/zap/zapcore"
 	// +kubebuilder:scaffold:imports
 )
 
+const (
+	// leaseDuration is how long other managers wait after the leader last renewed its lease before taking over.
+	leaseDuration = 15 * time.Second
+
+	// leaseRetryPeriod is how often the leader renews its lease.
+	leaseRetryPeriod = 2 * time.Second
+
+	// shutdownCleanupTimeout bounds how long the manager may spend finalizing deleted terminals before exiting. The
+	// lease stops being renewed once the manager stops, having last been renewed up to a retry period earlier, so the
+	// cleanup must finish before the lease expires and the next leader starts reconciling the same terminals.
+	shutdownCleanupTimeout = leaseDuration - leaseRetryPeriod - time.Second
+)
+
 var (
 	scheme   = runtime.NewScheme()
 	setupLog = ctrl.Log.WithName("setup")
@@ -54,15 +81,251 @@ func init() {
 	// +kubebuilder:scaffold:scheme
 }
 
+// restConfig loads the kubeconfig specified by the user, falling back to the in-cluster config.
+func restConfig(ctx *cli.Context) (*rest.Config, error) {
+	var config *rest.Config
+	var err error
+
+	if kubeconfig := ctx.String("kubeconfig"); kubeconfig != "" {
+		config, err = clientcmd.BuildConfigFromFlags("", kubeconfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get config from kubeconfig: %w", err)
+		}
+	} else {
+		config, err = rest.InClusterConfig()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get in-cluster config: %w", err)
+		}
+	}
+
+	config.QPS = float32(ctx.Float64("kube-api-qps"))
+	config.Burst = ctx.Int("kube-api-burst")
+
+	return config, nil
+}
+
+// parseKeyValues parses a list of key=value pairs into a map.
+func parseKeyValues(pairs []string) (map[string]string, error) {
+	values := make(map[string]string, len(pairs))
+
+	for _, pair := range pairs {
+		key, value, found := strings.Cut(pair, "=")
+		if !found || key == "" {
+			return nil, fmt.Errorf("expected key=value but found '%s'", pair)
+		}
+
+		values[key] = value
+	}
+
+	return values, nil
+}
+
+// parseImageDigests parses a list of repository=digest pairs, ensuring each digest has the form <algorithm>:<hex> (ex
+// sha256:...) so the images pinned to them can be pulled.
+func parseImageDigests(pairs []string) (map[string]string, error) {
+	digests, err := parseKeyValues(pairs)
+	if err != nil {
+		return nil, err
+	}
+
+	for repository, digest := range digests {
+		algorithm, encoded, found := strings.Cut(digest, ":")
+		if !found || algorithm == "" || encoded == "" || strings.Trim(encoded, "0123456789abcdef") != "" {
+			return nil, fmt.Errorf("expected a digest of the form <algorithm>:<hex> for '%s' but found '%s'", repository, digest)
+		}
+	}
+
+	return digests, nil
+}
+
+// cacheOptions scopes the manager's cache to the namespaces given by the cli flags, and sets how often it is resynced.
+// When no namespaces are given every namespace is watched.
+func cacheOptions(ctx *cli.Context) cache.Options {
+	namespaces := ctx.StringSlice("watch-namespaces")
+	if namespace := ctx.String("namespace"); namespace != "" {
+		namespaces = append(namespaces, namespace)
+	}
+
+	options := cache.Options{}
+
+	if syncPeriod := ctx.Duration("sync-period"); syncPeriod > 0 {
+		options.SyncPeriod = &syncPeriod
+	}
+
+	if len(namespaces) == 0 {
+		return options
+	}
+
+	options.DefaultNamespaces = make(map[string]cache.Config, len(namespaces))
+	for _, namespace := range namespaces {
+		options.DefaultNamespaces[namespace] = cache.Config{}
+	}
+
+	return options
+}
+
+// zapOptions configures the manager's logger from the cli flags, keeping development logging when they are unset.
+func zapOptions(ctx *cli.Context) (zap.Options, error) {
+	opts := zap.Options{
+		Development: true,
+	}
+
+	switch format := ctx.String("log-format"); format {
+	case "":
+	case "json":
+		opts.Development = false
+		zap.JSONEncoder()(&opts)
+	case "console":
+		opts.Development = false
+		zap.ConsoleEncoder()(&opts)
+	default:
+		return opts, fmt.Errorf("unknown log format '%s', expected json or console", format)
+	}
+
+	switch level := ctx.String("log-level"); level {
+	case "":
+	case "debug", "info", "warn", "error":
+		parsed, err := zapcore.ParseLevel(level)
+		if err != nil {
+			return opts, fmt.Errorf("invalid log level: %w", err)
+		}
+
+		opts.Level = parsed
+	default:
+		return opts, fmt.Errorf("unknown log level '%s', expected debug, info, warn, or error", level)
+	}
+
+	return opts, nil
+}
+
+// applyLeaderElection configures the manager's leader election from the cli flags.
+func applyLeaderElection(ctx *cli.Context, options *ctrl.Options) {
+	options.LeaderElection = ctx.Bool("enable-leader-election")
+	options.LeaderElectionNamespace = ctx.String("leader-election-namespace")
+	options.LeaseDuration = controller.ToPtr(leaseDuration)
+	options.RetryPeriod = controller.ToPtr(leaseRetryPeriod)
+
+	// stepping down as soon as the manager stops is only safe when nothing runs after it, otherwise the next leader
+	// would reconcile terminals alongside the shutdown cleanup
+	options.LeaderElectionReleaseOnCancel = !ctx.Bool("cleanup-on-shutdown")
+}
+
+// managerContext returns a context which is done once either the parent or the signal context is done.
+func managerContext(parent context.Context, signals context.Context) context.Context {
+	ctx, cancel := context.WithCancel(parent)
+	context.AfterFunc(signals, cancel)
+
+	return ctx
+}
+
+// finalizeDeletedTerminals runs a last finalizer pass over the terminals which were being deleted when the manager
+// stopped. The manager's cache stops with it, so the pass uses a client which talks to the api server directly.
+func finalizeDeletedTerminals(ctx *cli.Context, config *rest.Config, mgr ctrl.Manager, reconciler *controller.TerminalReconciler) error {
+	// replicas which never became the leader must not touch terminals
+	select {
+	case <-mgr.Elected():
+	default:
+		return nil
+	}
+
+	c, err := client.New(config, client.Options{Scheme: scheme})
+	if err != nil {
+		return fmt.Errorf("could not create cleanup client: %w", err)
+	}
+
+	cleanup := *reconciler
+	cleanup.Client = reconcilerClient(ctx, c)
+
+	cleanupCtx, cancel := context.WithTimeout(context.Background(), shutdownCleanupTimeout)
+	defer cancel()
+
+	return cleanup.FinalizeDeleted(cleanupCtx)
+}
+
+// reconcilerClient returns the client the reconcilers write through. With --dry-run every write is only logged along
+// with the changes it would have made.
+func reconcilerClient(ctx *cli.Context, c client.Client) client.Client {
+	if ctx.Bool("dry-run") {
+		return controller.NewDryRunClient(c)
+	}
+
+	return c
+}
+
+// applyProfiling serves pprof profiles from the manager when the cli flags give it an address. Profiling is disabled
+// by default.
+func applyProfiling(ctx *cli.Context, options *ctrl.Options) {
+	options.PprofBindAddress = ctx.String("pprof-bind-address")
+}
+
+// defaultImagePullPolicy returns the pull policy used for terminals which do not specify their own. When unset the
+// Kubernetes default is left in place.
+func defaultImagePullPolicy(ctx *cli.Context) (k8scorev1.PullPolicy, error) {
+	switch policy := k8scorev1.PullPolicy(ctx.String("default-image-pull-policy")); policy {
+	case "", k8scorev1.PullAlways, k8scorev1.PullIfNotPresent, k8scorev1.PullNever:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown image pull policy '%s', expected Always, IfNotPresent, or Never", policy)
+	}
+}
+
 func start(ctx *cli.Context) error {
 	metricsAddr := ctx.String("metrics-bind-address")
-	enableLeaderElection := ctx.Bool("enable-leader-elect")
 	probeAddr := ctx.String("health-probe-bind-address")
 	secureMetrics := ctx.Bool("metrics-secure")
 	enableHTTP2 := ctx.Bool("enable-http2")
 
-	opts := zap.Options{
-		Development: true,
+	defaultServiceAnnotations, err := parseKeyValues(ctx.StringSlice("default-service-annotation"))
+	if err != nil {
+		return fmt.Errorf("invalid default service annotations: %w", err)
+	}
+
+	latestImageDigests, err := parseImageDigests(ctx.StringSlice("latest-image-digest"))
+	if err != nil {
+		return fmt.Errorf("invalid latest image digests: %w", err)
+	}
+
+	defaultCPURequest, err := resource.ParseQuantity(ctx.String("default-cpu-request"))
+	if err != nil {
+		return fmt.Errorf("invalid default cpu request: %w", err)
+	}
+
+	defaultMemoryRequest, err := resource.ParseQuantity(ctx.String("default-memory-request"))
+	if err != nil {
+		return fmt.Errorf("invalid default memory request: %w", err)
+	}
+
+	imagePullPolicy, err := defaultImagePullPolicy(ctx)
+	if err != nil {
+		return fmt.Errorf("invalid default image pull policy: %w", err)
+	}
+
+	var maintenanceWindow *controller.MaintenanceWindow
+	if schedule := ctx.String("maintenance-window-schedule"); schedule != "" {
+		maintenanceWindow, err = controller.ParseMaintenanceWindow(schedule, ctx.Duration("maintenance-window-duration"))
+		if err != nil {
+			return fmt.Errorf("invalid maintenance window: %w", err)
+		}
+	}
+
+	var imageAllowlist types.NamespacedName
+	if allowlist := ctx.String("image-allowlist-configmap"); allowlist != "" {
+		namespace, name, found := strings.Cut(allowlist, "/")
+		if !found || namespace == "" || name == "" {
+			return fmt.Errorf("expected image allowlist in the form namespace/name but found '%s'", allowlist)
+		}
+
+		imageAllowlist = types.NamespacedName{Namespace: namespace, Name: name}
+	}
+
+	var allowedCapabilities []k8scorev1.Capability
+	for _, capability := range ctx.StringSlice("allowed-capability") {
+		allowedCapabilities = append(allowedCapabilities, k8scorev1.Capability(capability))
+	}
+
+	opts, err := zapOptions(ctx)
+	if err != nil {
+		return err
 	}
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
@@ -88,76 +351,152 @@ func start(ctx *cli.Context) error {
 		TLSOpts: tlsOpts,
 	})
 
-	var config *rest.Config
-	var err error
+	config, err := restConfig(ctx)
+	if err != nil {
+		return err
+	}
 
-	if kubeconfig := ctx.String("kubeconfig"); kubeconfig != "" {
-		if config, err = clientcmd.BuildConfigFromFlags("", kubeconfig); err != nil {
-			return fmt.Errorf("failed to get config from kubeconfig: %w", err)
+	// in cluster configs reference the api server ca by file, but kubeconfigs handed to users need it inline
+	kubeconfigCAData := config.CAData
+	if len(kubeconfigCAData) == 0 && config.CAFile != "" {
+		if kubeconfigCAData, err = os.ReadFile(config.CAFile); err != nil {
+			return fmt.Errorf("could not read api server ca: %w", err)
 		}
-	} else if config, err = rest.InClusterConfig(); err != nil {
-		return fmt.Errorf("failed to get in-cluster config: %w", err)
 	}
 
-	mgr, err := ctrl.NewManager(config, ctrl.Options{
+	options := ctrl.Options{
 		Scheme: scheme,
 		Metrics: metricsserver.Options{
 			BindAddress:   metricsAddr,
 			SecureServing: secureMetrics,
 			TLSOpts:       tlsOpts,
+			// exemplars are only exposed in the OpenMetrics format, which the default handler does not negotiate
+			ExtraHandlers: map[string]http.Handler{
+				"/metrics/openmetrics": promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{EnableOpenMetrics: true}),
+			},
 		},
+		Cache:                  cacheOptions(ctx),
 		WebhookServer:          webhookServer,
 		HealthProbeBindAddress: probeAddr,
-		LeaderElection:         enableLeaderElection,
 		LeaderElectionID:       "763ba5de.marina.io",
-		// LeaderElectionReleaseOnCancel defines if the leader should step down voluntarily
-		// when the Manager ends. This requires the binary to immediately end when the
-		// Manager is stopped, otherwise, this setting is unsafe. Setting this significantly
-		// speeds up voluntary leader transitions as the new leader don't have to wait
-		// LeaseDuration time first.
-		//
-		// In the default scaffold provided, the program ends immediately after
-		// the manager stops, so would be fine to enable this option. However,
-		// if you are doing or is intended to do any operation such as perform cleanups
-		// after the manager stops then its usage might be unsafe.
-		// LeaderElectionReleaseOnCancel: true,
-	})
+	}
+	applyLeaderElection(ctx, &options)
+	applyProfiling(ctx, &options)
+
+	mgr, err := ctrl.NewManager(config, options)
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
 		os.Exit(1)
 	}
 
-	if err = (&controller.TerminalReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
-	}).SetupWithManager(mgr); err != nil {
+	// events would describe changes which were never made, so they are dropped in dry runs. A FakeRecorder without an
+	// events channel discards every event.
+	var userRecorder, terminalRecorder record.EventRecorder = &record.FakeRecorder{}, &record.FakeRecorder{}
+	if !ctx.Bool("dry-run") {
+		userRecorder = mgr.GetEventRecorderFor("user-controller")
+		terminalRecorder = mgr.GetEventRecorderFor("terminal-controller")
+	}
+
+	terminalReconciler := &controller.TerminalReconciler{
+		Client:                      reconcilerClient(ctx, mgr.GetClient()),
+		Scheme:                      mgr.GetScheme(),
+		MaxConcurrentReconciles:     ctx.Int("max-concurrent-reconciles"),
+		DefaultServiceAnnotations:   defaultServiceAnnotations,
+		AllowedCapabilities:         allowedCapabilities,
+		AllowedServiceAccounts:      ctx.StringSlice("allowed-service-account"),
+		MaintenanceWindow:           maintenanceWindow,
+		ImageAllowlist:              imageAllowlist,
+		ChildNamespace:              ctx.String("default-namespace"),
+		UseUserServiceAccount:       ctx.Bool("terminal-user-service-account"),
+		AvailabilityRequeueInterval: ctx.Duration("terminal-availability-requeue-interval"),
+		ShutdownWebhookURL:          ctx.String("terminal-shutdown-webhook"),
+		ShutdownWebhookHosts:        ctx.StringSlice("terminal-shutdown-webhook-host"),
+		ShutdownWebhookTimeout:      ctx.Duration("terminal-shutdown-webhook-timeout"),
+		DryRun:                      ctx.Bool("dry-run"),
+		Recorder:                    terminalRecorder,
+		DefaultImagePullPolicy:      imagePullPolicy,
+		DefaultResources: k8scorev1.ResourceRequirements{
+			Requests: k8scorev1.ResourceList{
+				k8scorev1.ResourceCPU:    defaultCPURequest,
+				k8scorev1.ResourceMemory: defaultMemoryRequest,
+			},
+		},
+	}
+	if err = terminalReconciler.SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Terminal")
 		os.Exit(1)
 	}
 	if err = (&controller.UserReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:                  reconcilerClient(ctx, mgr.GetClient()),
+		Scheme:                  mgr.GetScheme(),
+		APIReader:               mgr.GetAPIReader(),
+		MaxConcurrentReconciles: ctx.Int("max-concurrent-reconciles"),
+		CleanupTokenSecrets:     ctx.Bool("cleanup-token-secrets"),
+		SharedRoleBindings:      ctx.Bool("shared-role-bindings"),
+		MaxReconcileRetries:     ctx.Int("max-reconcile-retries"),
+		MaintenanceWindow:       maintenanceWindow,
+		ExpirySuspensionWindow:  ctx.Duration("user-suspension-window"),
+		Recorder:                userRecorder,
+		PasswordHashCost:        ctx.Int("password-hash-cost"),
+		KubeconfigServer:        ctx.String("kubeconfig-server"),
+		KubeconfigCAData:        kubeconfigCAData,
+		KubeconfigTokenTTL:      ctx.Duration("kubeconfig-token-ttl"),
+		InlineRolesReviewed:     ctx.Bool("enable-webhooks"),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "User")
 		os.Exit(1)
 	}
+	if ctx.Bool("enable-webhooks") {
+		if err = (&corev1.User{}).SetupWebhookWithManager(mgr, &corev1.UserCustomValidator{
+			SelfServiceGroups:       ctx.StringSlice("self-service-group"),
+			SelfServiceRoles:        ctx.StringSlice("self-service-role"),
+			SelfServiceClusterRoles: ctx.StringSlice("self-service-cluster-role"),
+			Authorizer:              mgr.GetClient(),
+			Reader:                  mgr.GetClient(),
+			MaxPerNamespace:         ctx.Int("max-users-per-namespace"),
+		}); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "User")
+			os.Exit(1)
+		}
+		if err = (&corev1.Terminal{}).SetupWebhookWithManager(mgr, &corev1.TerminalCustomDefaulter{
+			DefaultImage:  ctx.String("default-image"),
+			LatestDigests: latestImageDigests,
+		}, &corev1.TerminalCustomValidator{
+			AllowedImages:     ctx.StringSlice("allowed-image"),
+			DeniedImages:      ctx.StringSlice("denied-image"),
+			Reader:            mgr.GetClient(),
+			Authorizer:        mgr.GetClient(),
+			SelfServiceGroups: ctx.StringSlice("self-service-group"),
+			MaxPerNamespace:   ctx.Int("max-terminals-per-namespace"),
+		}); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "Terminal")
+			os.Exit(1)
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
 	}
-	if err := mgr.AddReadyzCheck("readyz", healthz.Ping); err != nil {
+	if err := mgr.AddReadyzCheck("readyz", apiServerCheck(mgr.GetAPIReader())); err != nil {
 		setupLog.Error(err, "unable to set up ready check")
 		os.Exit(1)
 	}
 
 	setupLog.Info("starting manager")
-	if err := mgr.Start(ctx.Context); err != nil {
+	if err := mgr.Start(managerContext(ctx.Context, ctrl.SetupSignalHandler())); err != nil {
 		setupLog.Error(err, "problem running manager")
 		os.Exit(1)
 	}
 
+	if ctx.Bool("cleanup-on-shutdown") {
+		setupLog.Info("finalizing deleted terminals")
+		if err := finalizeDeletedTerminals(ctx, config, mgr, terminalReconciler); err != nil {
+			setupLog.Error(err, "could not finalize every deleted terminal")
+		}
+	}
+
 	return nil
 }
 
@@ -166,12 +505,55 @@ func App() cli.App {
 		Name:        "manager",
 		Description: "run the marina operator manager",
 		Action:      start,
+		Commands: []*cli.Command{
+			userCommand(),
+			versionCommand(),
+		},
 		Flags: []cli.Flag{
 			&cli.StringFlag{
 				Name:    "kubeconfig",
 				Usage:   "The path to the kubeconfig file. If not set, it will use the in-cluster config.",
 				EnvVars: []string{"KUBECONFIG"},
 			},
+			&cli.Float64Flag{
+				Name:  "kube-api-qps",
+				Usage: "The maximum queries per second from the manager to the kubernetes api server",
+				Value: 20,
+			},
+			&cli.IntFlag{
+				Name:  "kube-api-burst",
+				Usage: "The maximum burst of queries from the manager to the kubernetes api server",
+				Value: 30,
+			},
+			&cli.StringFlag{
+				Name:  "log-level",
+				Usage: "The minimum level logged, one of debug, info, warn, or error. If neither this nor --log-format are set, development logging is used",
+			},
+			&cli.StringFlag{
+				Name:  "log-format",
+				Usage: "The format logs are written in, one of json or console",
+			},
+			&cli.StringFlag{
+				Name:  "namespace",
+				Usage: "The only namespace the manager watches. If neither this nor --watch-namespaces are set, every namespace is watched",
+			},
+			&cli.StringSliceFlag{
+				Name:  "watch-namespaces",
+				Usage: "A comma separated list of namespaces the manager watches, may be specified multiple times",
+			},
+			&cli.DurationFlag{
+				Name:  "sync-period",
+				Usage: "How often every watched resource is reconciled regardless of changes. If not set, the controller-runtime default is used",
+			},
+			&cli.IntFlag{
+				Name:  "max-concurrent-reconciles",
+				Usage: "The number of workers reconciling each kind of resource at once",
+				Value: 1,
+			},
+			&cli.IntFlag{
+				Name:  "max-reconcile-retries",
+				Usage: "How many times in a row a user may fail to reconcile before it is marked as failed and only retried every 10 minutes. If not set users are retried with backoff forever.",
+			},
 			&cli.StringFlag{
 				Name:  "metrics-bind-address",
 				Usage: "The address the metric endpoint binds to. Use the port :8080. If not set, it will be 0 in order to disable the metrics server",
@@ -182,11 +564,27 @@ func App() cli.App {
 				Usage: "Enable leader election for controller manager. Enabling this will ensure there is only one active controller manager.",
 				Value: false,
 			},
+			&cli.BoolFlag{
+				Name:  "cleanup-on-shutdown",
+				Usage: "Run the finalizers of terminals which are being deleted before the manager exits, rather than leaving them for the next manager. The cleanup is cut short before the leader election lease can expire, leaving any remaining terminals for the next manager.",
+			},
+			&cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: "Send every change the reconcilers make to the api server as a dry run and log it instead of persisting it. No events are recorded and no shutdown webhooks are notified",
+			},
+			&cli.StringFlag{
+				Name:  "leader-election-namespace",
+				Usage: "The namespace the leader election lease is created in. If not set, the namespace the manager runs in is used.",
+			},
 			&cli.StringFlag{
 				Name:  "health-probe-bind-address",
 				Usage: "The address the probe endpoint binds to.",
 				Value: ":8081",
 			},
+			&cli.StringFlag{
+				Name:  "pprof-bind-address",
+				Usage: "The address the pprof endpoint binds to. Leave empty to disable profiling.",
+			},
 			&cli.BoolFlag{
 				Name:  "metrics-secure",
 				Usage: "If set the metrics endpoint is served securely",
@@ -202,6 +600,141 @@ func App() cli.App {
 				Usage: "The port the webhook server serves at",
 				Value: 9443,
 			},
+			&cli.StringSliceFlag{
+				Name:  "default-service-annotation",
+				Usage: "An annotation in the form key=value to add to every terminal service, may be specified multiple times",
+			},
+			&cli.StringSliceFlag{
+				Name:  "allowed-capability",
+				Usage: "A linux capability (ex NET_RAW) terminals are allowed to add, may be specified multiple times",
+			},
+			&cli.StringSliceFlag{
+				Name:  "allowed-service-account",
+				Usage: "A service account terminals are allowed to run as, may be specified multiple times",
+			},
+			&cli.StringSliceFlag{
+				Name:  "self-service-group",
+				Usage: "A group identifying self-service clients, which may only update users they own, may only add self-service roles to them, may not change admin-only user fields, and may only create terminals for users they own, may be specified multiple times. Requires webhooks",
+			},
+			&cli.StringSliceFlag{
+				Name:  "self-service-role",
+				Usage: "A role self-service clients may add to users, may be specified multiple times. Requires webhooks",
+			},
+			&cli.StringSliceFlag{
+				Name:  "self-service-cluster-role",
+				Usage: "A cluster role self-service clients may add to users, may be specified multiple times. Requires webhooks",
+			},
+			&cli.StringFlag{
+				Name:  "default-image",
+				Usage: "The image used by terminals which do not specify one. Requires webhooks",
+			},
+			&cli.StringSliceFlag{
+				Name:  "latest-image-digest",
+				Usage: "A repository=digest pair (ex docker.io/library/busybox=sha256:...) pinning the latest tag of the repository in terminal images, may be specified multiple times. Requires webhooks",
+			},
+			&cli.StringSliceFlag{
+				Name:  "allowed-image",
+				Usage: "An image pattern (ex docker.io/library/*) terminals are allowed to use, may be specified multiple times. If unset any image not denied is allowed. Only checked when a terminal's images change, and images must also pass --image-allowlist-configmap. Requires webhooks",
+			},
+			&cli.StringSliceFlag{
+				Name:  "denied-image",
+				Usage: "An image pattern terminals may not use, may be specified multiple times. Takes precedence over --allowed-image. Requires webhooks",
+			},
+			&cli.IntFlag{
+				Name:  "max-terminals-per-namespace",
+				Usage: "The most terminals a namespace may hold. If not set namespaces are not limited. Requires webhooks",
+			},
+			&cli.IntFlag{
+				Name:  "max-users-per-namespace",
+				Usage: "The most users a namespace may hold. If not set namespaces are not limited. Requires webhooks",
+			},
+			&cli.StringFlag{
+				Name:  "image-allowlist-configmap",
+				Usage: "The namespace/name of a ConfigMap listing the image patterns terminals may use under the 'images' key, if unset any image is allowed. Checked by the controller on every reconcile in addition to --allowed-image and --denied-image",
+			},
+			&cli.BoolFlag{
+				Name:  "terminal-user-service-account",
+				Usage: "If set, terminals belonging to a user run as the user's service account unless they specify their own",
+			},
+			&cli.StringFlag{
+				Name:  "default-namespace",
+				Usage: "The namespace new terminal resources are created in, defaulting to the namespace of their terminal",
+			},
+			&cli.DurationFlag{
+				Name:  "terminal-availability-requeue-interval",
+				Usage: "How often terminals are reconciled while their pods are not yet available. Set to 0 to only reconcile them when their deployment changes",
+				Value: 10 * time.Second,
+			},
+			&cli.StringFlag{
+				Name:  "terminal-shutdown-webhook",
+				Usage: "A URL sent a POST request with a terminal's final status when the terminal is deleted, unless the terminal specifies its own",
+			},
+			&cli.StringSliceFlag{
+				Name:  "terminal-shutdown-webhook-host",
+				Usage: "A host terminals may send their own shutdown webhooks to, may be specified multiple times. If not set terminals may not specify their own shutdown webhook",
+			},
+			&cli.DurationFlag{
+				Name:  "terminal-shutdown-webhook-timeout",
+				Usage: "How long a failing terminal shutdown webhook is retried before the terminal is deleted anyway",
+				Value: controller.DefaultShutdownWebhookTimeout,
+			},
+			&cli.StringFlag{
+				Name:  "default-image-pull-policy",
+				Usage: "The image pull policy of terminals which do not specify one, one of Always, IfNotPresent, or Never. If not set the Kubernetes default is used.",
+			},
+			&cli.StringFlag{
+				Name:  "default-cpu-request",
+				Usage: "The cpu request of terminals which do not specify any resources",
+				Value: "100m",
+			},
+			&cli.StringFlag{
+				Name:  "default-memory-request",
+				Usage: "The memory request of terminals which do not specify any resources",
+				Value: "128Mi",
+			},
+			&cli.BoolFlag{
+				Name:  "cleanup-token-secrets",
+				Usage: "If set, manually created token secrets for a user's service account are deleted with the user",
+				Value: true,
+			},
+			&cli.BoolFlag{
+				Name:  "shared-role-bindings",
+				Usage: "If set, users with the same role share a single role binding per namespace rather than each having their own",
+			},
+			&cli.StringFlag{
+				Name:  "kubeconfig-server",
+				Usage: "The API server URL written to the kubeconfig generated for each user, if unset no kubeconfigs are generated",
+			},
+			&cli.DurationFlag{
+				Name:  "kubeconfig-token-ttl",
+				Usage: "How long the service account tokens in generated user kubeconfigs are valid for",
+				Value: controller.DefaultKubeconfigTokenTTL,
+			},
+			&cli.IntFlag{
+				Name:  "password-hash-cost",
+				Usage: "The bcrypt cost used to hash user passwords",
+				Value: bcrypt.DefaultCost,
+			},
+			&cli.DurationFlag{
+				Name:  "user-suspension-window",
+				Usage: "How long before expiring a user is suspended, revoking their roles",
+				Value: 24 * time.Hour,
+			},
+			&cli.StringFlag{
+				Name:  "maintenance-window-schedule",
+				Usage: "A cron schedule (ex '0 2 * * 6') at which a maintenance window starts, during which only resource status is reconciled",
+			},
+			&cli.DurationFlag{
+				Name:  "maintenance-window-duration",
+				Usage: "How long each maintenance window lasts",
+				Value: time.Hour,
+			},
+			&cli.BoolFlag{
+				Name:    "enable-webhooks",
+				Usage:   "If set, the admission webhooks will be registered with the webhook server",
+				EnvVars: []string{"ENABLE_WEBHOOKS"},
+				Value:   false,
+			},
 		},
 	}
 }
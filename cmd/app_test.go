@@ -0,0 +1,248 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/urfave/cli/v2"
+	"go.uber.org/zap/zapcore"
+	k8scorev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// contextForApp parses the given arguments against the flags registered by App.
+func contextForApp(args ...string) *cli.Context {
+	app := App()
+	set := flag.NewFlagSet("test", flag.ContinueOnError)
+
+	for _, f := range app.Flags {
+		Expect(f.Apply(set)).To(Succeed())
+	}
+
+	Expect(set.Parse(args)).To(Succeed())
+
+	return cli.NewContext(&app, set, nil)
+}
+
+var _ = Describe("App", func() {
+	var kubeconfig string
+
+	BeforeEach(func() {
+		kubeconfig = filepath.Join(GinkgoT().TempDir(), "kubeconfig")
+
+		config := clientcmdapi.NewConfig()
+		config.Clusters["marina"] = &clientcmdapi.Cluster{Server: "https://127.0.0.1:6443"}
+		config.AuthInfos["marina"] = &clientcmdapi.AuthInfo{Token: "token"}
+		config.Contexts["marina"] = &clientcmdapi.Context{Cluster: "marina", AuthInfo: "marina"}
+		config.CurrentContext = "marina"
+
+		err := clientcmd.WriteToFile(*config, kubeconfig)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	When("latest image digests are configured", func() {
+		It("should parse repository=digest pairs", func() {
+			digests, err := parseImageDigests([]string{"docker.io/library/busybox=sha256:50aa4698fa6262977cff89181b2664b99d8a56dbca847bf62f2ef04854597cf8"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(digests).To(HaveKeyWithValue("docker.io/library/busybox", "sha256:50aa4698fa6262977cff89181b2664b99d8a56dbca847bf62f2ef04854597cf8"))
+		})
+
+		It("should reject pairs without a digest", func() {
+			_, err := parseImageDigests([]string{"docker.io/library/busybox"})
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should reject malformed digests", func() {
+			_, err := parseImageDigests([]string{"docker.io/library/busybox=latest"})
+			Expect(err).To(MatchError(ContainSubstring("<algorithm>:<hex>")))
+		})
+	})
+
+	When("the client rate limits are configured", func() {
+		It("should apply them to the rest config", func() {
+			set := flag.NewFlagSet("test", flag.ContinueOnError)
+			set.String("kubeconfig", kubeconfig, "")
+			set.Float64("kube-api-qps", 50, "")
+			set.Int("kube-api-burst", 100, "")
+
+			config, err := restConfig(cli.NewContext(nil, set, nil))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config.QPS).To(Equal(float32(50)))
+			Expect(config.Burst).To(Equal(100))
+		})
+	})
+
+	When("leader election is configured", func() {
+		It("should be disabled by default", func() {
+			options := ctrl.Options{}
+			applyLeaderElection(contextForApp(), &options)
+
+			Expect(options.LeaderElection).To(BeFalse())
+			Expect(options.LeaderElectionNamespace).To(BeEmpty())
+		})
+
+		It("should apply the leader election flags", func() {
+			options := ctrl.Options{}
+			applyLeaderElection(contextForApp("--enable-leader-election", "--leader-election-namespace", "marina-system"), &options)
+
+			Expect(options.LeaderElection).To(BeTrue())
+			Expect(options.LeaderElectionNamespace).To(Equal("marina-system"))
+		})
+
+		It("should only release the lease on cancel when nothing runs after the manager", func() {
+			options := ctrl.Options{}
+			applyLeaderElection(contextForApp("--enable-leader-election"), &options)
+			Expect(options.LeaderElectionReleaseOnCancel).To(BeTrue())
+
+			applyLeaderElection(contextForApp("--enable-leader-election", "--cleanup-on-shutdown"), &options)
+			Expect(options.LeaderElectionReleaseOnCancel).To(BeFalse())
+		})
+
+		It("should finish the shutdown cleanup before the lease can expire", func() {
+			options := ctrl.Options{}
+			applyLeaderElection(contextForApp("--enable-leader-election", "--cleanup-on-shutdown"), &options)
+
+			Expect(shutdownCleanupTimeout + *options.RetryPeriod).To(BeNumerically("<", *options.LeaseDuration))
+		})
+	})
+
+	When("the manager is shutting down", func() {
+		It("should stop the manager once a signal is received", func() {
+			signals, signal := context.WithCancel(context.Background())
+			ctx := managerContext(context.Background(), signals)
+			Expect(ctx.Done()).NotTo(BeClosed())
+
+			signal()
+			Eventually(ctx.Done()).Should(BeClosed())
+		})
+
+		It("should stop the manager once the cli context is done", func() {
+			parent, cancel := context.WithCancel(context.Background())
+			ctx := managerContext(parent, context.Background())
+
+			cancel()
+			Eventually(ctx.Done()).Should(BeClosed())
+		})
+	})
+
+	When("profiling is configured", func() {
+		It("should be disabled by default", func() {
+			options := ctrl.Options{}
+			applyProfiling(contextForApp(), &options)
+
+			Expect(options.PprofBindAddress).To(BeEmpty())
+		})
+
+		It("should serve profiles at the given address", func() {
+			options := ctrl.Options{}
+			applyProfiling(contextForApp("--pprof-bind-address", ":8082"), &options)
+
+			Expect(options.PprofBindAddress).To(Equal(":8082"))
+		})
+	})
+
+	When("dry run is configured", func() {
+		It("should write through the manager's client by default", func() {
+			c := fake.NewClientBuilder().Build()
+
+			Expect(reconcilerClient(contextForApp(), c)).To(BeIdenticalTo(c))
+		})
+
+		It("should wrap the manager's client", func() {
+			c := fake.NewClientBuilder().Build()
+
+			Expect(reconcilerClient(contextForApp("--dry-run"), c)).NotTo(BeIdenticalTo(c))
+		})
+	})
+
+	When("the default image pull policy is configured", func() {
+		It("should use the kubernetes default when unset", func() {
+			policy, err := defaultImagePullPolicy(contextForApp())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(policy).To(BeEmpty())
+		})
+
+		It("should apply the pull policy", func() {
+			policy, err := defaultImagePullPolicy(contextForApp("--default-image-pull-policy", "Always"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(policy).To(Equal(k8scorev1.PullAlways))
+		})
+
+		It("should reject unknown pull policies", func() {
+			_, err := defaultImagePullPolicy(contextForApp("--default-image-pull-policy", "Sometimes"))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	When("the watched namespaces are configured", func() {
+		It("should watch every namespace by default", func() {
+			Expect(cacheOptions(contextForApp()).DefaultNamespaces).To(BeEmpty())
+		})
+
+		It("should watch a single namespace", func() {
+			options := cacheOptions(contextForApp("--namespace", "tenant-a"))
+			Expect(options.DefaultNamespaces).To(Equal(map[string]cache.Config{"tenant-a": {}}))
+		})
+
+		It("should watch a list of namespaces", func() {
+			options := cacheOptions(contextForApp("--watch-namespaces", "tenant-a,tenant-b", "--namespace", "tenant-c"))
+			Expect(options.DefaultNamespaces).To(Equal(map[string]cache.Config{
+				"tenant-a": {},
+				"tenant-b": {},
+				"tenant-c": {},
+			}))
+		})
+	})
+
+	When("the reconcile throughput is configured", func() {
+		It("should use the default sync period when unset", func() {
+			Expect(cacheOptions(contextForApp()).SyncPeriod).To(BeNil())
+		})
+
+		It("should apply the sync period", func() {
+			options := cacheOptions(contextForApp("--sync-period", "30m"))
+			Expect(options.SyncPeriod).To(HaveValue(Equal(30 * time.Minute)))
+		})
+
+		It("should run a single worker by default", func() {
+			Expect(contextForApp().Int("max-concurrent-reconciles")).To(Equal(1))
+		})
+	})
+
+	When("logging is configured", func() {
+		It("should use development logging by default", func() {
+			opts, err := zapOptions(contextForApp())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(opts.Development).To(BeTrue())
+			Expect(opts.Level).To(BeNil())
+			Expect(opts.Encoder).To(BeNil())
+		})
+
+		It("should apply the log level and format", func() {
+			opts, err := zapOptions(contextForApp("--log-level", "info", "--log-format", "json"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(opts.Development).To(BeFalse())
+			Expect(opts.Level).To(Equal(zapcore.InfoLevel))
+
+			buf, err := opts.Encoder.EncodeEntry(zapcore.Entry{Message: "hello"}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(buf.String()).To(HavePrefix("{"))
+		})
+
+		It("should reject unknown levels and formats", func() {
+			_, err := zapOptions(contextForApp("--log-level", "verbose"))
+			Expect(err).To(HaveOccurred())
+
+			_, err = zapOptions(contextForApp("--log-format", "xml"))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+
+	corev1 "github.com/joshmeranda/marina-operator/api/v1"
+)
+
+// apiServerCheck fails while the api server cannot be reached. It lists at most a single terminal, so it is cheap
+// enough to run on every probe. The reader should bypass the manager's cache, which keeps serving stale objects while
+// the api server is unreachable.
+func apiServerCheck(reader client.Reader) healthz.Checker {
+	return func(req *http.Request) error {
+		if err := reader.List(req.Context(), &corev1.TerminalList{}, client.Limit(1)); err != nil {
+			return fmt.Errorf("could not reach api server: %w", err)
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"context"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+var _ = Describe("API Server Check", func() {
+	It("should pass while the api server is reachable", func() {
+		reader := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+		err := apiServerCheck(reader)(httptest.NewRequest("GET", "/readyz", nil))
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should fail while the api server is unreachable", func() {
+		unavailable := errors.NewServiceUnavailable("api server is unavailable")
+		reader := fake.NewClientBuilder().WithScheme(scheme).WithInterceptorFuncs(interceptor.Funcs{
+			List: func(context.Context, client.WithWatch, client.ObjectList, ...client.ListOption) error {
+				return unavailable
+			},
+		}).Build()
+
+		err := apiServerCheck(reader)(httptest.NewRequest("GET", "/readyz", nil))
+		Expect(err).To(MatchError(unavailable))
+	})
+})
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	corev1 "github.com/joshmeranda/marina-operator/api/v1"
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	// generatedPasswordLength is the number of random bytes used to generate a password.
+	generatedPasswordLength = 24
+)
+
+func generatePassword() ([]byte, error) {
+	raw := make([]byte, generatedPasswordLength)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, fmt.Errorf("could not generate password: %w", err)
+	}
+
+	password := make([]byte, base64.RawURLEncoding.EncodedLen(len(raw)))
+	base64.RawURLEncoding.Encode(password, raw)
+
+	return password, nil
+}
+
+// rotateUserPassword replaces the password of the given user with a newly generated one. The controller stores its
+// hash in the user's credentials secret and records the rotation time on the user's status.
+func rotateUserPassword(ctx context.Context, c client.Client, key client.ObjectKey) ([]byte, error) {
+	user := &corev1.User{}
+	if err := c.Get(ctx, key, user); err != nil {
+		return nil, fmt.Errorf("could not fetch user: %w", err)
+	}
+
+	password, err := generatePassword()
+	if err != nil {
+		return nil, err
+	}
+
+	user.Spec.Password = password
+	if err := c.Update(ctx, user); err != nil {
+		return nil, fmt.Errorf("could not update user password: %w", err)
+	}
+
+	return password, nil
+}
+
+func rotatePassword(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		return fmt.Errorf("expected exactly one user name but found %d", ctx.NArg())
+	}
+
+	config, err := restConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	c, err := client.New(config, client.Options{Scheme: scheme})
+	if err != nil {
+		return fmt.Errorf("could not create client: %w", err)
+	}
+
+	key := client.ObjectKey{
+		Namespace: ctx.String("namespace"),
+		Name:      ctx.Args().First(),
+	}
+
+	password, err := rotateUserPassword(ctx.Context, c, key)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(ctx.App.Writer, "%s\n", password)
+
+	return nil
+}
+
+func userCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "user",
+		Usage: "manage marina users",
+		Subcommands: []*cli.Command{
+			{
+				Name:      "rotate-password",
+				Usage:     "generate a new password for a user",
+				ArgsUsage: "<name>",
+				Action:    rotatePassword,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "namespace",
+						Aliases: []string{"n"},
+						Usage:   "The namespace of the user",
+						Value:   "default",
+					},
+				},
+			},
+		},
+	}
+}
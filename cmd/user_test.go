@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"golang.org/x/crypto/bcrypt"
+	k8scorev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	corev1 "github.com/joshmeranda/marina-operator/api/v1"
+	"github.com/joshmeranda/marina-operator/internal/controller"
+)
+
+var _ = Describe("User Command", func() {
+	var ctx context.Context
+	var c client.Client
+	var reconciler *controller.UserReconciler
+	var user *corev1.User
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		user = &corev1.User{
+			ObjectMeta: metav1.ObjectMeta{Name: "user-test", Namespace: "marina-system"},
+			Spec: corev1.UserSpec{
+				Name:     "bilbo",
+				Password: []byte("baggins"),
+			},
+		}
+
+		c = fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithStatusSubresource(&corev1.User{}).
+			WithObjects(user).
+			Build()
+
+		reconciler = &controller.UserReconciler{
+			Client:           c,
+			Scheme:           scheme,
+			PasswordHashCost: bcrypt.MinCost,
+		}
+	})
+
+	// reconcile reconciles the user and returns the password hash stored in its credentials secret.
+	reconcile := func() []byte {
+		GinkgoHelper()
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(user)})
+		Expect(err).NotTo(HaveOccurred())
+
+		secret := &k8scorev1.Secret{}
+		err = c.Get(ctx, client.ObjectKeyFromObject(user), secret)
+		Expect(err).NotTo(HaveOccurred())
+
+		return secret.Data[controller.UserPasswordHashKey]
+	}
+
+	When("a user's password is rotated", func() {
+		It("should replace the password", func() {
+			password, err := rotateUserPassword(ctx, c, client.ObjectKeyFromObject(user))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(password).NotTo(Equal([]byte("baggins")))
+
+			rotated := &corev1.User{}
+			err = c.Get(ctx, client.ObjectKeyFromObject(user), rotated)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rotated.Spec.Password).To(Equal(password))
+
+			again, err := rotateUserPassword(ctx, c, client.ObjectKeyFromObject(user))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(again).NotTo(Equal(password))
+		})
+
+		It("should change the hash in the user's credentials secret", func() {
+			original := reconcile()
+			Expect(bcrypt.CompareHashAndPassword(original, []byte("baggins"))).To(Succeed())
+
+			password, err := rotateUserPassword(ctx, c, client.ObjectKeyFromObject(user))
+			Expect(err).NotTo(HaveOccurred())
+
+			hash := reconcile()
+			Expect(hash).NotTo(Equal(original))
+			Expect(bcrypt.CompareHashAndPassword(hash, password)).To(Succeed())
+
+			rotated := &corev1.User{}
+			err = c.Get(ctx, client.ObjectKeyFromObject(user), rotated)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rotated.Spec.Password).To(BeEmpty())
+			Expect(rotated.Status.PasswordRotatedAt).NotTo(BeNil())
+		})
+	})
+
+	When("the user does not exist", func() {
+		It("should fail", func() {
+			_, err := rotateUserPassword(ctx, c, client.ObjectKey{Namespace: "marina-system", Name: "missing"})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
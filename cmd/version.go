@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	// Version is the version of the build, set at build time with -ldflags "-X".
+	Version = "dev"
+
+	// Commit is the git commit of the build, set at build time with -ldflags "-X".
+	Commit = "unknown"
+)
+
+func printVersion(ctx *cli.Context) error {
+	fmt.Fprintf(ctx.App.Writer, "version: %s\n", Version)
+	fmt.Fprintf(ctx.App.Writer, "commit: %s\n", Commit)
+	fmt.Fprintf(ctx.App.Writer, "go: %s\n", runtime.Version())
+
+	return nil
+}
+
+func versionCommand() *cli.Command {
+	return &cli.Command{
+		Name:   "version",
+		Usage:  "print the build version",
+		Action: printVersion,
+	}
+}
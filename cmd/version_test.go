@@ -0,0 +1,23 @@
+package cmd
+
+import (
+	"bytes"
+	"runtime"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Version Command", func() {
+	It("should print the build version", func() {
+		out := &bytes.Buffer{}
+
+		app := App()
+		app.Writer = out
+
+		err := app.Run([]string{"manager", "version"})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(out.String()).To(Equal("version: " + Version + "\ncommit: " + Commit + "\ngo: " + runtime.Version() + "\n"))
+	})
+})
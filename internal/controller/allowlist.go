@@ -0,0 +1,74 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	marinacorev1 "github.com/joshmeranda/marina-operator/api/v1"
+)
+
+// ImageAllowlistKey is the key of the image allowlist ConfigMap holding the newline separated image patterns (ex
+// docker.io/library/*) terminals may use.
+const ImageAllowlistKey = "images"
+
+// disallowedImage returns the first of the given images which does not match a pattern in the image allowlist, or an
+// empty string if they are all allowed. When no allowlist is configured every image is allowed.
+func (r *TerminalReconciler) disallowedImage(ctx context.Context, images []string) (string, error) {
+	if r.ImageAllowlist == (types.NamespacedName{}) {
+		return "", nil
+	}
+
+	configMap := &corev1.ConfigMap{}
+	if err := r.Get(ctx, r.ImageAllowlist, configMap); err != nil {
+		return "", fmt.Errorf("could not fetch image allowlist: %w", err)
+	}
+
+	var patterns []string
+	for _, pattern := range strings.Split(configMap.Data[ImageAllowlistKey], "\n") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			patterns = append(patterns, pattern)
+		}
+	}
+
+	for _, image := range images {
+		allowed, err := marinacorev1.MatchesAnyImage(patterns, image)
+		if err != nil {
+			return "", err
+		}
+
+		if !allowed {
+			return image, nil
+		}
+	}
+
+	return "", nil
+}
+
+// terminalsForAllowlist enqueues every terminal when the image allowlist changes, so that any terminals using a newly
+// disallowed image are flagged.
+func (r *TerminalReconciler) terminalsForAllowlist(ctx context.Context, obj client.Object) []reconcile.Request {
+	if client.ObjectKeyFromObject(obj) != r.ImageAllowlist {
+		return nil
+	}
+
+	terminals := &marinacorev1.TerminalList{}
+	if err := r.List(ctx, terminals); err != nil {
+		log.FromContext(ctx).Error(err, "could not list terminals for image allowlist change")
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(terminals.Items))
+	for _, terminal := range terminals.Items {
+		requests = append(requests, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(&terminal)})
+	}
+
+	return requests
+}
@@ -0,0 +1,42 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	marinacorev1 "github.com/joshmeranda/marina-operator/api/v1"
+)
+
+// FinalizeDeleted runs the finalizers of every terminal which is being deleted, so they are not left waiting for the
+// operator to restart. It is a best-effort pass: a terminal which cannot be finalized does not stop the others.
+func (r *TerminalReconciler) FinalizeDeleted(ctx context.Context) error {
+	logger := log.FromContext(ctx)
+
+	terminals := &marinacorev1.TerminalList{}
+	if err := r.List(ctx, terminals); err != nil {
+		return fmt.Errorf("could not list terminals: %w", err)
+	}
+
+	var errs []error
+
+	for _, terminal := range terminals.Items {
+		if terminal.GetDeletionTimestamp() == nil {
+			continue
+		}
+
+		key := client.ObjectKeyFromObject(&terminal)
+		if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err != nil {
+			errs = append(errs, fmt.Errorf("could not finalize terminal '%s': %w", key, err))
+			continue
+		}
+
+		logger.Info("finalized deleted terminal", "terminal", key)
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,123 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	marinacorev1 "github.com/joshmeranda/marina-operator/api/v1"
+)
+
+const (
+	// TerminalConnectionHostKey is the key of the connection config map holding the dns name of the terminal service.
+	TerminalConnectionHostKey = "host"
+
+	// TerminalConnectionPortKey is the key of the connection config map holding the ssh port of the terminal service.
+	TerminalConnectionPortKey = "port"
+
+	// TerminalConnectionNodePortKey is the key of the connection config map holding the node port of the terminal
+	// service. It is only set for NodePort services.
+	TerminalConnectionNodePortKey = "nodePort"
+
+	// TerminalConnectionReadyKey is the key of the connection config map holding whether the terminal is ready to
+	// accept connections, either "true" or "false".
+	TerminalConnectionReadyKey = "ready"
+)
+
+func connectionConfigMapForTerminal(terminal *marinacorev1.Terminal) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      childNameForTerminal(terminal),
+			Namespace: childNamespaceForTerminal(terminal),
+		},
+	}
+}
+
+// connectionDataForService returns the connection info of a terminal exposed by the given service.
+func connectionDataForService(service *corev1.Service, ready bool) map[string]string {
+	data := map[string]string{
+		TerminalConnectionHostKey:  service.Name + "." + service.Namespace + ".svc",
+		TerminalConnectionReadyKey: strconv.FormatBool(ready),
+	}
+
+	for _, port := range service.Spec.Ports {
+		if port.Name != "ssh" {
+			continue
+		}
+
+		data[TerminalConnectionPortKey] = strconv.Itoa(int(port.Port))
+
+		if port.NodePort != 0 {
+			data[TerminalConnectionNodePortKey] = strconv.Itoa(int(port.NodePort))
+		}
+	}
+
+	return data
+}
+
+// reconcileConnectionConfigMap publishes how to reach the terminal in a config map, so clients can discover terminals
+// without reading their services. It is taken from the terminal's service as it exists, so it must be reconciled after
+// the service.
+func (r *TerminalReconciler) reconcileConnectionConfigMap(ctx context.Context, terminal *marinacorev1.Terminal) error {
+	logger := log.FromContext(ctx)
+	configMap := connectionConfigMapForTerminal(terminal)
+
+	if terminal.GetDeletionTimestamp() != nil {
+		if controllerutil.ContainsFinalizer(terminal, TerminalConnectionFinalizer) {
+			if err := r.Delete(ctx, configMap); client.IgnoreNotFound(err) != nil {
+				return fmt.Errorf("could not delete connection config map: %w", err)
+			}
+
+			controllerutil.RemoveFinalizer(terminal, TerminalConnectionFinalizer)
+
+			logger.Info("deleted terminal connection config map", "terminal", client.ObjectKeyFromObject(terminal))
+		}
+
+		return nil
+	}
+
+	_ = controllerutil.AddFinalizer(terminal, TerminalConnectionFinalizer)
+
+	// a service which does not exist (ex in a dry run) is described by its desired state instead
+	service := serviceForTerminal(terminal, r.DefaultServiceAnnotations)
+	if err := r.Get(ctx, client.ObjectKeyFromObject(service), service); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("could not fetch service: %w", err)
+	}
+
+	// terminals which run to completion or are scaled down have no pods to connect to
+	ready := false
+	if !terminal.Spec.RunToCompletion && replicasForTerminal(terminal) > 0 {
+		available, err := r.deploymentAvailable(ctx, terminal)
+		if err != nil {
+			return err
+		}
+
+		ready = available
+	}
+
+	result, err := controllerutil.CreateOrUpdate(ctx, r.Client, configMap, func() error {
+		configMap.Labels = labelsForTerminal(terminal)
+		configMap.Data = connectionDataForService(service, ready)
+
+		return r.setTerminalOwner(terminal, configMap)
+	})
+	if err != nil {
+		return fmt.Errorf("could not reconcile connection config map: %w", err)
+	}
+
+	switch result {
+	case controllerutil.OperationResultCreated:
+		logger.Info("created terminal connection config map", "terminal", client.ObjectKeyFromObject(terminal))
+		r.recordEvent(terminal, corev1.EventTypeNormal, "Created", "created config map %s", configMap.Name)
+	case controllerutil.OperationResultUpdated:
+		logger.Info("updated terminal connection config map", "terminal", client.ObjectKeyFromObject(terminal))
+	}
+
+	return nil
+}
@@ -0,0 +1,100 @@
+package controller
+
+import (
+	"context"
+
+	"github.com/google/go-cmp/cmp"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// dryRunClient sends every write to the api server as a dry run, logging the changes it would have made instead.
+type dryRunClient struct {
+	client.Client
+}
+
+// NewDryRunClient returns a client which never persists any changes. Creates, updates, patches and deletes are logged
+// along with the difference they would have made to the object. Status writes are sent as dry runs without logging.
+func NewDryRunClient(c client.Client) client.Client {
+	return dryRunClient{Client: client.NewDryRunClient(c)}
+}
+
+func (c dryRunClient) logChange(ctx context.Context, action string, obj client.Object, keysAndValues ...any) {
+	kind := ""
+	if gvk, err := apiutil.GVKForObject(obj, c.Scheme()); err == nil {
+		kind = gvk.Kind
+	}
+
+	keysAndValues = append([]any{"kind", kind, "object", client.ObjectKeyFromObject(obj)}, keysAndValues...)
+	log.FromContext(ctx).Info("dry run would "+action+" object", keysAndValues...)
+}
+
+// current returns the object as it currently exists, or nil if it cannot be fetched.
+func (c dryRunClient) current(ctx context.Context, obj client.Object) client.Object {
+	gvk, err := apiutil.GVKForObject(obj, c.Scheme())
+	if err != nil {
+		return nil
+	}
+
+	// the object is fetched into a new instance, since decoding into obj itself would merge with its desired state
+	fresh, err := c.Scheme().New(gvk)
+	if err != nil {
+		return nil
+	}
+
+	existing, ok := fresh.(client.Object)
+	if !ok {
+		return nil
+	}
+
+	if err := c.Get(ctx, client.ObjectKeyFromObject(obj), existing); err != nil {
+		return nil
+	}
+
+	return existing
+}
+
+func (c dryRunClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	if err := c.Client.Create(ctx, obj, opts...); err != nil {
+		return err
+	}
+
+	c.logChange(ctx, "create", obj)
+
+	return nil
+}
+
+func (c dryRunClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	existing := c.current(ctx, obj)
+
+	if err := c.Client.Update(ctx, obj, opts...); err != nil {
+		return err
+	}
+
+	c.logChange(ctx, "update", obj, "diff", cmp.Diff(existing, obj))
+
+	return nil
+}
+
+func (c dryRunClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	existing := c.current(ctx, obj)
+
+	if err := c.Client.Patch(ctx, obj, patch, opts...); err != nil {
+		return err
+	}
+
+	c.logChange(ctx, "patch", obj, "diff", cmp.Diff(existing, obj))
+
+	return nil
+}
+
+func (c dryRunClient) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	if err := c.Client.Delete(ctx, obj, opts...); err != nil {
+		return err
+	}
+
+	c.logChange(ctx, "delete", obj)
+
+	return nil
+}
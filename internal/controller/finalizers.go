@@ -0,0 +1,36 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// persistFinalizers patches only the finalizers of an object being deleted, so finalizers removed once their children
+// were cleaned up are kept even if cleaning up another child fails. Failures are logged and counted, since the object
+// stays terminating until its finalizers are removed.
+func persistFinalizers(ctx context.Context, c client.Client, controller string, original client.Object, obj client.Object) error {
+	if obj.GetDeletionTimestamp() == nil || slices.Equal(original.GetFinalizers(), obj.GetFinalizers()) {
+		return nil
+	}
+
+	patched, ok := original.DeepCopyObject().(client.Object)
+	if !ok {
+		return fmt.Errorf("expected a client.Object but got a %T", original)
+	}
+
+	patched.SetFinalizers(obj.GetFinalizers())
+
+	// the lock keeps finalizers added by others since the object was fetched from being dropped
+	if err := c.Patch(ctx, patched, client.MergeFromWithOptions(original, client.MergeFromWithOptimisticLock{})); err != nil {
+		finalizerErrors.WithLabelValues(controller).Inc()
+		log.FromContext(ctx).Error(err, "could not remove finalizers", controller, client.ObjectKeyFromObject(obj), "finalizers", obj.GetFinalizers())
+
+		return fmt.Errorf("could not remove finalizers: %w", err)
+	}
+
+	return nil
+}
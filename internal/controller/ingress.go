@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	marinacorev1 "github.com/joshmeranda/marina-operator/api/v1"
+)
+
+func ingressForTerminal(terminal *marinacorev1.Terminal) *networkingv1.Ingress {
+	return &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      childNameForTerminal(terminal),
+			Namespace: childNamespaceForTerminal(terminal),
+		},
+	}
+}
+
+// ingressSpecForTerminal returns the spec of the ingress routing the terminal's ingress host and path to the terminal
+// service.
+func ingressSpecForTerminal(terminal *marinacorev1.Terminal) networkingv1.IngressSpec {
+	path := terminal.Spec.Ingress.Path
+	if path == "" {
+		path = "/"
+	}
+
+	return networkingv1.IngressSpec{
+		IngressClassName: terminal.Spec.Ingress.IngressClassName,
+		Rules: []networkingv1.IngressRule{
+			{
+				Host: terminal.Spec.Ingress.Host,
+				IngressRuleValue: networkingv1.IngressRuleValue{
+					HTTP: &networkingv1.HTTPIngressRuleValue{
+						Paths: []networkingv1.HTTPIngressPath{
+							{
+								Path:     path,
+								PathType: ToPtr(networkingv1.PathTypePrefix),
+								Backend: networkingv1.IngressBackend{
+									Service: &networkingv1.IngressServiceBackend{
+										Name: serviceForTerminal(terminal, nil).Name,
+										Port: networkingv1.ServiceBackendPort{
+											Name: "ssh",
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// reconcileIngress manages the ingress of the given terminal, removing it once the terminal no longer has an ingress.
+func (r *TerminalReconciler) reconcileIngress(ctx context.Context, terminal *marinacorev1.Terminal) error {
+	logger := log.FromContext(ctx)
+	ingress := ingressForTerminal(terminal)
+
+	if terminal.GetDeletionTimestamp() != nil || terminal.Spec.Ingress == nil || terminal.Spec.RunToCompletion {
+		if controllerutil.ContainsFinalizer(terminal, TerminalIngressFinalizer) {
+			if err := r.Delete(ctx, ingress); client.IgnoreNotFound(err) != nil {
+				return fmt.Errorf("could not delete ingress: %w", err)
+			}
+
+			controllerutil.RemoveFinalizer(terminal, TerminalIngressFinalizer)
+
+			logger.Info("deleted terminal ingress", "terminal", client.ObjectKeyFromObject(terminal))
+		}
+
+		return nil
+	}
+
+	_ = controllerutil.AddFinalizer(terminal, TerminalIngressFinalizer)
+
+	result, err := controllerutil.CreateOrUpdate(ctx, r.Client, ingress, func() error {
+		ingress.Labels = labelsForTerminal(terminal)
+		ingress.Spec = ingressSpecForTerminal(terminal)
+
+		return r.setTerminalOwner(terminal, ingress)
+	})
+	if err != nil {
+		return fmt.Errorf("could not reconcile ingress: %w", err)
+	}
+
+	switch result {
+	case controllerutil.OperationResultCreated:
+		logger.Info("created terminal ingress", "terminal", client.ObjectKeyFromObject(terminal))
+		r.recordEvent(terminal, corev1.EventTypeNormal, "Created", "created ingress %s", ingress.Name)
+	case controllerutil.OperationResultUpdated:
+		logger.Info("updated terminal ingress", "terminal", client.ObjectKeyFromObject(terminal))
+	}
+
+	return nil
+}
@@ -0,0 +1,122 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	marinacorev1 "github.com/joshmeranda/marina-operator/api/v1"
+)
+
+// inlineRoleForUser returns the role holding the user's inline rules.
+func inlineRoleForUser(user *marinacorev1.User) *rbacv1.Role {
+	return &rbacv1.Role{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      user.Name + "-inline",
+			Namespace: user.Namespace,
+		},
+	}
+}
+
+// inlineRoleBindingForUser returns the role binding granting the user their inline role. It is owned by the user rather
+// than labelled for it like the bindings of the user's roles, which would have it pruned.
+func inlineRoleBindingForUser(user *marinacorev1.User) *rbacv1.RoleBinding {
+	role := inlineRoleForUser(user)
+
+	return &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      user.Name + "-" + role.Name,
+			Namespace: role.Namespace,
+		},
+	}
+}
+
+// reconcileInlineRole creates a role from the user's inline rules and binds the user to it, revoking the binding while
+// the user is suspended. Both are deleted once the user is deleted or no longer has any inline rules, and are never
+// created unless the inline rules were reviewed by the user webhook.
+func (r *UserReconciler) reconcileInlineRole(ctx context.Context, user *marinacorev1.User, suspended bool) error {
+	logger := log.FromContext(ctx)
+	role := inlineRoleForUser(user)
+	binding := inlineRoleBindingForUser(user)
+
+	unreviewed := len(user.Spec.InlineRoles) > 0 && !r.InlineRolesReviewed
+	if unreviewed && user.GetDeletionTimestamp() == nil {
+		logger.Info("refusing inline roles which were not reviewed by the user webhook", "user", client.ObjectKeyFromObject(user))
+		r.recordEvent(user, corev1.EventTypeWarning, "InlineRolesRefused", "inline roles are only granted when the user webhook is enabled")
+	}
+
+	if user.GetDeletionTimestamp() != nil || len(user.Spec.InlineRoles) == 0 || unreviewed {
+		if controllerutil.ContainsFinalizer(user, UserInlineRoleFinalizer) {
+			if err := r.Delete(ctx, binding); client.IgnoreNotFound(err) != nil {
+				logger.Error(err, "could not delete inline role binding", "rolebinding", client.ObjectKeyFromObject(binding))
+				return err
+			}
+
+			if err := r.Delete(ctx, role); client.IgnoreNotFound(err) != nil {
+				logger.Error(err, "could not delete inline role", "role", client.ObjectKeyFromObject(role))
+				return err
+			}
+
+			logger.Info("deleted inline role", "role", client.ObjectKeyFromObject(role))
+			r.recordEvent(user, corev1.EventTypeNormal, "Deleted", "deleted role %s", role.Name)
+
+			controllerutil.RemoveFinalizer(user, UserInlineRoleFinalizer)
+		}
+
+		return nil
+	}
+
+	_ = controllerutil.AddFinalizer(user, UserInlineRoleFinalizer)
+
+	result, err := controllerutil.CreateOrUpdate(ctx, r.Client, role, func() error {
+		role.Rules = user.Spec.InlineRoles
+		return r.setUserOwner(user, role)
+	})
+	if err != nil {
+		return fmt.Errorf("could not reconcile inline role: %w", err)
+	}
+
+	if result == controllerutil.OperationResultCreated {
+		logger.Info("created inline role", "role", client.ObjectKeyFromObject(role))
+		r.recordEvent(user, corev1.EventTypeNormal, "Created", "created role %s", role.Name)
+	}
+
+	// the role is kept while the user is suspended, so the binding is restored if the suspension is lifted
+	if suspended {
+		if err := r.Delete(ctx, binding); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+
+		logger.Info("revoked inline role binding", "rolebinding", client.ObjectKeyFromObject(binding))
+		r.recordEvent(user, corev1.EventTypeNormal, "Deleted", "revoked role binding %s", binding.Name)
+
+		return nil
+	}
+
+	result, err = controllerutil.CreateOrUpdate(ctx, r.Client, binding, func() error {
+		binding.Subjects = []rbacv1.Subject{subjectForUser(user)}
+		binding.RoleRef = rbacv1.RoleRef{
+			Kind:     "Role",
+			Name:     role.Name,
+			APIGroup: "rbac.authorization.k8s.io",
+		}
+
+		return r.setUserOwner(user, binding)
+	})
+	if err != nil {
+		return fmt.Errorf("could not reconcile inline role binding: %w", err)
+	}
+
+	if result == controllerutil.OperationResultCreated {
+		logger.Info("created inline role binding", "rolebinding", client.ObjectKeyFromObject(binding))
+		r.recordEvent(user, corev1.EventTypeNormal, "Created", "created role binding %s", binding.Name)
+	}
+
+	return nil
+}
@@ -0,0 +1,152 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	marinacorev1 "github.com/joshmeranda/marina-operator/api/v1"
+)
+
+const (
+	UserKubeconfigFinalizer = "marina.io.kubeconfig/finalizer"
+
+	// UserKubeconfigKey is the key of the kubeconfig secret holding the user's kubeconfig.
+	UserKubeconfigKey = "kubeconfig"
+
+	// KubeconfigExpiresAtAnnotation records when the token in a user's kubeconfig expires.
+	KubeconfigExpiresAtAnnotation = "marina.io/token-expires-at"
+
+	// DefaultKubeconfigTokenTTL is how long the tokens in user kubeconfigs are valid for.
+	DefaultKubeconfigTokenTTL = 24 * time.Hour
+)
+
+func kubeconfigSecretForUser(user *marinacorev1.User) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      user.Name + "-kubeconfig",
+			Namespace: user.Namespace,
+		},
+	}
+}
+
+// kubeconfigForUser returns a kubeconfig authenticating as the user's service account, defaulting to the namespace
+// the user's roles are granted in, or to the user's provisioned namespace if their roles are not granted elsewhere.
+func kubeconfigForUser(user *marinacorev1.User, server string, caData []byte, token string) *clientcmdapi.Config {
+	namespace := serviceAccountNamespace(user)
+	if user.Spec.GrantNamespace != "" {
+		namespace = user.Spec.GrantNamespace
+	}
+
+	config := clientcmdapi.NewConfig()
+	config.Clusters["marina"] = &clientcmdapi.Cluster{
+		Server:                   server,
+		CertificateAuthorityData: caData,
+	}
+	config.AuthInfos[user.Name] = &clientcmdapi.AuthInfo{
+		Token: token,
+	}
+	config.Contexts["marina"] = &clientcmdapi.Context{
+		Cluster:   "marina",
+		AuthInfo:  user.Name,
+		Namespace: namespace,
+	}
+	config.CurrentContext = "marina"
+
+	return config
+}
+
+func (r *UserReconciler) kubeconfigTokenTTL() time.Duration {
+	if r.KubeconfigTokenTTL == 0 {
+		return DefaultKubeconfigTokenTTL
+	}
+
+	return r.KubeconfigTokenTTL
+}
+
+// reconcileKubeconfig keeps a kubeconfig for the user's service account in the user's kubeconfig secret. Tokens are
+// reissued once 80% of their lifetime has passed. It returns how long until the token should next be reissued, or 0
+// if no kubeconfig is kept.
+func (r *UserReconciler) reconcileKubeconfig(ctx context.Context, user *marinacorev1.User) (time.Duration, error) {
+	logger := log.FromContext(ctx)
+	secret := kubeconfigSecretForUser(user)
+
+	if user.GetDeletionTimestamp() != nil {
+		if controllerutil.ContainsFinalizer(user, UserKubeconfigFinalizer) {
+			if err := r.Delete(ctx, secret); client.IgnoreNotFound(err) != nil {
+				logger.Error(err, "could not delete kubeconfig secret", "secret", client.ObjectKeyFromObject(secret))
+				return 0, err
+			}
+
+			controllerutil.RemoveFinalizer(user, UserKubeconfigFinalizer)
+		}
+
+		return 0, nil
+	}
+
+	if r.KubeconfigServer == "" {
+		return 0, nil
+	}
+
+	_ = controllerutil.AddFinalizer(user, UserKubeconfigFinalizer)
+
+	ttl := r.kubeconfigTokenTTL()
+
+	existing := &corev1.Secret{}
+	if err := r.Get(ctx, client.ObjectKeyFromObject(secret), existing); client.IgnoreNotFound(err) != nil {
+		return 0, fmt.Errorf("could not fetch kubeconfig secret: %w", err)
+	} else if err == nil {
+		if expiresAt, err := time.Parse(time.RFC3339, existing.Annotations[KubeconfigExpiresAtAnnotation]); err == nil {
+			if refreshAt := expiresAt.Add(-ttl / 5); r.now().Before(refreshAt) {
+				return refreshAt.Sub(r.now()), nil
+			}
+		}
+	}
+
+	serviceAccount := serviceAccountForUser(user)
+	request := &authenticationv1.TokenRequest{
+		Spec: authenticationv1.TokenRequestSpec{
+			ExpirationSeconds: ToPtr(int64(ttl.Seconds())),
+		},
+	}
+
+	if err := r.SubResource("token").Create(ctx, serviceAccount, request); err != nil {
+		return 0, fmt.Errorf("could not request service account token: %w", err)
+	}
+
+	raw, err := clientcmd.Write(*kubeconfigForUser(user, r.KubeconfigServer, r.KubeconfigCAData, request.Status.Token))
+	if err != nil {
+		return 0, fmt.Errorf("could not encode kubeconfig: %w", err)
+	}
+
+	expiresAt := request.Status.ExpirationTimestamp.Time
+
+	if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, secret, func() error {
+		if secret.Annotations == nil {
+			secret.Annotations = make(map[string]string)
+		}
+
+		secret.Annotations[corev1.ServiceAccountNameKey] = serviceAccount.Name
+		secret.Annotations[KubeconfigExpiresAtAnnotation] = expiresAt.UTC().Format(time.RFC3339)
+		secret.Data = map[string][]byte{
+			UserKubeconfigKey: raw,
+		}
+
+		return controllerutil.SetControllerReference(user, secret, r.Scheme)
+	}); err != nil {
+		return 0, fmt.Errorf("could not store kubeconfig: %w", err)
+	}
+
+	logger.Info("issued kubeconfig for user", "secret", client.ObjectKeyFromObject(secret))
+
+	return expiresAt.Add(-ttl / 5).Sub(r.now()), nil
+}
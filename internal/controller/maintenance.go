@@ -0,0 +1,191 @@
+package controller
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaintenanceRequeueInterval is how long reconcilers wait before retrying a resource skipped during a maintenance
+// window.
+const MaintenanceRequeueInterval = time.Minute
+
+// maxTransitionSearch bounds how far ahead UntilTransition looks for the window to open or close.
+const maxTransitionSearch = 366 * 24 * time.Hour
+
+// cronField is the set of values matched by a single field of a cron schedule.
+type cronField map[int]bool
+
+func parseCronField(field string, min int, max int) (cronField, error) {
+	values := cronField{}
+
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+
+		if before, after, found := strings.Cut(part, "/"); found {
+			var err error
+			if step, err = strconv.Atoi(after); err != nil || step < 1 {
+				return nil, fmt.Errorf("invalid step '%s'", after)
+			}
+			part = before
+		}
+
+		start, end := min, max
+
+		switch {
+		case part == "*":
+		case strings.Contains(part, "-"):
+			before, after, _ := strings.Cut(part, "-")
+
+			var err error
+			if start, err = strconv.Atoi(before); err != nil {
+				return nil, fmt.Errorf("invalid range start '%s'", before)
+			}
+			if end, err = strconv.Atoi(after); err != nil {
+				return nil, fmt.Errorf("invalid range end '%s'", after)
+			}
+		default:
+			value, err := strconv.Atoi(part)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value '%s'", part)
+			}
+			start, end = value, value
+		}
+
+		if start < min || end > max || start > end {
+			return nil, fmt.Errorf("value '%s' out of range [%d, %d]", part, min, max)
+		}
+
+		for i := start; i <= end; i += step {
+			values[i] = true
+		}
+	}
+
+	return values, nil
+}
+
+// MaintenanceWindow is a recurring period of time during which reconcilers pause any mutating writes. Each window
+// starts whenever its cron schedule matches and lasts for its duration.
+type MaintenanceWindow struct {
+	minutes  cronField
+	hours    cronField
+	days     cronField
+	months   cronField
+	weekdays cronField
+
+	duration time.Duration
+}
+
+// ParseMaintenanceWindow parses a standard 5 field cron schedule (minute, hour, day of month, month, day of week) into
+// a MaintenanceWindow lasting for the given duration.
+func ParseMaintenanceWindow(schedule string, duration time.Duration) (*MaintenanceWindow, error) {
+	fields := strings.Fields(schedule)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expected 5 fields in cron schedule but found %d", len(fields))
+	}
+
+	if duration <= 0 {
+		return nil, fmt.Errorf("maintenance window duration must be positive")
+	}
+
+	bounds := [][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 6}}
+	parsed := make([]cronField, len(fields))
+
+	for i, field := range fields {
+		var err error
+		if parsed[i], err = parseCronField(field, bounds[i][0], bounds[i][1]); err != nil {
+			return nil, fmt.Errorf("invalid cron field '%s': %w", field, err)
+		}
+	}
+
+	return &MaintenanceWindow{
+		minutes:  parsed[0],
+		hours:    parsed[1],
+		days:     parsed[2],
+		months:   parsed[3],
+		weekdays: parsed[4],
+		duration: duration,
+	}, nil
+}
+
+func (w *MaintenanceWindow) matches(t time.Time) bool {
+	return w.minutes[t.Minute()] && w.hours[t.Hour()] && w.days[t.Day()] && w.months[int(t.Month())] && w.weekdays[int(t.Weekday())]
+}
+
+// Active reports whether the given time falls within the maintenance window. A nil window is never active.
+func (w *MaintenanceWindow) Active(now time.Time) bool {
+	if w == nil {
+		return false
+	}
+
+	now = now.Truncate(time.Minute)
+
+	for start := now; now.Sub(start) < w.duration; start = start.Add(-time.Minute) {
+		if w.matches(start) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// next returns the first minute from t on which the schedule matches, or false if there is none within
+// maxTransitionSearch. Months, days and hours which cannot match are skipped whole rather than minute by minute.
+func (w *MaintenanceWindow) next(t time.Time) (time.Time, bool) {
+	t = t.Truncate(time.Minute)
+	limit := t.Add(maxTransitionSearch)
+
+	for !t.After(limit) {
+		switch {
+		case !w.months[int(t.Month())]:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !w.days[t.Day()] || !w.weekdays[int(t.Weekday())]:
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case !w.hours[t.Hour()]:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case !w.minutes[t.Minute()]:
+			t = t.Add(time.Minute)
+		default:
+			return t, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// UntilTransition returns how long until the window next opens or closes, or 0 if it does not within a year. A nil
+// window never transitions.
+func (w *MaintenanceWindow) UntilTransition(now time.Time) time.Duration {
+	if w == nil {
+		return 0
+	}
+
+	start := now.Truncate(time.Minute)
+
+	if !w.Active(now) {
+		if opens, found := w.next(start.Add(time.Minute)); found {
+			return opens.Sub(now)
+		}
+
+		return 0
+	}
+
+	// the window closes once it runs its duration past the latest start, unless it starts again before then
+	latest := start
+	for !w.matches(latest) {
+		latest = latest.Add(-time.Minute)
+	}
+
+	closes := latest.Add(w.duration)
+	for closes.Sub(start) <= maxTransitionSearch {
+		again, found := w.next(latest.Add(time.Minute))
+		if !found || again.After(closes) {
+			return closes.Sub(now)
+		}
+
+		latest, closes = again, again.Add(w.duration)
+	}
+
+	return 0
+}
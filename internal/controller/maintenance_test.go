@@ -0,0 +1,84 @@
+package controller
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Maintenance Window", func() {
+	When("a schedule is parsed", func() {
+		It("should reject invalid schedules", func() {
+			_, err := ParseMaintenanceWindow("* * * *", time.Hour)
+			Expect(err).To(HaveOccurred())
+
+			_, err = ParseMaintenanceWindow("60 * * * *", time.Hour)
+			Expect(err).To(HaveOccurred())
+
+			_, err = ParseMaintenanceWindow("* * * * *", 0)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	When("a window is checked", func() {
+		var window *MaintenanceWindow
+
+		BeforeEach(func() {
+			var err error
+			window, err = ParseMaintenanceWindow("0 2 * * 6", 2*time.Hour)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should be active during the window", func() {
+			Expect(window.Active(time.Date(2024, time.June, 1, 2, 0, 0, 0, time.UTC))).To(BeTrue())
+			Expect(window.Active(time.Date(2024, time.June, 1, 3, 59, 0, 0, time.UTC))).To(BeTrue())
+		})
+
+		It("should not be active outside the window", func() {
+			Expect(window.Active(time.Date(2024, time.June, 1, 1, 59, 0, 0, time.UTC))).To(BeFalse())
+			Expect(window.Active(time.Date(2024, time.June, 1, 4, 0, 0, 0, time.UTC))).To(BeFalse())
+			Expect(window.Active(time.Date(2024, time.June, 2, 2, 30, 0, 0, time.UTC))).To(BeFalse())
+		})
+
+		It("should never be active when nil", func() {
+			Expect((*MaintenanceWindow)(nil).Active(time.Now())).To(BeFalse())
+		})
+
+		It("should find when the window next opens", func() {
+			Expect(window.UntilTransition(time.Date(2024, time.June, 1, 1, 30, 0, 0, time.UTC))).To(Equal(30 * time.Minute))
+			Expect(window.UntilTransition(time.Date(2024, time.June, 1, 4, 0, 0, 0, time.UTC))).To(Equal(166 * time.Hour))
+		})
+
+		It("should find when the window next closes", func() {
+			Expect(window.UntilTransition(time.Date(2024, time.June, 1, 3, 15, 30, 0, time.UTC))).To(Equal(44*time.Minute + 30*time.Second))
+		})
+
+		It("should find transitions far in the future", func() {
+			leapDay, err := ParseMaintenanceWindow("0 0 29 2 *", time.Hour)
+			Expect(err).NotTo(HaveOccurred())
+
+			now := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+			Expect(leapDay.UntilTransition(now)).To(BeZero())
+
+			now = time.Date(2027, time.March, 1, 0, 0, 0, 0, time.UTC)
+			Expect(leapDay.UntilTransition(now)).To(Equal(time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC).Sub(now)))
+		})
+
+		It("should not close windows which start again before they end", func() {
+			always, err := ParseMaintenanceWindow("* * * * *", time.Hour)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(always.UntilTransition(time.Date(2024, time.June, 1, 2, 0, 0, 0, time.UTC))).To(BeZero())
+
+			hourly, err := ParseMaintenanceWindow("0 * * * 6", 90*time.Minute)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(hourly.UntilTransition(time.Date(2024, time.June, 1, 12, 0, 0, 0, time.UTC))).To(Equal(12*time.Hour + 30*time.Minute))
+		})
+
+		It("should never transition when nil", func() {
+			Expect((*MaintenanceWindow)(nil).UntilTransition(time.Now())).To(BeZero())
+		})
+	})
+})
@@ -0,0 +1,66 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	reconcileDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "marina_reconcile_duration_seconds",
+		Help:    "How long each reconcile took, with exemplars linking to the reconcile trace when tracing is enabled.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"controller"})
+
+	terminalsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "marina_terminals_total",
+		Help: "Number of terminals reconciled for the first time.",
+	})
+
+	terminalReconcileErrors = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "marina_terminal_reconcile_errors_total",
+		Help: "Number of terminal reconciles which returned an error.",
+	})
+
+	usersTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "marina_users_total",
+		Help: "Number of users reconciled for the first time.",
+	})
+
+	userReconcileErrors = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "marina_user_reconcile_errors_total",
+		Help: "Number of user reconciles which returned an error.",
+	})
+
+	reconcileRetries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "marina_reconcile_retries_total",
+		Help: "Number of failed reconciles which were requeued to be retried.",
+	}, []string{"controller"})
+
+	finalizerErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "marina_finalizer_errors_total",
+		Help: "Number of times the finalizers removed from a deleted object could not be persisted.",
+	}, []string{"controller"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(reconcileDuration, terminalsTotal, terminalReconcileErrors, usersTotal, userReconcileErrors, reconcileRetries, finalizerErrors)
+}
+
+// observeReconcile records how long a reconcile started at the given time took. It is meant to be deferred at the start
+// of a reconcile. When the reconcile is part of a sampled OpenTelemetry trace, the trace id is attached as an exemplar.
+func observeReconcile(ctx context.Context, controller string, start time.Time) {
+	observer := reconcileDuration.WithLabelValues(controller)
+	duration := time.Since(start).Seconds()
+
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() && spanContext.IsSampled() {
+		observer.(prometheus.ExemplarObserver).ObserveWithExemplar(duration, prometheus.Labels{"trace_id": spanContext.TraceID().String()})
+		return
+	}
+
+	observer.Observe(duration)
+}
@@ -0,0 +1,164 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"go.opentelemetry.io/otel/trace"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	marinacorev1 "github.com/joshmeranda/marina-operator/api/v1"
+)
+
+var _ = Describe("Metrics", func() {
+	exemplarsFor := func(controller string) []*dto.Exemplar {
+		metric := &dto.Metric{}
+		err := reconcileDuration.WithLabelValues(controller).(prometheus.Metric).Write(metric)
+		Expect(err).NotTo(HaveOccurred())
+
+		var exemplars []*dto.Exemplar
+		for _, bucket := range metric.GetHistogram().GetBucket() {
+			if bucket.GetExemplar() != nil {
+				exemplars = append(exemplars, bucket.GetExemplar())
+			}
+		}
+
+		return exemplars
+	}
+
+	When("tracing is enabled", func() {
+		It("should attach the trace id as an exemplar", func() {
+			traceID, err := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+			Expect(err).NotTo(HaveOccurred())
+
+			spanID, err := trace.SpanIDFromHex("00f067aa0ba902b7")
+			Expect(err).NotTo(HaveOccurred())
+
+			ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+				TraceID:    traceID,
+				SpanID:     spanID,
+				TraceFlags: trace.FlagsSampled,
+			}))
+			observeReconcile(ctx, "traced", time.Now())
+
+			exemplars := exemplarsFor("traced")
+			Expect(exemplars).To(HaveLen(1))
+			Expect(exemplars[0].GetLabel()).To(ContainElement(HaveField("Value", HaveValue(Equal("4bf92f3577b34da6a3ce929d0e0e4736")))))
+		})
+	})
+
+	When("tracing is disabled", func() {
+		It("should not attach any exemplars", func() {
+			observeReconcile(context.Background(), "untraced", time.Now())
+			Expect(exemplarsFor("untraced")).To(BeEmpty())
+		})
+	})
+
+	When("terminals and users are reconciled", func() {
+		var ctx context.Context
+
+		// counterValue returns the value of the named counter in the manager's metrics registry.
+		counterValue := func(name string) float64 {
+			families, err := metrics.Registry.Gather()
+			Expect(err).NotTo(HaveOccurred())
+
+			for _, family := range families {
+				if family.GetName() == name {
+					return family.GetMetric()[0].GetCounter().GetValue()
+				}
+			}
+
+			Fail("no metric named " + name)
+			return 0
+		}
+
+		BeforeEach(func() {
+			ctx = context.Background()
+
+			namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "marina-system"}}
+			err := k8sClient.Create(ctx, namespace)
+			if !errors.IsAlreadyExists(err) {
+				Expect(err).NotTo(HaveOccurred())
+			}
+		})
+
+		It("should count new terminals and failed reconciles", func() {
+			reconciler := &TerminalReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+
+			terminal := &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-metrics-terminal", Namespace: "marina-system"},
+				Spec:       marinacorev1.TerminalSpec{Image: "busybox: 1.36.0"},
+			}
+			req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(terminal)}
+
+			err := k8sClient.Create(ctx, terminal)
+			Expect(err).NotTo(HaveOccurred())
+
+			terminals := counterValue("marina_terminals_total")
+
+			_, err = reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(counterValue("marina_terminals_total")).To(Equal(terminals + 1))
+
+			_, err = reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(counterValue("marina_terminals_total")).To(Equal(terminals + 1))
+
+			err = k8sClient.Get(ctx, req.NamespacedName, terminal)
+			Expect(err).NotTo(HaveOccurred())
+
+			terminal.Spec.Capabilities = []corev1.Capability{"SYS_ADMIN"}
+			err = k8sClient.Update(ctx, terminal)
+			Expect(err).NotTo(HaveOccurred())
+
+			reconcileErrors := counterValue("marina_terminal_reconcile_errors_total")
+
+			_, err = reconciler.Reconcile(ctx, req)
+			Expect(err).To(HaveOccurred())
+			Expect(counterValue("marina_terminal_reconcile_errors_total")).To(Equal(reconcileErrors + 1))
+
+			err = k8sClient.Delete(ctx, terminal)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should count new users", func() {
+			reconciler := &UserReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+
+			user := &marinacorev1.User{
+				ObjectMeta: metav1.ObjectMeta{Name: "user-metrics", Namespace: "marina-system"},
+				Spec: marinacorev1.UserSpec{
+					Name:     "fili",
+					Password: []byte("kili"),
+				},
+			}
+			req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(user)}
+
+			err := k8sClient.Create(ctx, user)
+			Expect(err).NotTo(HaveOccurred())
+
+			users := counterValue("marina_users_total")
+
+			_, err = reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(counterValue("marina_users_total")).To(Equal(users + 1))
+
+			err = k8sClient.Delete(ctx, user)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+})
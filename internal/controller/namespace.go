@@ -0,0 +1,86 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	marinacorev1 "github.com/joshmeranda/marina-operator/api/v1"
+)
+
+// childNamespaceForTerminal returns the namespace the children of the given terminal are created in.
+func childNamespaceForTerminal(terminal *marinacorev1.Terminal) string {
+	if terminal.Status.ChildNamespace != "" {
+		return terminal.Status.ChildNamespace
+	}
+
+	return terminal.Namespace
+}
+
+// childNameForTerminal returns the name of the children of the given terminal. Children created outside of the
+// terminal's namespace include the terminal's namespace, so terminals with the same name do not collide.
+func childNameForTerminal(terminal *marinacorev1.Terminal) string {
+	if childNamespaceForTerminal(terminal) != terminal.Namespace {
+		return "marina-terminal-" + terminal.Namespace + "-" + terminal.Name
+	}
+
+	return "marina-terminal-" + terminal.Name
+}
+
+// placeChildren records the namespace the children of a new terminal are created in, so they are still found if the
+// operator's child namespace changes later. Terminals which already have children are left where they are. The
+// namespace is recorded in the terminal's status and persisted straight away, since anyone able to edit the terminal
+// could otherwise choose where its children are created.
+func (r *TerminalReconciler) placeChildren(ctx context.Context, terminal *marinacorev1.Terminal) error {
+	if r.ChildNamespace == "" || r.ChildNamespace == terminal.Namespace || terminal.Status.ChildNamespace != "" {
+		return nil
+	}
+
+	// every terminal has a service, so the service finalizer is only missing before the terminal is first reconciled.
+	// Terminals placed by older operators only recorded their namespace in an annotation, which is trusted as long as
+	// it names the operator's own child namespace.
+	legacy := terminal.Annotations[marinacorev1.TerminalChildNamespaceAnnotation] == r.ChildNamespace
+	if controllerutil.ContainsFinalizer(terminal, TerminalServiceFinalizer) && !legacy {
+		return nil
+	}
+
+	original := terminal.DeepCopy()
+	terminal.Status.ChildNamespace = r.ChildNamespace
+
+	if err := r.Status().Patch(ctx, terminal, client.MergeFrom(original)); err != nil {
+		return fmt.Errorf("could not record terminal child namespace: %w", err)
+	}
+
+	return nil
+}
+
+// setTerminalOwner makes the terminal the controller of the given child. Owner references may not cross namespaces, so
+// children in another namespace are only cleaned up by the terminal's finalizers.
+func (r *TerminalReconciler) setTerminalOwner(terminal *marinacorev1.Terminal, child client.Object) error {
+	if child.GetNamespace() != terminal.Namespace {
+		return nil
+	}
+
+	return controllerutil.SetControllerReference(terminal, child, r.Scheme)
+}
+
+// terminalForChild maps a child created outside of its terminal's namespace back to its terminal. Children in their
+// terminal's namespace are mapped by their owner reference instead.
+func (r *TerminalReconciler) terminalForChild(_ context.Context, obj client.Object) []reconcile.Request {
+	labels := obj.GetLabels()
+
+	name := labels[TerminalNameLabel]
+	if name == "" {
+		name = labels[TerminalPreviewLabel]
+	}
+
+	namespace := labels[TerminalNamespaceLabel]
+	if namespace == "" || name == "" {
+		return nil
+	}
+
+	return []reconcile.Request{{NamespacedName: client.ObjectKey{Name: name, Namespace: namespace}}}
+}
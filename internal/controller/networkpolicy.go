@@ -0,0 +1,90 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	marinacorev1 "github.com/joshmeranda/marina-operator/api/v1"
+)
+
+func networkPolicyForTerminal(terminal *marinacorev1.Terminal) *networkingv1.NetworkPolicy {
+	return &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      childNameForTerminal(terminal),
+			Namespace: childNamespaceForTerminal(terminal),
+		},
+	}
+}
+
+// networkPolicySpecForTerminal returns the spec of the network policy isolating the terminal's pods, which only allows
+// ssh connections in and the terminal's egress rules out.
+func networkPolicySpecForTerminal(terminal *marinacorev1.Terminal) networkingv1.NetworkPolicySpec {
+	return networkingv1.NetworkPolicySpec{
+		PodSelector: metav1.LabelSelector{
+			MatchLabels: selectorLabelsForTerminal(terminal),
+		},
+		PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress},
+		Ingress: []networkingv1.NetworkPolicyIngressRule{
+			{
+				Ports: []networkingv1.NetworkPolicyPort{
+					{
+						Protocol: ToPtr(corev1.ProtocolTCP),
+						Port:     ToPtr(intstr.FromInt32(portForTerminal(terminal))),
+					},
+				},
+			},
+		},
+		Egress: terminal.Spec.NetworkPolicy.Egress,
+	}
+}
+
+// reconcileNetworkPolicy manages the network policy of the given terminal, removing it once the terminal no longer
+// has a network policy.
+func (r *TerminalReconciler) reconcileNetworkPolicy(ctx context.Context, terminal *marinacorev1.Terminal) error {
+	logger := log.FromContext(ctx)
+	policy := networkPolicyForTerminal(terminal)
+
+	if terminal.GetDeletionTimestamp() != nil || terminal.Spec.NetworkPolicy == nil {
+		if controllerutil.ContainsFinalizer(terminal, TerminalNetworkFinalizer) {
+			if err := r.Delete(ctx, policy); client.IgnoreNotFound(err) != nil {
+				return fmt.Errorf("could not delete network policy: %w", err)
+			}
+
+			controllerutil.RemoveFinalizer(terminal, TerminalNetworkFinalizer)
+
+			logger.Info("deleted terminal network policy", "terminal", client.ObjectKeyFromObject(terminal))
+		}
+
+		return nil
+	}
+
+	_ = controllerutil.AddFinalizer(terminal, TerminalNetworkFinalizer)
+
+	result, err := controllerutil.CreateOrUpdate(ctx, r.Client, policy, func() error {
+		policy.Labels = labelsForTerminal(terminal)
+		policy.Spec = networkPolicySpecForTerminal(terminal)
+
+		return r.setTerminalOwner(terminal, policy)
+	})
+	if err != nil {
+		return fmt.Errorf("could not reconcile network policy: %w", err)
+	}
+
+	switch result {
+	case controllerutil.OperationResultCreated:
+		logger.Info("created terminal network policy", "terminal", client.ObjectKeyFromObject(terminal))
+		r.recordEvent(terminal, corev1.EventTypeNormal, "Created", "created network policy %s", policy.Name)
+	case controllerutil.OperationResultUpdated:
+		logger.Info("updated terminal network policy", "terminal", client.ObjectKeyFromObject(terminal))
+	}
+
+	return nil
+}
@@ -0,0 +1,13 @@
+package controller
+
+import (
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+)
+
+// controllerOptions returns the options for a reconciler's controller, running a single worker unless more are
+// requested.
+func controllerOptions(maxConcurrentReconciles int) controller.Options {
+	return controller.Options{
+		MaxConcurrentReconciles: max(maxConcurrentReconciles, 1),
+	}
+}
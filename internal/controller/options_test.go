@@ -0,0 +1,17 @@
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Controller Options", func() {
+	It("should default to a single worker", func() {
+		Expect(controllerOptions(0).MaxConcurrentReconciles).To(Equal(1))
+		Expect(controllerOptions(-1).MaxConcurrentReconciles).To(Equal(1))
+	})
+
+	It("should use the requested number of workers", func() {
+		Expect(controllerOptions(8).MaxConcurrentReconciles).To(Equal(8))
+	})
+})
@@ -0,0 +1,130 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"maps"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	marinacorev1 "github.com/joshmeranda/marina-operator/api/v1"
+)
+
+// previewSelectorLabelsForTerminal returns the labels used to select the preview pods of the given terminal.
+func previewSelectorLabelsForTerminal(terminal *marinacorev1.Terminal) map[string]string {
+	labels := maps.Clone(CommonLabels)
+	labels[TerminalPreviewLabel] = terminal.Name
+
+	if childNamespaceForTerminal(terminal) != terminal.Namespace {
+		labels[TerminalNamespaceLabel] = terminal.Namespace
+	}
+
+	return labels
+}
+
+func previewDeploymentForTerminal(terminal *marinacorev1.Terminal) *appsv1.Deployment {
+	preview := terminal.DeepCopy()
+	preview.Spec.Image = terminal.Spec.PreviewImage
+
+	deployment := deploymentForTerminal(preview)
+	deployment.Name += "-preview"
+	delete(deployment.Labels, TerminalNameLabel)
+	maps.Copy(deployment.Labels, previewSelectorLabelsForTerminal(terminal))
+	deployment.Spec.Replicas = ToPtr(min(replicasForTerminal(terminal), 1))
+	deployment.Spec.Selector.MatchLabels = previewSelectorLabelsForTerminal(terminal)
+
+	delete(deployment.Spec.Template.Labels, TerminalNameLabel)
+	maps.Copy(deployment.Spec.Template.Labels, previewSelectorLabelsForTerminal(terminal))
+
+	return deployment
+}
+
+func previewServiceForTerminal(terminal *marinacorev1.Terminal, defaultAnnotations map[string]string) *corev1.Service {
+	service := serviceForTerminal(terminal, defaultAnnotations)
+	service.Name += "-preview"
+	service.Spec.Selector = previewSelectorLabelsForTerminal(terminal)
+
+	return service
+}
+
+// reconcilePreview manages the preview deployment and service of the given terminal, removing them once the terminal
+// no longer has a preview image.
+func (r *TerminalReconciler) reconcilePreview(ctx context.Context, terminal *marinacorev1.Terminal, setupComplete bool) error {
+	logger := log.FromContext(ctx)
+	deployment := previewDeploymentForTerminal(terminal)
+	service := previewServiceForTerminal(terminal, r.DefaultServiceAnnotations)
+
+	if terminal.GetDeletionTimestamp() != nil || terminal.Spec.PreviewImage == "" || terminal.Spec.RunToCompletion {
+		if controllerutil.ContainsFinalizer(terminal, TerminalPreviewFinalizer) {
+			if err := r.Delete(ctx, deployment); client.IgnoreNotFound(err) != nil {
+				return fmt.Errorf("could not delete preview deployment: %w", err)
+			}
+
+			if err := r.Delete(ctx, service); client.IgnoreNotFound(err) != nil {
+				return fmt.Errorf("could not delete preview service: %w", err)
+			}
+
+			controllerutil.RemoveFinalizer(terminal, TerminalPreviewFinalizer)
+
+			logger.Info("deleted terminal preview", "terminal", client.ObjectKeyFromObject(terminal))
+		}
+
+		return nil
+	}
+
+	if !setupComplete {
+		return nil
+	}
+
+	_ = controllerutil.AddFinalizer(terminal, TerminalPreviewFinalizer)
+
+	if err := r.applyPodTemplate(ctx, terminal, &deployment.Spec.Template); err != nil {
+		return err
+	}
+
+	// a pod template replaces the whole pod, so the preview image is applied again on top of it
+	deployment.Spec.Template.Spec.Containers[0].Image = terminal.Spec.PreviewImage
+
+	if err := r.preparePodSpec(ctx, terminal, &deployment.Spec.Template.Spec); err != nil {
+		return err
+	}
+
+	if err := setTemplateHash(deployment); err != nil {
+		return err
+	}
+
+	if err := r.setTerminalOwner(terminal, deployment); err != nil {
+		return fmt.Errorf("could not set preview deployment owner: %w", err)
+	}
+
+	if err := r.Create(ctx, deployment); err != nil {
+		if !errors.IsAlreadyExists(err) {
+			return err
+		}
+
+		if err := r.updateDeployment(ctx, deployment); err != nil {
+			return err
+		}
+	} else {
+		logger.Info("created terminal preview deployment", "terminal", client.ObjectKeyFromObject(terminal))
+		r.recordEvent(terminal, corev1.EventTypeNormal, "Created", "created preview deployment %s", deployment.Name)
+	}
+
+	if err := r.setTerminalOwner(terminal, service); err != nil {
+		return fmt.Errorf("could not set preview service owner: %w", err)
+	}
+
+	if err := r.Create(ctx, service); err != nil {
+		return client.IgnoreAlreadyExists(err)
+	}
+
+	logger.Info("created terminal preview service", "terminal", client.ObjectKeyFromObject(terminal))
+	r.recordEvent(terminal, corev1.EventTypeNormal, "Created", "created preview service %s", service.Name)
+
+	return nil
+}
@@ -0,0 +1,36 @@
+package controller
+
+import (
+	"sync"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// retryTracker counts how many times in a row each object has failed to reconcile. Counts are kept in memory, so they
+// start over when the operator restarts.
+type retryTracker struct {
+	mu       sync.Mutex
+	failures map[types.NamespacedName]int
+}
+
+// failed records a failed reconcile of the given object, returning how many times in a row it has now failed.
+func (t *retryTracker) failed(key types.NamespacedName) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.failures == nil {
+		t.failures = make(map[types.NamespacedName]int)
+	}
+
+	t.failures[key]++
+
+	return t.failures[key]
+}
+
+// reset forgets the failures of the given object, for example once it reconciles successfully.
+func (t *retryTracker) reset(key types.NamespacedName) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.failures, key)
+}
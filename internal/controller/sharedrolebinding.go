@@ -0,0 +1,190 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	marinacorev1 "github.com/joshmeranda/marina-operator/api/v1"
+)
+
+const (
+	// SharedRoleBindingLabel marks the role bindings shared by every user with the same role.
+	SharedRoleBindingLabel = "marina.io/shared-role-binding"
+)
+
+func subjectForUser(user *marinacorev1.User) rbacv1.Subject {
+	return rbacv1.Subject{
+		Kind:      rbacv1.ServiceAccountKind,
+		Name:      user.Name,
+		Namespace: serviceAccountNamespace(user),
+	}
+}
+
+// sharedRoleBindingForRole returns the role binding shared by every user granted the given role in the same namespace.
+func sharedRoleBindingForRole(user *marinacorev1.User, role string) *rbacv1.RoleBinding {
+	return &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "marina-" + role,
+			Namespace: roleBindingNamespace(user, role),
+			Labels: map[string]string{
+				SharedRoleBindingLabel: "true",
+			},
+		},
+		RoleRef: rbacv1.RoleRef{
+			Kind:     "Role",
+			Name:     role,
+			APIGroup: "rbac.authorization.k8s.io",
+		},
+	}
+}
+
+// sharedRoleBindingsForUser returns the shared role bindings which have the given user as a subject.
+func (r *UserReconciler) sharedRoleBindingsForUser(ctx context.Context, user *marinacorev1.User) ([]rbacv1.RoleBinding, error) {
+	bindings := &rbacv1.RoleBindingList{}
+	if err := r.List(ctx, bindings, client.MatchingLabels{SharedRoleBindingLabel: "true"}); err != nil {
+		return nil, fmt.Errorf("could not list shared role bindings: %w", err)
+	}
+
+	subject := subjectForUser(user)
+
+	return slices.DeleteFunc(bindings.Items, func(binding rbacv1.RoleBinding) bool {
+		return !slices.Contains(binding.Subjects, subject)
+	}), nil
+}
+
+// addSharedRoleBindingSubject adds the user to the shared role binding for the given role, creating the binding if
+// the user is the first to be granted the role. Existing role bindings with the same name are only joined if they are
+// labelled as shared and bind the same role.
+func (r *UserReconciler) addSharedRoleBindingSubject(ctx context.Context, user *marinacorev1.User, role string) error {
+	logger := log.FromContext(ctx)
+	binding := sharedRoleBindingForRole(user, role)
+	subject := subjectForUser(user)
+
+	if err := r.ensureRoleExists(ctx, user, role, binding.Namespace); err != nil {
+		return err
+	}
+
+	if err := r.Get(ctx, client.ObjectKeyFromObject(binding), binding); errors.IsNotFound(err) {
+		binding.Subjects = []rbacv1.Subject{subject}
+
+		if err := r.Create(ctx, binding); err != nil {
+			return fmt.Errorf("could not create shared role binding: %w", err)
+		}
+
+		logger.Info("created shared role binding", "rolebinding", client.ObjectKeyFromObject(binding))
+		r.recordEvent(user, corev1.EventTypeNormal, "Created", "created role binding %s", binding.Name)
+
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("could not fetch shared role binding: %w", err)
+	}
+
+	// a role binding which only shares the name is never adopted, since the user would be granted whatever it binds
+	if binding.Labels[SharedRoleBindingLabel] != "true" || binding.RoleRef != sharedRoleBindingForRole(user, role).RoleRef {
+		r.recordEvent(user, corev1.EventTypeWarning, "RoleBindingConflict", "role binding %s already exists and is not shared for role '%s'", binding.Name, role)
+		return fmt.Errorf("role binding '%s' already exists and is not shared for role '%s'", binding.Name, role)
+	}
+
+	if slices.Contains(binding.Subjects, subject) {
+		return nil
+	}
+
+	// other users may update the binding at the same time, in which case the conflict is retried on the next reconcile
+	binding.Subjects = append(binding.Subjects, subject)
+	if err := r.Update(ctx, binding); err != nil {
+		return fmt.Errorf("could not add user to shared role binding: %w", err)
+	}
+
+	logger.Info("added user to shared role binding", "rolebinding", client.ObjectKeyFromObject(binding))
+	r.recordEvent(user, corev1.EventTypeNormal, "Updated", "added to role binding %s", binding.Name)
+
+	return nil
+}
+
+// removeSharedRoleBindingSubject removes the user from the given shared role binding, deleting the binding once it
+// has no subjects left.
+func (r *UserReconciler) removeSharedRoleBindingSubject(ctx context.Context, user *marinacorev1.User, binding *rbacv1.RoleBinding) error {
+	logger := log.FromContext(ctx)
+	subject := subjectForUser(user)
+
+	if !slices.Contains(binding.Subjects, subject) {
+		return nil
+	}
+
+	binding.Subjects = slices.DeleteFunc(binding.Subjects, func(s rbacv1.Subject) bool {
+		return s == subject
+	})
+
+	if len(binding.Subjects) == 0 {
+		// the precondition keeps users added since the binding was fetched from being dropped with it
+		if err := r.Delete(ctx, binding, client.Preconditions{ResourceVersion: &binding.ResourceVersion}); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("could not delete shared role binding: %w", err)
+		}
+
+		logger.Info("deleted shared role binding", "rolebinding", client.ObjectKeyFromObject(binding))
+		r.recordEvent(user, corev1.EventTypeNormal, "Deleted", "deleted role binding %s", binding.Name)
+
+		return nil
+	}
+
+	if err := r.Update(ctx, binding); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("could not remove user from shared role binding: %w", err)
+	}
+
+	logger.Info("removed user from shared role binding", "rolebinding", client.ObjectKeyFromObject(binding))
+	r.recordEvent(user, corev1.EventTypeNormal, "Deleted", "removed from role binding %s", binding.Name)
+
+	return nil
+}
+
+// reconcileSharedRoleBinding adds the user to the shared role binding for the given role, or removes them from it if
+// the user is being deleted or is suspended.
+func (r *UserReconciler) reconcileSharedRoleBinding(ctx context.Context, user *marinacorev1.User, role string, suspended bool) error {
+	if user.GetDeletionTimestamp() != nil || suspended {
+		binding := sharedRoleBindingForRole(user, role)
+		if err := r.Get(ctx, client.ObjectKeyFromObject(binding), binding); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+
+		return r.removeSharedRoleBindingSubject(ctx, user, binding)
+	}
+
+	return r.addSharedRoleBindingSubject(ctx, user, role)
+}
+
+// pruneSharedRoleBindings removes the user from the shared role bindings of roles which are no longer in the user's
+// spec. Users are removed from every shared role binding when shared role bindings are disabled.
+func (r *UserReconciler) pruneSharedRoleBindings(ctx context.Context, user *marinacorev1.User) error {
+	desired := make(map[types.NamespacedName]bool, len(user.Spec.Roles))
+	if r.SharedRoleBindings && user.GetDeletionTimestamp() == nil {
+		for _, role := range user.Spec.Roles {
+			desired[client.ObjectKeyFromObject(sharedRoleBindingForRole(user, role))] = true
+		}
+	}
+
+	bindings, err := r.sharedRoleBindingsForUser(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	for _, binding := range bindings {
+		if desired[client.ObjectKeyFromObject(&binding)] {
+			continue
+		}
+
+		if err := r.removeSharedRoleBindingSubject(ctx, user, &binding); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,138 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	marinacorev1 "github.com/joshmeranda/marina-operator/api/v1"
+)
+
+// DefaultShutdownWebhookTimeout is how long a failing shutdown webhook is retried before giving up.
+const DefaultShutdownWebhookTimeout = 5 * time.Minute
+
+// shutdownWebhookClient does not follow redirects, so an allowed host cannot send the request on to another host.
+var shutdownWebhookClient = &http.Client{
+	Timeout: 10 * time.Second,
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// TerminalShutdownPayload is the body sent to a terminal's shutdown webhook.
+type TerminalShutdownPayload struct {
+	Name      string                      `json:"name"`
+	Namespace string                      `json:"namespace"`
+	Status    marinacorev1.TerminalStatus `json:"status"`
+}
+
+// shutdownWebhookURL returns the terminal's own shutdown webhook if it is allowed, or else the operator's.
+func (r *TerminalReconciler) shutdownWebhookURL(terminal *marinacorev1.Terminal) string {
+	if terminal.Spec.ShutdownWebhookURL != "" && r.shutdownWebhookAllowed(terminal.Spec.ShutdownWebhookURL) {
+		return terminal.Spec.ShutdownWebhookURL
+	}
+
+	return r.ShutdownWebhookURL
+}
+
+// shutdownWebhookAllowed reports whether a terminal may send its shutdown webhook to the given url. The request is sent
+// by the operator, so terminals are limited to http(s) urls on the allowed hosts rather than reaching anything the
+// operator can.
+func (r *TerminalReconciler) shutdownWebhookAllowed(rawURL string) bool {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return false
+	}
+
+	return slices.Contains(r.ShutdownWebhookHosts, parsed.Hostname())
+}
+
+// validateShutdownWebhook rejects terminals whose own shutdown webhook is not allowed.
+func (r *TerminalReconciler) validateShutdownWebhook(terminal *marinacorev1.Terminal) error {
+	if terminal.Spec.ShutdownWebhookURL == "" || r.shutdownWebhookAllowed(terminal.Spec.ShutdownWebhookURL) {
+		return nil
+	}
+
+	return fmt.Errorf("shutdown webhook '%s' is not an http(s) url on an allowed host", terminal.Spec.ShutdownWebhookURL)
+}
+
+func notifyShutdownWebhook(ctx context.Context, webhookURL string, terminal *marinacorev1.Terminal) error {
+	body, err := json.Marshal(TerminalShutdownPayload{
+		Name:      terminal.Name,
+		Namespace: terminal.Namespace,
+		Status:    terminal.Status,
+	})
+	if err != nil {
+		return fmt.Errorf("could not encode shutdown payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("could not create shutdown request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := shutdownWebhookClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("could not send shutdown request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("shutdown webhook responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// reconcileShutdownWebhook notifies the terminal's shutdown webhook when the terminal is deleted. Failed notifications
+// are retried with the controller's backoff until the shutdown webhook timeout passes, after which the terminal is
+// finalized without notifying the webhook.
+func (r *TerminalReconciler) reconcileShutdownWebhook(ctx context.Context, terminal *marinacorev1.Terminal) error {
+	logger := log.FromContext(ctx)
+	webhookURL := r.shutdownWebhookURL(terminal)
+
+	if terminal.GetDeletionTimestamp() == nil {
+		if webhookURL != "" {
+			_ = controllerutil.AddFinalizer(terminal, TerminalShutdownFinalizer)
+		}
+
+		return nil
+	}
+
+	if !controllerutil.ContainsFinalizer(terminal, TerminalShutdownFinalizer) {
+		return nil
+	}
+
+	if webhookURL != "" && r.DryRun {
+		logger.Info("dry run, not notifying terminal shutdown webhook", "terminal", client.ObjectKeyFromObject(terminal), "url", webhookURL)
+	} else if webhookURL != "" {
+		if err := notifyShutdownWebhook(ctx, webhookURL, terminal); err != nil {
+			timeout := r.ShutdownWebhookTimeout
+			if timeout == 0 {
+				timeout = DefaultShutdownWebhookTimeout
+			}
+
+			if r.now().Before(terminal.GetDeletionTimestamp().Add(timeout)) {
+				return err
+			}
+
+			logger.Error(err, "giving up on terminal shutdown webhook", "terminal", client.ObjectKeyFromObject(terminal))
+		} else {
+			logger.Info("notified terminal shutdown webhook", "terminal", client.ObjectKeyFromObject(terminal))
+		}
+	}
+
+	controllerutil.RemoveFinalizer(terminal, TerminalShutdownFinalizer)
+
+	return nil
+}
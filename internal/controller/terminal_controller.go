@@ -2,17 +2,35 @@ package controller
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"maps"
+	"slices"
+	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	nodev1 "k8s.io/api/node/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/clock"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/yaml"
 
 	marinacorev1 "github.com/joshmeranda/marina-operator/api/v1"
 )
@@ -20,6 +38,44 @@ import (
 const (
 	TerminalDeploymentFinalizer = "marina.io.deployment/finalizer"
 	TerminalServiceFinalizer    = "marina.io.service/finalizer"
+	TerminalJobFinalizer        = "marina.io.job/finalizer"
+	TerminalSetupJobFinalizer   = "marina.io.setupjob/finalizer"
+	TerminalHomeFinalizer       = "marina.io.home/finalizer"
+	TerminalShutdownFinalizer   = "marina.io.shutdown/finalizer"
+	TerminalPreviewFinalizer    = "marina.io.preview/finalizer"
+	TerminalIngressFinalizer    = "marina.io.ingress/finalizer"
+	TerminalNetworkFinalizer    = "marina.io.networkpolicy/finalizer"
+	TerminalConnectionFinalizer = "marina.io.connection/finalizer"
+
+	// DefaultImagePullTimeout is how long terminal pods may fail to pull their image before their terminal's image
+	// pull back off policy is applied.
+	DefaultImagePullTimeout = 5 * time.Minute
+
+	// DefaultHomeMountPath is where persistent home volumes are mounted when no path is given.
+	DefaultHomeMountPath = "/root"
+
+	// TerminalCredentialsMountPath is where the credentials secret of the terminal's user is mounted, for the terminal's
+	// sshd to authenticate the user.
+	TerminalCredentialsMountPath = "/etc/marina/credentials"
+
+	// TerminalContainerName is the name of the terminal's shell container.
+	TerminalContainerName = "exec-shell"
+
+	// TerminalNameLabel identifies the terminal a pod belongs to.
+	TerminalNameLabel = "marina.io/terminal"
+
+	// TerminalPreviewLabel identifies the terminal a preview pod belongs to. Preview pods do not have the
+	// TerminalNameLabel so they are not selected by the terminal's own deployment or service.
+	TerminalPreviewLabel = "marina.io/preview-terminal"
+
+	// TerminalNamespaceLabel identifies the namespace of the terminal a child belongs to. It is only set on children
+	// created outside of their terminal's namespace.
+	TerminalNamespaceLabel = "marina.io/terminal-namespace"
+
+	// TerminalTemplateHashAnnotation records a hash of the pod template the operator last applied to a terminal's
+	// deployment, so changes to the terminal are detected without comparing against the defaults filled in by the api
+	// server.
+	TerminalTemplateHashAnnotation = "marina.io/template-hash"
 )
 
 var (
@@ -32,55 +88,469 @@ func ToPtr[T any](t T) *T {
 	return &t
 }
 
+// hashOf returns a short hash of the json encoding of the given object.
+func hashOf(obj any) (string, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return "", fmt.Errorf("could not encode object for hashing: %w", err)
+	}
+
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:8]), nil
+}
+
+// setTemplateHash records the hash of the deployment's pod template in its annotations.
+func setTemplateHash(deployment *appsv1.Deployment) error {
+	hash, err := hashOf(deployment.Spec.Template)
+	if err != nil {
+		return err
+	}
+
+	mergeStringMap(&deployment.Annotations, map[string]string{TerminalTemplateHashAnnotation: hash})
+
+	return nil
+}
+
+// mergeStringMap adds the entries of src to dst, returning true if any were added or changed.
+func mergeStringMap(dst *map[string]string, src map[string]string) bool {
+	changed := false
+
+	for k, v := range src {
+		if current, found := (*dst)[k]; found && current == v {
+			continue
+		}
+
+		if *dst == nil {
+			*dst = make(map[string]string)
+		}
+
+		(*dst)[k] = v
+		changed = true
+	}
+
+	return changed
+}
+
+// selectorLabelsForTerminal returns the labels used to select the pods of the given terminal. Anything selecting
+// terminal pods (deployments, services, etc) should use these labels.
+func selectorLabelsForTerminal(terminal *marinacorev1.Terminal) map[string]string {
+	labels := maps.Clone(CommonLabels)
+	labels[TerminalNameLabel] = terminal.Name
+
+	if childNamespaceForTerminal(terminal) != terminal.Namespace {
+		labels[TerminalNamespaceLabel] = terminal.Namespace
+	}
+
+	return labels
+}
+
+// labelsForTerminal returns the labels for the children of the given terminal, which inherit the terminal's own labels
+// (ex for cost allocation). The terminal's labels may not override the selector labels.
+func labelsForTerminal(terminal *marinacorev1.Terminal) map[string]string {
+	labels := maps.Clone(terminal.Labels)
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+
+	maps.Copy(labels, selectorLabelsForTerminal(terminal))
+
+	return labels
+}
+
+// annotationsForTerminal returns the annotations for the children of the given terminal, which inherit the terminal's
+// own annotations except for those only meaningful on the terminal itself. They are not added to pod templates, since
+// annotating the terminal would otherwise restart its pods.
+func annotationsForTerminal(terminal *marinacorev1.Terminal) map[string]string {
+	annotations := maps.Clone(terminal.Annotations)
+
+	delete(annotations, marinacorev1.TerminalResetAnnotation)
+	delete(annotations, marinacorev1.TerminalChildNamespaceAnnotation)
+	delete(annotations, corev1.LastAppliedConfigAnnotation)
+
+	if len(annotations) == 0 {
+		return nil
+	}
+
+	return annotations
+}
+
+// podLabelsForTerminal returns the labels for the pods of the given terminal. The terminal's pod labels take precedence
+// over the terminal's own labels, but neither may override the selector labels.
+func podLabelsForTerminal(terminal *marinacorev1.Terminal) map[string]string {
+	labels := maps.Clone(terminal.Labels)
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+
+	maps.Copy(labels, terminal.Spec.PodLabels)
+	maps.Copy(labels, selectorLabelsForTerminal(terminal))
+
+	return labels
+}
+
+func portForTerminal(terminal *marinacorev1.Terminal) int32 {
+	if terminal.Spec.Port != 0 {
+		return terminal.Spec.Port
+	}
+
+	return 22
+}
+
+func serviceTypeForTerminal(terminal *marinacorev1.Terminal) corev1.ServiceType {
+	if terminal.Spec.ServiceType != "" {
+		return terminal.Spec.ServiceType
+	}
+
+	return corev1.ServiceTypeClusterIP
+}
+
+// commandForTerminal returns the command of the terminal container, which keeps the container running until it is
+// stopped. When the terminal runs sshd it is started first, failing the container if it cannot start.
+func commandForTerminal(terminal *marinacorev1.Terminal) []string {
+	script := "trap : TERM INT; sleep infinity & wait"
+	if terminal.Spec.RunSshd {
+		// sshd detaches once it is listening, so the container still needs to sleep afterwards
+		script = fmt.Sprintf("ssh-keygen -A; mkdir -p /run/sshd; /usr/sbin/sshd -p %d; %s", portForTerminal(terminal), script)
+	}
+
+	return []string{"/bin/sh", "-ec", script}
+}
+
+func containerForTerminal(terminal *marinacorev1.Terminal) corev1.Container {
+	container := corev1.Container{
+		Name:            TerminalContainerName,
+		Image:           terminal.Spec.Image,
+		ImagePullPolicy: terminal.Spec.ImagePullPolicy,
+		Command:         commandForTerminal(terminal),
+		Ports: []corev1.ContainerPort{
+			{
+				Name:          "ssh",
+				ContainerPort: portForTerminal(terminal),
+				Protocol:      corev1.ProtocolTCP,
+			},
+		},
+		Env:          terminal.Spec.Env,
+		Resources:    terminal.Spec.Resources,
+		WorkingDir:   terminal.Spec.WorkingDir,
+		VolumeMounts: slices.Clone(terminal.Spec.VolumeMounts),
+	}
+
+	if home := terminal.Spec.PersistentHome; home != nil {
+		mountPath := home.MountPath
+		if mountPath == "" {
+			mountPath = terminal.Spec.WorkingDir
+		}
+		if mountPath == "" {
+			mountPath = DefaultHomeMountPath
+		}
+
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+			Name:      "home",
+			MountPath: mountPath,
+		})
+	}
+
+	container.SecurityContext = securityContextForTerminal(terminal)
+
+	return container
+}
+
+// securityContextForTerminal returns the security context of the terminal container, or nil if the terminal does not
+// need one.
+func securityContextForTerminal(terminal *marinacorev1.Terminal) *corev1.SecurityContext {
+	securityContext := terminal.Spec.SecurityContext.DeepCopy()
+
+	if len(terminal.Spec.Capabilities) > 0 || terminal.Spec.RunAsNonRoot {
+		if securityContext == nil {
+			securityContext = &corev1.SecurityContext{}
+		}
+
+		if securityContext.Capabilities == nil {
+			securityContext.Capabilities = &corev1.Capabilities{}
+		}
+
+		securityContext.Capabilities.Add = append(securityContext.Capabilities.Add, terminal.Spec.Capabilities...)
+	}
+
+	if terminal.Spec.RunAsNonRoot {
+		if securityContext.RunAsNonRoot == nil {
+			securityContext.RunAsNonRoot = ToPtr(true)
+		}
+
+		if securityContext.AllowPrivilegeEscalation == nil {
+			securityContext.AllowPrivilegeEscalation = ToPtr(false)
+		}
+
+		if len(securityContext.Capabilities.Drop) == 0 {
+			securityContext.Capabilities.Drop = []corev1.Capability{"ALL"}
+		}
+	}
+
+	return securityContext
+}
+
+// podSecurityContextForTerminal returns the security context of the terminal pod, or nil if the terminal does not need
+// one.
+func podSecurityContextForTerminal(terminal *marinacorev1.Terminal) *corev1.PodSecurityContext {
+	securityContext := terminal.Spec.PodSecurityContext.DeepCopy()
+
+	if terminal.Spec.RunAsNonRoot {
+		if securityContext == nil {
+			securityContext = &corev1.PodSecurityContext{}
+		}
+
+		if securityContext.RunAsNonRoot == nil {
+			securityContext.RunAsNonRoot = ToPtr(true)
+		}
+
+		if securityContext.SeccompProfile == nil {
+			securityContext.SeccompProfile = &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault}
+		}
+	}
+
+	return securityContext
+}
+
+func podSpecForTerminal(terminal *marinacorev1.Terminal) corev1.PodSpec {
+	podSpec := corev1.PodSpec{
+		Containers: []corev1.Container{
+			containerForTerminal(terminal),
+		},
+		RuntimeClassName:              terminal.Spec.RuntimeClassName,
+		ReadinessGates:                terminal.Spec.ReadinessGates,
+		SchedulingGates:               terminal.Spec.SchedulingGates,
+		InitContainers:                terminal.Spec.InitContainers,
+		SecurityContext:               podSecurityContextForTerminal(terminal),
+		ServiceAccountName:            terminal.Spec.ServiceAccountName,
+		TerminationGracePeriodSeconds: terminal.Spec.TerminationGracePeriodSeconds,
+		Volumes:                       slices.Clone(terminal.Spec.Volumes),
+	}
+
+	if terminal.Spec.PersistentHome != nil {
+		podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+			Name: "home",
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+					ClaimName: homeClaimForTerminal(terminal).Name,
+				},
+			},
+		})
+	}
+
+	return podSpec
+}
+
+// homeClaimForTerminal returns the claim for the terminal's persistent home. It is not owned by the terminal so that it
+// may be retained after the terminal is deleted.
+func homeClaimForTerminal(terminal *marinacorev1.Terminal) *corev1.PersistentVolumeClaim {
+	claim := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      childNameForTerminal(terminal) + "-home",
+			Namespace: childNamespaceForTerminal(terminal),
+		},
+	}
+
+	if home := terminal.Spec.PersistentHome; home != nil {
+		claim.Spec = corev1.PersistentVolumeClaimSpec{
+			AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			StorageClassName: home.StorageClassName,
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: home.Size,
+				},
+			},
+		}
+	}
+
+	return claim
+}
+
+func replicasForTerminal(terminal *marinacorev1.Terminal) int32 {
+	if terminal.Spec.Frozen {
+		return 0
+	}
+
+	if terminal.Spec.Replicas != nil {
+		return *terminal.Spec.Replicas
+	}
+
+	return 1
+}
+
+// probeForTerminal returns a probe checking the terminal's ssh port, or nil if nothing is known to listen on it. The
+// port is only probed when the operator starts sshd, or when the terminal opts in for an image which starts its own
+// ssh server, since probing a port nobody listens on would restart the terminal forever.
+func probeForTerminal(terminal *marinacorev1.Terminal) *corev1.Probe {
+	if terminal.Spec.Probe == nil && !terminal.Spec.RunSshd {
+		return nil
+	}
+
+	probe := &corev1.Probe{
+		ProbeHandler: corev1.ProbeHandler{
+			TCPSocket: &corev1.TCPSocketAction{
+				Port: intstr.FromInt32(portForTerminal(terminal)),
+			},
+		},
+	}
+
+	if overrides := terminal.Spec.Probe; overrides != nil {
+		if overrides.Disabled {
+			return nil
+		}
+
+		probe.InitialDelaySeconds = overrides.InitialDelaySeconds
+		probe.PeriodSeconds = overrides.PeriodSeconds
+	}
+
+	return probe
+}
+
 func deploymentForTerminal(terminal *marinacorev1.Terminal) *appsv1.Deployment {
+	podSpec := podSpecForTerminal(terminal)
+	podSpec.Containers[0].ReadinessProbe = probeForTerminal(terminal)
+	podSpec.Containers[0].LivenessProbe = probeForTerminal(terminal)
+
+	// the credentials secret is named after the user and lives in the terminal's namespace, so only pods created there
+	// can mount it. The webhook only admits terminals whose creator owns the user, so other users' credentials cannot
+	// be mounted.
+	if terminal.Spec.User != "" && childNamespaceForTerminal(terminal) == terminal.Namespace {
+		podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+			Name: "credentials",
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName:  terminal.Spec.User,
+					DefaultMode: ToPtr[int32](0440),
+				},
+			},
+		})
+
+		podSpec.Containers[0].VolumeMounts = append(podSpec.Containers[0].VolumeMounts, corev1.VolumeMount{
+			Name:      "credentials",
+			MountPath: TerminalCredentialsMountPath,
+			ReadOnly:  true,
+		})
+	}
+
+	// the terminal container is kept first, since it is the container the rest of the operator manages
+	podSpec.Containers = append(podSpec.Containers, terminal.Spec.Sidecars...)
+
 	return &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "marina-terminal-" + terminal.Name,
-			Namespace: terminal.Namespace,
-			Labels:    CommonLabels,
+			Name:        childNameForTerminal(terminal),
+			Namespace:   childNamespaceForTerminal(terminal),
+			Labels:      labelsForTerminal(terminal),
+			Annotations: annotationsForTerminal(terminal),
 		},
 		Spec: appsv1.DeploymentSpec{
-			Replicas: ToPtr[int32](1),
+			Replicas: ToPtr(replicasForTerminal(terminal)),
 			Selector: &metav1.LabelSelector{
-				MatchLabels: CommonLabels,
+				MatchLabels: selectorLabelsForTerminal(terminal),
 			},
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
-					Labels: CommonLabels,
+					Labels:      podLabelsForTerminal(terminal),
+					Annotations: terminal.Spec.PodAnnotations,
 				},
-				Spec: corev1.PodSpec{
-					Containers: []corev1.Container{
-						{
-							Name:    "exec-shell",
-							Image:   terminal.Spec.Image,
-							Command: []string{"/bin/sh", "-ec", "trap : TERM INT; sleep infinity & wait"},
-						},
-					},
+				Spec: podSpec,
+			},
+		},
+	}
+}
+
+func jobForTerminal(terminal *marinacorev1.Terminal) *batchv1.Job {
+	podSpec := podSpecForTerminal(terminal)
+	podSpec.RestartPolicy = corev1.RestartPolicyNever
+	if terminal.Spec.RestartPolicy != "" {
+		podSpec.RestartPolicy = terminal.Spec.RestartPolicy
+	}
+
+	// let the image entrypoint run to completion rather than sleeping forever
+	podSpec.Containers[0].Command = nil
+
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        childNameForTerminal(terminal),
+			Namespace:   childNamespaceForTerminal(terminal),
+			Labels:      labelsForTerminal(terminal),
+			Annotations: annotationsForTerminal(terminal),
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: ToPtr[int32](0),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      podLabelsForTerminal(terminal),
+					Annotations: terminal.Spec.PodAnnotations,
 				},
+				Spec: podSpec,
 			},
 		},
 	}
 }
 
-func serviceForTerminal(terminal *marinacorev1.Terminal) *corev1.Service {
-	return &corev1.Service{
+// setupJobForTerminal returns the setup job of the given terminal. The setup pod intentionally does not use the terminal
+// selector labels so it is never selected by the terminal service.
+func setupJobForTerminal(terminal *marinacorev1.Terminal) *batchv1.Job {
+	job := &batchv1.Job{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "marina-terminal-" + terminal.Name,
-			Namespace: terminal.Namespace,
+			Name:      childNameForTerminal(terminal) + "-setup",
+			Namespace: childNamespaceForTerminal(terminal),
 		},
+	}
+
+	if terminal.Spec.SetupJob != nil {
+		job.Spec.Template.Spec = corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name:    "setup",
+					Image:   terminal.Spec.SetupJob.Image,
+					Command: terminal.Spec.SetupJob.Command,
+				},
+			},
+			RestartPolicy: corev1.RestartPolicyOnFailure,
+		}
+	}
+
+	return job
+}
+
+// jobFinished returns the type of the condition marking the job as finished, or an empty string if it is still
+// running.
+func jobFinished(job *batchv1.Job) batchv1.JobConditionType {
+	for _, condition := range job.Status.Conditions {
+		if (condition.Type == batchv1.JobComplete || condition.Type == batchv1.JobFailed) && condition.Status == corev1.ConditionTrue {
+			return condition.Type
+		}
+	}
+
+	return ""
+}
+
+func serviceForTerminal(terminal *marinacorev1.Terminal, defaultAnnotations map[string]string) *corev1.Service {
+	meta := metav1.ObjectMeta{
+		Name:      childNameForTerminal(terminal),
+		Namespace: childNamespaceForTerminal(terminal),
+		Labels:    labelsForTerminal(terminal),
+	}
+
+	_ = mergeStringMap(&meta.Annotations, defaultAnnotations)
+	_ = mergeStringMap(&meta.Annotations, annotationsForTerminal(terminal))
+	_ = mergeStringMap(&meta.Annotations, terminal.Spec.ServiceAnnotations)
+
+	return &corev1.Service{
+		ObjectMeta: meta,
 		Spec: corev1.ServiceSpec{
 			Ports: []corev1.ServicePort{
 				{
 					Name:     "ssh",
 					Protocol: corev1.ProtocolTCP,
 					Port:     22,
-					TargetPort: intstr.IntOrString{
-						Type:   intstr.String,
-						StrVal: "ssh",
-					},
+					// the port is targeted by number rather than name so pod templates need not declare it
+					TargetPort: intstr.FromInt32(portForTerminal(terminal)),
 				},
 			},
-			Selector: CommonLabels,
+			Selector: selectorLabelsForTerminal(terminal),
+			Type:     serviceTypeForTerminal(terminal),
 		},
 	}
 }
@@ -89,6 +559,67 @@ func serviceForTerminal(terminal *marinacorev1.Terminal) *corev1.Service {
 type TerminalReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+
+	// MaxConcurrentReconciles is the number of workers reconciling terminals at once, defaulting to 1.
+	MaxConcurrentReconciles int
+
+	// ChildNamespace is the namespace the children of new terminals are created in. When empty children are created
+	// alongside their terminal. Pods created in another namespace cannot use the service account of their terminal's
+	// user, and any secrets or config maps they reference must exist in the child namespace.
+	ChildNamespace string
+
+	// DefaultServiceAnnotations are added to every terminal service.
+	DefaultServiceAnnotations map[string]string
+
+	// AllowedCapabilities are the only capabilities terminals may add to their container.
+	AllowedCapabilities []corev1.Capability
+
+	// AllowedServiceAccounts are the only service accounts terminals may name to run as. The service account of a
+	// terminal's user is always allowed.
+	AllowedServiceAccounts []string
+
+	// DefaultResources are used for terminals which do not specify any resources.
+	DefaultResources corev1.ResourceRequirements
+
+	// DefaultImagePullPolicy is used for terminals which do not specify an image pull policy. When empty the
+	// Kubernetes default is used.
+	DefaultImagePullPolicy corev1.PullPolicy
+
+	// MaintenanceWindow pauses all terminal mutations while active.
+	MaintenanceWindow *MaintenanceWindow
+
+	// ImageAllowlist is the ConfigMap listing the images terminals may use. When empty every image is allowed.
+	ImageAllowlist types.NamespacedName
+
+	// Clock is used to determine how long terminal pods have been failing, defaulting to the real clock when nil.
+	Clock clock.PassiveClock
+
+	// Recorder emits events for terminals, for example when their children are created. It is set from the manager in
+	// SetupWithManager if nil.
+	Recorder record.EventRecorder
+
+	// UseUserServiceAccount runs the pods of terminals belonging to a user as the user's service account, unless the
+	// terminal specifies its own service account.
+	UseUserServiceAccount bool
+
+	// AvailabilityRequeueInterval is how often terminals are reconciled while their deployment is not yet available.
+	// When 0 they are only reconciled again once their deployment changes.
+	AvailabilityRequeueInterval time.Duration
+
+	// ShutdownWebhookURL is notified when terminals which do not specify their own shutdown webhook are deleted.
+	ShutdownWebhookURL string
+
+	// ShutdownWebhookHosts are the hosts terminals may send their own shutdown webhooks to. Terminals may not specify
+	// their own shutdown webhook when empty.
+	ShutdownWebhookHosts []string
+
+	// ShutdownWebhookTimeout is how long a failing shutdown webhook is retried before the terminal is deleted anyway,
+	// defaulting to DefaultShutdownWebhookTimeout when 0.
+	ShutdownWebhookTimeout time.Duration
+
+	// DryRun skips the side effects which cannot be sent to the api server as a dry run, such as notifying shutdown
+	// webhooks. It does not stop writes to the api server, which are left to the client.
+	DryRun bool
 }
 
 // +kubebuilder:rbac:groups=core.marina.io,resources=terminals,verbs=get;list;watch;create;update;patch;delete
@@ -96,98 +627,1223 @@ type TerminalReconciler struct {
 // +kubebuilder:rbac:groups=core.marina.io,resources=terminals/finalizers,verbs=update
 // +kubebuilder:rbac:groups=*,resources=services,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=*,resources=deployments,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=networking.k8s.io,resources=networkpolicies,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=*,resources=configmaps,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core.marina.io,resources=users,verbs=get;list;watch
+// +kubebuilder:rbac:groups=*,resources=pods,verbs=get;list;watch
+// +kubebuilder:rbac:groups=*,resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups=*,resources=persistentvolumeclaims,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=node.k8s.io,resources=runtimeclasses,verbs=get;list;watch
 
-func (r *TerminalReconciler) reconcileDeployment(ctx context.Context, terminal *marinacorev1.Terminal) error {
-	logger := log.FromContext(ctx)
-	deployment := deploymentForTerminal(terminal)
+func (r *TerminalReconciler) now() time.Time {
+	if r.Clock == nil {
+		return time.Now()
+	}
 
-	if terminal.GetDeletionTimestamp() != nil {
-		if controllerutil.ContainsFinalizer(terminal, TerminalDeploymentFinalizer) {
-			if err := r.Client.Delete(ctx, deployment); err != nil {
-				return fmt.Errorf("could not delete deployment: %w", err)
-			}
+	return r.Clock.Now()
+}
 
-			controllerutil.RemoveFinalizer(terminal, TerminalDeploymentFinalizer)
+// recordEvent records an event for the terminal, doing nothing when the reconciler has no recorder.
+func (r *TerminalReconciler) recordEvent(terminal *marinacorev1.Terminal, eventType string, reason string, messageFmt string, args ...any) {
+	if r.Recorder == nil {
+		return
+	}
 
-			logger.Info("deleted terminal deployment", "terminal", client.ObjectKeyFromObject(terminal))
-		}
+	r.Recorder.Eventf(terminal, eventType, reason, messageFmt, args...)
+}
 
+// validateSecurityContext ensures a container with the given security context can only add the capabilities allowed by
+// the operator, and cannot run privileged or gain privileges.
+func (r *TerminalReconciler) validateSecurityContext(securityContext *corev1.SecurityContext) error {
+	if securityContext == nil {
 		return nil
 	}
 
-	_ = controllerutil.AddFinalizer(terminal, TerminalDeploymentFinalizer)
+	if securityContext.Privileged != nil && *securityContext.Privileged {
+		return fmt.Errorf("privileged containers are not allowed")
+	}
 
-	if err := r.Create(ctx, deployment); err != nil {
-		return client.IgnoreAlreadyExists(err)
+	if securityContext.AllowPrivilegeEscalation != nil && *securityContext.AllowPrivilegeEscalation {
+		return fmt.Errorf("privilege escalation is not allowed")
 	}
 
-	logger.Info("created terminal deployment", "terminal", client.ObjectKeyFromObject(terminal))
+	if securityContext.Capabilities == nil {
+		return nil
+	}
+
+	for _, capability := range securityContext.Capabilities.Add {
+		if !slices.Contains(r.AllowedCapabilities, capability) {
+			return fmt.Errorf("capability '%s' is not allowed", capability)
+		}
+	}
 
 	return nil
 }
 
-func (r *TerminalReconciler) reconcileService(ctx context.Context, terminal *marinacorev1.Terminal) error {
-	logger := log.FromContext(ctx)
-	service := serviceForTerminal(terminal)
+// validateServiceAccount ensures terminals only run as service accounts allowed by the operator, since they could
+// otherwise borrow the permissions of any service account in their namespace.
+func (r *TerminalReconciler) validateServiceAccount(name string) error {
+	if name == "" || slices.Contains(r.AllowedServiceAccounts, name) {
+		return nil
+	}
 
-	if terminal.GetDeletionTimestamp() != nil {
-		if controllerutil.ContainsFinalizer(terminal, TerminalServiceFinalizer) {
-			if err := r.Client.Delete(ctx, service); err != nil {
-				return fmt.Errorf("could not delete service: %w", err)
-			}
+	return fmt.Errorf("service account '%s' is not allowed", name)
+}
 
-			controllerutil.RemoveFinalizer(terminal, TerminalServiceFinalizer)
+// validateCapabilities checks the terminal container's security context, including any capabilities added through
+// the terminal's security context rather than its capabilities.
+func (r *TerminalReconciler) validateCapabilities(terminal *marinacorev1.Terminal) error {
+	return r.validateSecurityContext(securityContextForTerminal(terminal))
+}
 
-			logger.Info("deleted terminal service", "terminal", client.ObjectKeyFromObject(terminal))
+// validateAdditionalContainers checks the security context of each of the terminal's additional containers, since
+// they share the terminal pod and its volumes.
+func (r *TerminalReconciler) validateAdditionalContainers(terminal *marinacorev1.Terminal) error {
+	for _, container := range slices.Concat(terminal.Spec.InitContainers, terminal.Spec.Sidecars) {
+		if err := r.validateSecurityContext(container.SecurityContext); err != nil {
+			return fmt.Errorf("container '%s': %w", container.Name, err)
 		}
-
-		return nil
 	}
 
-	_ = controllerutil.AddFinalizer(terminal, TerminalServiceFinalizer)
+	return nil
+}
 
-	if err := r.Create(ctx, service); err != nil {
-		return client.IgnoreAlreadyExists(err)
-	}
+// validateContainerNames ensures the terminal's additional containers do not collide with each other or with the
+// terminal container.
+func validateContainerNames(terminal *marinacorev1.Terminal) error {
+	names := map[string]bool{TerminalContainerName: true}
+
+	for _, container := range slices.Concat(terminal.Spec.InitContainers, terminal.Spec.Sidecars) {
+		if names[container.Name] {
+			return fmt.Errorf("container name '%s' is used more than once", container.Name)
+		}
 
-	logger.Info("created terminal service", "terminal", client.ObjectKeyFromObject(terminal))
+		names[container.Name] = true
+	}
 
 	return nil
 }
 
-func (r *TerminalReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
-	logger := log.FromContext(ctx)
-	logger.Info("reconciling terminal", "temrinal", req.NamespacedName)
+// validateVolumes ensures the terminal's volumes only use allowed sources and do not collide with each other or with the
+// volumes managed by the operator, and that each of its volume mounts mounts one of them. Sources are checked here as
+// well as in the webhook, since the webhook may not be deployed.
+func validateVolumes(terminal *marinacorev1.Terminal) error {
+	names := map[string]bool{}
 
-	terminal := &marinacorev1.Terminal{}
-	if err := r.Get(ctx, req.NamespacedName, terminal); err != nil {
-		logger.Error(err, "error fetching terminal", "terminal", req.NamespacedName)
-		return ctrl.Result{}, client.IgnoreNotFound(err)
-	}
+	for _, volume := range terminal.Spec.Volumes {
+		if !marinacorev1.VolumeSourceAllowed(volume.VolumeSource) {
+			return fmt.Errorf("volume '%s' uses a source which is not allowed", volume.Name)
+		}
 
-	if err := r.reconcileDeployment(ctx, terminal); err != nil {
-		logger.Error(err, "error reconciling terminal deployment", "terminal", req.NamespacedName)
-		return ctrl.Result{}, err
-	}
+		if volume.Name == "home" || volume.Name == "credentials" {
+			return fmt.Errorf("volume name '%s' is reserved", volume.Name)
+		}
 
-	if err := r.reconcileService(ctx, terminal); err != nil {
-		logger.Error(err, "error reconciling terminal service", "terminal", req.NamespacedName)
-		return ctrl.Result{}, err
-	}
+		if names[volume.Name] {
+			return fmt.Errorf("volume name '%s' is used more than once", volume.Name)
+		}
 
-	if err := r.Update(ctx, terminal); err != nil {
-		logger.Error(err, "error updating terminal", req.NamespacedName)
-		return ctrl.Result{}, err
+		names[volume.Name] = true
 	}
 
-	return ctrl.Result{}, nil
+	for _, mount := range terminal.Spec.VolumeMounts {
+		if !names[mount.Name] {
+			return fmt.Errorf("volume mount '%s' does not match any volume", mount.Name)
+		}
+	}
+
+	return nil
+}
+
+func validateResources(terminal *marinacorev1.Terminal) error {
+	for name, limit := range terminal.Spec.Resources.Limits {
+		if request, found := terminal.Spec.Resources.Requests[name]; found && limit.Cmp(request) < 0 {
+			return fmt.Errorf("%s limit '%s' is less than request '%s'", name, limit.String(), request.String())
+		}
+	}
+
+	return nil
+}
+
+func (r *TerminalReconciler) validateTerminal(terminal *marinacorev1.Terminal) error {
+	if err := r.validateCapabilities(terminal); err != nil {
+		return err
+	}
+
+	if err := r.validateAdditionalContainers(terminal); err != nil {
+		return err
+	}
+
+	if err := r.validateServiceAccount(terminal.Spec.ServiceAccountName); err != nil {
+		return err
+	}
+
+	if err := validateResources(terminal); err != nil {
+		return err
+	}
+
+	if err := validateContainerNames(terminal); err != nil {
+		return err
+	}
+
+	if err := validateVolumes(terminal); err != nil {
+		return err
+	}
+
+	if err := r.validateShutdownWebhook(terminal); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// applyPodTemplate replaces the given pod template with the one referenced by the terminal, keeping the labels and
+// annotations managed by the operator.
+func (r *TerminalReconciler) applyPodTemplate(ctx context.Context, terminal *marinacorev1.Terminal, template *corev1.PodTemplateSpec) error {
+	ref := terminal.Spec.PodTemplateRef
+	if ref == nil {
+		return nil
+	}
+
+	configMap := &corev1.ConfigMap{}
+	if err := r.Get(ctx, client.ObjectKey{Name: ref.Name, Namespace: terminal.Namespace}, configMap); err != nil {
+		return fmt.Errorf("could not fetch pod template config map: %w", err)
+	}
+
+	data, found := configMap.Data[ref.Key]
+	if !found {
+		return fmt.Errorf("pod template config map '%s' has no key '%s'", ref.Name, ref.Key)
+	}
+
+	base := corev1.PodTemplateSpec{}
+	if err := yaml.UnmarshalStrict([]byte(data), &base); err != nil {
+		return fmt.Errorf("could not parse pod template: %w", err)
+	}
+
+	if len(base.Spec.Containers) == 0 {
+		return fmt.Errorf("pod template must have at least one container")
+	}
+
+	if err := r.validatePodTemplate(ctx, &base.Spec); err != nil {
+		return fmt.Errorf("pod template is invalid: %w", err)
+	}
+
+	mergeStringMap(&base.Labels, template.Labels)
+	mergeStringMap(&base.Annotations, template.Annotations)
+
+	*template = base
+
+	return nil
+}
+
+// validatePodTemplate holds the pod spec of a pod template to the same rules as the terminal's own containers and
+// volumes, since the template replaces the pod the operator would otherwise render.
+func (r *TerminalReconciler) validatePodTemplate(ctx context.Context, podSpec *corev1.PodSpec) error {
+	if podSpec.HostNetwork || podSpec.HostPID || podSpec.HostIPC {
+		return fmt.Errorf("host namespaces are not allowed")
+	}
+
+	if err := r.validateServiceAccount(podSpec.ServiceAccountName); err != nil {
+		return err
+	}
+
+	var images []string
+	for _, container := range slices.Concat(podSpec.InitContainers, podSpec.Containers) {
+		if err := r.validateSecurityContext(container.SecurityContext); err != nil {
+			return fmt.Errorf("container '%s': %w", container.Name, err)
+		}
+
+		images = append(images, container.Image)
+	}
+
+	for _, volume := range podSpec.Volumes {
+		if !marinacorev1.VolumeSourceAllowed(volume.VolumeSource) {
+			return fmt.Errorf("volume '%s' uses a source which is not allowed", volume.Name)
+		}
+	}
+
+	if image, err := r.disallowedImage(ctx, images); err != nil {
+		return err
+	} else if image != "" {
+		return fmt.Errorf("image '%s' is not allowed", image)
+	}
+
+	return nil
+}
+
+// terminalsForPodTemplate enqueues the terminals using a pod template from the given config map, so that changes to
+// the template reach their deployments.
+func (r *TerminalReconciler) terminalsForPodTemplate(ctx context.Context, obj client.Object) []reconcile.Request {
+	terminals := &marinacorev1.TerminalList{}
+	if err := r.List(ctx, terminals, client.InNamespace(obj.GetNamespace())); err != nil {
+		log.FromContext(ctx).Error(err, "could not list terminals for pod template change")
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, terminal := range terminals.Items {
+		if ref := terminal.Spec.PodTemplateRef; ref != nil && ref.Name == obj.GetName() {
+			requests = append(requests, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(&terminal)})
+		}
+	}
+
+	return requests
+}
+
+// terminalsForConfigMap enqueues the terminals affected by a change to the given config map, whether it is the image
+// allowlist or holds pod templates. The allowlist enqueues every terminal, which covers those using it as a template.
+func (r *TerminalReconciler) terminalsForConfigMap(ctx context.Context, obj client.Object) []reconcile.Request {
+	if client.ObjectKeyFromObject(obj) == r.ImageAllowlist {
+		return r.terminalsForAllowlist(ctx, obj)
+	}
+
+	return r.terminalsForPodTemplate(ctx, obj)
+}
+
+// preparePodSpec fills in the parts of a terminal pod spec which depend on operator configuration or other cluster
+// resources.
+func (r *TerminalReconciler) preparePodSpec(ctx context.Context, terminal *marinacorev1.Terminal, podSpec *corev1.PodSpec) error {
+	container := &podSpec.Containers[0]
+	if len(container.Resources.Requests) == 0 && len(container.Resources.Limits) == 0 {
+		container.Resources = *r.DefaultResources.DeepCopy()
+	}
+
+	if container.ImagePullPolicy == "" {
+		container.ImagePullPolicy = r.DefaultImagePullPolicy
+	}
+
+	if err := r.applyRuntimeClassOverhead(ctx, podSpec); err != nil {
+		return err
+	}
+
+	if err := r.applyUserIdentity(ctx, terminal, podSpec); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// applyUserIdentity runs the pod as the UID assigned to the terminal's user, so that users are isolated from each other
+// on shared volumes.
+func (r *TerminalReconciler) applyUserIdentity(ctx context.Context, terminal *marinacorev1.Terminal, podSpec *corev1.PodSpec) error {
+	if terminal.Spec.User == "" {
+		return nil
+	}
+
+	user := &marinacorev1.User{}
+	if err := r.Get(ctx, client.ObjectKey{Name: terminal.Spec.User, Namespace: terminal.Namespace}, user); errors.IsNotFound(err) {
+		return fmt.Errorf("user '%s' does not exist", terminal.Spec.User)
+	} else if err != nil {
+		return fmt.Errorf("could not fetch terminal user: %w", err)
+	}
+
+	if user.Status.UID == 0 {
+		return fmt.Errorf("user '%s' has not been assigned a uid", user.Name)
+	}
+
+	if podSpec.SecurityContext == nil {
+		podSpec.SecurityContext = &corev1.PodSecurityContext{}
+	}
+
+	podSpec.SecurityContext.RunAsUser = ToPtr(user.Status.UID)
+	podSpec.SecurityContext.RunAsGroup = ToPtr(user.Status.UID)
+	podSpec.SecurityContext.FSGroup = ToPtr(user.Status.UID)
+
+	// pods may only use service accounts from their own namespace
+	serviceAccount := serviceAccountForUser(user)
+	if r.UseUserServiceAccount && podSpec.ServiceAccountName == "" && childNamespaceForTerminal(terminal) == serviceAccount.Namespace {
+		podSpec.ServiceAccountName = serviceAccount.Name
+	}
+
+	return nil
+}
+
+// applyRuntimeClassOverhead sets the pod overhead defined by the pod's RuntimeClass, so that scheduling and quota
+// account for it even when the RuntimeClass admission controller is not enabled.
+func (r *TerminalReconciler) applyRuntimeClassOverhead(ctx context.Context, podSpec *corev1.PodSpec) error {
+	if podSpec.RuntimeClassName == nil {
+		return nil
+	}
+
+	runtimeClass := &nodev1.RuntimeClass{}
+	if err := r.Get(ctx, client.ObjectKey{Name: *podSpec.RuntimeClassName}, runtimeClass); err != nil {
+		return fmt.Errorf("could not fetch runtime class: %w", err)
+	}
+
+	if runtimeClass.Overhead != nil {
+		podSpec.Overhead = runtimeClass.Overhead.PodFixed
+	}
+
+	return nil
+}
+
+func (r *TerminalReconciler) reconcileDeployment(ctx context.Context, terminal *marinacorev1.Terminal) error {
+	logger := log.FromContext(ctx)
+	deployment := deploymentForTerminal(terminal)
+
+	// the deployment is also removed once the terminal runs to completion instead, so switching modes does not leave it
+	// running or its finalizer stranded
+	if terminal.GetDeletionTimestamp() != nil || terminal.Spec.RunToCompletion {
+		if controllerutil.ContainsFinalizer(terminal, TerminalDeploymentFinalizer) {
+			if err := r.Client.Delete(ctx, deployment); client.IgnoreNotFound(err) != nil {
+				return fmt.Errorf("could not delete deployment: %w", err)
+			}
+
+			controllerutil.RemoveFinalizer(terminal, TerminalDeploymentFinalizer)
+
+			logger.Info("deleted terminal deployment", "terminal", client.ObjectKeyFromObject(terminal))
+		}
+
+		return nil
+	}
+
+	_ = controllerutil.AddFinalizer(terminal, TerminalDeploymentFinalizer)
+
+	if err := r.applyPodTemplate(ctx, terminal, &deployment.Spec.Template); err != nil {
+		return err
+	}
+
+	if err := r.preparePodSpec(ctx, terminal, &deployment.Spec.Template.Spec); err != nil {
+		return err
+	}
+
+	if err := setTemplateHash(deployment); err != nil {
+		return err
+	}
+
+	if err := r.setTerminalOwner(terminal, deployment); err != nil {
+		return fmt.Errorf("could not set deployment owner: %w", err)
+	}
+
+	if err := r.Create(ctx, deployment); err != nil {
+		if !errors.IsAlreadyExists(err) {
+			return err
+		}
+
+		return r.updateDeployment(ctx, deployment)
+	}
+
+	logger.Info("created terminal deployment", "terminal", client.ObjectKeyFromObject(terminal))
+	r.recordEvent(terminal, corev1.EventTypeNormal, "Created", "created deployment %s", deployment.Name)
+
+	return nil
+}
+
+func (r *TerminalReconciler) recreateDeployment(ctx context.Context, existing *appsv1.Deployment, desired *appsv1.Deployment) error {
+	logger := log.FromContext(ctx)
+
+	// background propagation lets the old pods terminate gracefully while the new deployment is created
+	if err := r.Delete(ctx, existing, client.PropagationPolicy(metav1.DeletePropagationBackground)); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("could not delete deployment with outdated selector: %w", err)
+	}
+
+	if err := r.Create(ctx, desired); err != nil {
+		return fmt.Errorf("could not recreate deployment: %w", err)
+	}
+
+	logger.Info("recreated terminal deployment with new selector", "deployment", client.ObjectKeyFromObject(desired))
+
+	return nil
+}
+
+func (r *TerminalReconciler) updateDeployment(ctx context.Context, desired *appsv1.Deployment) error {
+	logger := log.FromContext(ctx)
+
+	existing := &appsv1.Deployment{}
+	if err := r.Get(ctx, client.ObjectKeyFromObject(desired), existing); err != nil {
+		return fmt.Errorf("could not fetch deployment: %w", err)
+	}
+
+	// the selector is immutable, so if it has changed (ex between operator versions) we need to replace the deployment
+	if !equality.Semantic.DeepEqual(existing.Spec.Selector, desired.Spec.Selector) {
+		return r.recreateDeployment(ctx, existing, desired)
+	}
+
+	changed := false
+
+	// the pod template is compared by the hash recorded when it was last applied, since the api server fills in defaults
+	// which the desired template lacks. Deployments without a recorded hash (ex created by an older operator) are always
+	// given the desired template.
+	if existing.Annotations[TerminalTemplateHashAnnotation] != desired.Annotations[TerminalTemplateHashAnnotation] {
+		template := *desired.Spec.Template.DeepCopy()
+
+		// other controllers (ex kubectl rollout restart) may annotate the pod template, so their annotations are kept
+		for key, value := range existing.Spec.Template.Annotations {
+			if _, found := template.Annotations[key]; !found {
+				mergeStringMap(&template.Annotations, map[string]string{key: value})
+			}
+		}
+
+		existing.Spec.Template = template
+		changed = true
+	}
+
+	// other controllers (ex vault injectors) may add their own annotations so we only ensure ours are present
+	changed = mergeStringMap(&existing.Labels, desired.Labels) || changed
+	changed = mergeStringMap(&existing.Annotations, desired.Annotations) || changed
+	changed = mergeStringMap(&existing.Spec.Template.Annotations, desired.Spec.Template.Annotations) || changed
+	changed = mergeStringMap(&existing.Spec.Template.Labels, desired.Spec.Template.Labels) || changed
+
+	if existing.Spec.Replicas == nil || *existing.Spec.Replicas != *desired.Spec.Replicas {
+		existing.Spec.Replicas = desired.Spec.Replicas
+		changed = true
+	}
+
+	if !changed {
+		return nil
+	}
+
+	if err := r.Update(ctx, existing); err != nil {
+		return fmt.Errorf("could not update deployment: %w", err)
+	}
+
+	logger.Info("updated terminal deployment", "deployment", client.ObjectKeyFromObject(existing))
+
+	return nil
+}
+
+func (r *TerminalReconciler) reconcileJob(ctx context.Context, terminal *marinacorev1.Terminal) error {
+	logger := log.FromContext(ctx)
+	job := jobForTerminal(terminal)
+
+	// the job is also removed once the terminal no longer runs to completion
+	if terminal.GetDeletionTimestamp() != nil || !terminal.Spec.RunToCompletion {
+		if controllerutil.ContainsFinalizer(terminal, TerminalJobFinalizer) {
+			// jobs orphan their pods by default, so we need to explicitly ask for them to be cleaned up
+			if err := r.Client.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground)); client.IgnoreNotFound(err) != nil {
+				return fmt.Errorf("could not delete job: %w", err)
+			}
+
+			controllerutil.RemoveFinalizer(terminal, TerminalJobFinalizer)
+
+			logger.Info("deleted terminal job", "terminal", client.ObjectKeyFromObject(terminal))
+		}
+
+		return nil
+	}
+
+	_ = controllerutil.AddFinalizer(terminal, TerminalJobFinalizer)
+
+	if err := r.applyPodTemplate(ctx, terminal, &job.Spec.Template); err != nil {
+		return err
+	}
+
+	if err := r.preparePodSpec(ctx, terminal, &job.Spec.Template.Spec); err != nil {
+		return err
+	}
+
+	if err := r.setTerminalOwner(terminal, job); err != nil {
+		return fmt.Errorf("could not set job owner: %w", err)
+	}
+
+	if err := r.Create(ctx, job); err != nil {
+		return client.IgnoreAlreadyExists(err)
+	}
+
+	logger.Info("created terminal job", "terminal", client.ObjectKeyFromObject(terminal))
+	r.recordEvent(terminal, corev1.EventTypeNormal, "Created", "created job %s", job.Name)
+
+	return nil
+}
+
+// reconcileSetupJob ensures the terminal's setup job exists, returning true once it has completed or if the terminal
+// has no setup job.
+func (r *TerminalReconciler) reconcileSetupJob(ctx context.Context, terminal *marinacorev1.Terminal) (bool, error) {
+	logger := log.FromContext(ctx)
+	job := setupJobForTerminal(terminal)
+
+	if terminal.GetDeletionTimestamp() != nil {
+		if controllerutil.ContainsFinalizer(terminal, TerminalSetupJobFinalizer) {
+			if err := r.Client.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground)); client.IgnoreNotFound(err) != nil {
+				return false, fmt.Errorf("could not delete setup job: %w", err)
+			}
+
+			controllerutil.RemoveFinalizer(terminal, TerminalSetupJobFinalizer)
+
+			logger.Info("deleted terminal setup job", "terminal", client.ObjectKeyFromObject(terminal))
+		}
+
+		return true, nil
+	}
+
+	if terminal.Spec.SetupJob == nil {
+		return true, nil
+	}
+
+	_ = controllerutil.AddFinalizer(terminal, TerminalSetupJobFinalizer)
+
+	if err := r.setTerminalOwner(terminal, job); err != nil {
+		return false, fmt.Errorf("could not set setup job owner: %w", err)
+	}
+
+	if err := r.Create(ctx, job); err == nil {
+		logger.Info("created terminal setup job", "terminal", client.ObjectKeyFromObject(terminal))
+		r.recordEvent(terminal, corev1.EventTypeNormal, "Created", "created setup job %s", job.Name)
+		return false, nil
+	} else if !errors.IsAlreadyExists(err) {
+		return false, fmt.Errorf("could not create setup job: %w", err)
+	}
+
+	if err := r.Get(ctx, client.ObjectKeyFromObject(job), job); err != nil {
+		return false, fmt.Errorf("could not fetch setup job: %w", err)
+	}
+
+	return jobFinished(job) == batchv1.JobComplete, nil
+}
+
+func (r *TerminalReconciler) reconcileHome(ctx context.Context, terminal *marinacorev1.Terminal) error {
+	logger := log.FromContext(ctx)
+	claim := homeClaimForTerminal(terminal)
+
+	if terminal.GetDeletionTimestamp() != nil {
+		if controllerutil.ContainsFinalizer(terminal, TerminalHomeFinalizer) {
+			if terminal.Spec.PersistentHome == nil || !terminal.Spec.PersistentHome.RetainVolume {
+				if err := r.Client.Delete(ctx, claim); client.IgnoreNotFound(err) != nil {
+					return fmt.Errorf("could not delete home volume claim: %w", err)
+				}
+
+				logger.Info("deleted terminal home volume claim", "terminal", client.ObjectKeyFromObject(terminal))
+			}
+
+			controllerutil.RemoveFinalizer(terminal, TerminalHomeFinalizer)
+		}
+
+		return nil
+	}
+
+	if terminal.Spec.PersistentHome == nil {
+		return nil
+	}
+
+	_ = controllerutil.AddFinalizer(terminal, TerminalHomeFinalizer)
+
+	if err := r.Create(ctx, claim); err != nil {
+		return client.IgnoreAlreadyExists(err)
+	}
+
+	logger.Info("created terminal home volume claim", "terminal", client.ObjectKeyFromObject(terminal))
+	r.recordEvent(terminal, corev1.EventTypeNormal, "Created", "created home volume claim %s", claim.Name)
+
+	return nil
+}
+
+func (r *TerminalReconciler) reconcileService(ctx context.Context, terminal *marinacorev1.Terminal) error {
+	logger := log.FromContext(ctx)
+	service := serviceForTerminal(terminal, r.DefaultServiceAnnotations)
+
+	if terminal.GetDeletionTimestamp() != nil {
+		if controllerutil.ContainsFinalizer(terminal, TerminalServiceFinalizer) {
+			if err := r.Client.Delete(ctx, service); client.IgnoreNotFound(err) != nil {
+				return fmt.Errorf("could not delete service: %w", err)
+			}
+
+			controllerutil.RemoveFinalizer(terminal, TerminalServiceFinalizer)
+
+			logger.Info("deleted terminal service", "terminal", client.ObjectKeyFromObject(terminal))
+		}
+
+		return nil
+	}
+
+	// every terminal has a service, so the first time its finalizer is added is when the terminal is first reconciled
+	if controllerutil.AddFinalizer(terminal, TerminalServiceFinalizer) {
+		terminalsTotal.Inc()
+	}
+
+	existing := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: service.Name, Namespace: service.Namespace}}
+	result, err := controllerutil.CreateOrUpdate(ctx, r.Client, existing, func() error {
+		updateService(existing, service)
+
+		return r.setTerminalOwner(terminal, existing)
+	})
+	if err != nil {
+		return fmt.Errorf("could not reconcile service: %w", err)
+	}
+
+	switch result {
+	case controllerutil.OperationResultCreated:
+		logger.Info("created terminal service", "terminal", client.ObjectKeyFromObject(terminal))
+		r.recordEvent(terminal, corev1.EventTypeNormal, "Created", "created service %s", service.Name)
+	case controllerutil.OperationResultUpdated:
+		logger.Info("updated terminal service", "terminal", client.ObjectKeyFromObject(terminal))
+	}
+
+	return nil
+}
+
+// updateService brings the existing service in line with the desired service. Fields assigned by the cluster (ex
+// ClusterIP) are left untouched, and other controllers may add their own labels and annotations, so we only ensure
+// ours are present.
+func updateService(existing *corev1.Service, desired *corev1.Service) {
+	_ = mergeStringMap(&existing.Labels, desired.Labels)
+	_ = mergeStringMap(&existing.Annotations, desired.Annotations)
+
+	ports := slices.Clone(desired.Spec.Ports)
+	if desired.Spec.Type != corev1.ServiceTypeClusterIP {
+		// keep allocated node ports so clients are not disconnected by unrelated changes
+		for i := range ports {
+			j := slices.IndexFunc(existing.Spec.Ports, func(p corev1.ServicePort) bool {
+				return p.Name == ports[i].Name
+			})
+
+			if j >= 0 {
+				ports[i].NodePort = existing.Spec.Ports[j].NodePort
+			}
+		}
+	}
+
+	existing.Spec.Ports = ports
+	existing.Spec.Selector = desired.Spec.Selector
+	existing.Spec.Type = desired.Spec.Type
+}
+
+// resetTerminal deletes the children of the terminal so they are recreated from scratch.
+func (r *TerminalReconciler) resetTerminal(ctx context.Context, terminal *marinacorev1.Terminal) error {
+	logger := log.FromContext(ctx)
+
+	children := []client.Object{
+		deploymentForTerminal(terminal),
+		jobForTerminal(terminal),
+		serviceForTerminal(terminal, r.DefaultServiceAnnotations),
+	}
+
+	for _, child := range children {
+		if err := r.Delete(ctx, child, client.PropagationPolicy(metav1.DeletePropagationBackground)); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("could not delete terminal child: %w", err)
+		}
+	}
+
+	delete(terminal.Annotations, marinacorev1.TerminalResetAnnotation)
+
+	logger.Info("reset terminal", "terminal", client.ObjectKeyFromObject(terminal))
+
+	return nil
+}
+
+// imagePullTimedOut reports whether any terminal pod has been failing to pull its image for longer than allowed by the
+// terminal's image pull back off policy. If not it also returns how long until the earliest failing pod times out, or
+// 0 if no pods are failing.
+func (r *TerminalReconciler) imagePullTimedOut(ctx context.Context, terminal *marinacorev1.Terminal) (bool, time.Duration, error) {
+	policy := terminal.Spec.ImagePullBackOffPolicy
+	if policy == nil {
+		return false, 0, nil
+	}
+
+	timeout := DefaultImagePullTimeout
+	if policy.Timeout != nil {
+		timeout = policy.Timeout.Duration
+	}
+
+	pods := &corev1.PodList{}
+	if err := r.List(ctx, pods, client.InNamespace(childNamespaceForTerminal(terminal)), client.MatchingLabels(selectorLabelsForTerminal(terminal))); err != nil {
+		return false, 0, fmt.Errorf("could not list terminal pods: %w", err)
+	}
+
+	var remaining time.Duration
+
+	for _, pod := range pods.Items {
+		failing := slices.ContainsFunc(pod.Status.ContainerStatuses, func(status corev1.ContainerStatus) bool {
+			return status.State.Waiting != nil && (status.State.Waiting.Reason == "ImagePullBackOff" || status.State.Waiting.Reason == "ErrImagePull")
+		})
+
+		if !failing {
+			continue
+		}
+
+		left := pod.CreationTimestamp.Add(timeout).Sub(r.now())
+		if left <= 0 {
+			return true, 0, nil
+		}
+
+		if remaining == 0 || left < remaining {
+			remaining = left
+		}
+	}
+
+	return false, remaining, nil
+}
+
+// deploymentAvailable reports whether all of the replicas of the terminal's deployment are available.
+func (r *TerminalReconciler) deploymentAvailable(ctx context.Context, terminal *marinacorev1.Terminal) (bool, error) {
+	deployment := deploymentForTerminal(terminal)
+	if err := r.Get(ctx, client.ObjectKeyFromObject(deployment), deployment); err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+
+		return false, fmt.Errorf("could not fetch deployment: %w", err)
+	}
+
+	return deployment.Status.AvailableReplicas >= replicasForTerminal(terminal), nil
+}
+
+// idleTimedOut reports whether the terminal has had no active sessions for longer than its idle timeout, and otherwise
+// how long until it would.
+func (r *TerminalReconciler) idleTimedOut(terminal *marinacorev1.Terminal) (bool, time.Duration) {
+	if terminal.Spec.IdleTimeout == nil {
+		return false, 0
+	}
+
+	lastActivity := terminal.CreationTimestamp.Time
+	if terminal.Status.LastActivityTime != nil {
+		lastActivity = terminal.Status.LastActivityTime.Time
+	}
+
+	remaining := lastActivity.Add(terminal.Spec.IdleTimeout.Duration).Sub(r.now())
+	if remaining <= 0 {
+		return true, 0
+	}
+
+	return false, remaining
+}
+
+// ttlExpired reports whether the terminal has outlived its TTL, and otherwise how long until it does.
+func (r *TerminalReconciler) ttlExpired(terminal *marinacorev1.Terminal) (bool, time.Duration) {
+	if terminal.Spec.TTL == nil {
+		return false, 0
+	}
+
+	remaining := terminal.CreationTimestamp.Add(terminal.Spec.TTL.Duration).Sub(r.now())
+	if remaining <= 0 {
+		return true, 0
+	}
+
+	return false, remaining
+}
+
+func (r *TerminalReconciler) reconcileStatus(ctx context.Context, terminal *marinacorev1.Terminal) error {
+	original := terminal.DeepCopy()
+
+	if terminalPaused(terminal) {
+		meta.SetStatusCondition(&terminal.Status.Conditions, metav1.Condition{
+			Type:               marinacorev1.TerminalConditionPaused,
+			Status:             metav1.ConditionTrue,
+			Reason:             "Paused",
+			Message:            fmt.Sprintf("reconciliation is paused by the %s annotation", marinacorev1.TerminalPausedAnnotation),
+			ObservedGeneration: terminal.Generation,
+		})
+	} else {
+		meta.RemoveStatusCondition(&terminal.Status.Conditions, marinacorev1.TerminalConditionPaused)
+	}
+
+	disallowed, err := r.disallowedImage(ctx, terminal.Images())
+	if err != nil {
+		return err
+	}
+
+	pullTimedOut, _, err := r.imagePullTimedOut(ctx, terminal)
+	if err != nil {
+		return err
+	}
+
+	if disallowed != "" {
+		meta.SetStatusCondition(&terminal.Status.Conditions, metav1.Condition{
+			Type:               marinacorev1.TerminalConditionFailed,
+			Status:             metav1.ConditionTrue,
+			Reason:             "ImageNotAllowed",
+			Message:            fmt.Sprintf("image '%s' is not allowed", disallowed),
+			ObservedGeneration: terminal.Generation,
+		})
+	} else if pullTimedOut {
+		meta.SetStatusCondition(&terminal.Status.Conditions, metav1.Condition{
+			Type:               marinacorev1.TerminalConditionFailed,
+			Status:             metav1.ConditionTrue,
+			Reason:             "ImagePullBackOff",
+			Message:            fmt.Sprintf("image '%s' could not be pulled", terminal.Spec.Image),
+			ObservedGeneration: terminal.Generation,
+		})
+	} else {
+		meta.SetStatusCondition(&terminal.Status.Conditions, metav1.Condition{
+			Type:               marinacorev1.TerminalConditionFailed,
+			Status:             metav1.ConditionFalse,
+			Reason:             "Reconciled",
+			ObservedGeneration: terminal.Generation,
+		})
+	}
+
+	if terminal.Spec.SetupJob != nil {
+		job := setupJobForTerminal(terminal)
+		if err := r.Get(ctx, client.ObjectKeyFromObject(job), job); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("could not fetch setup job: %w", err)
+		}
+
+		condition := metav1.Condition{
+			Type:               marinacorev1.TerminalConditionSetupComplete,
+			Status:             metav1.ConditionFalse,
+			Reason:             "SetupRunning",
+			Message:            "waiting for the setup job to complete",
+			ObservedGeneration: terminal.Generation,
+		}
+
+		switch jobFinished(job) {
+		case batchv1.JobComplete:
+			condition.Status = metav1.ConditionTrue
+			condition.Reason = "SetupSucceeded"
+			condition.Message = ""
+		case batchv1.JobFailed:
+			condition.Reason = "SetupFailed"
+			condition.Message = "the setup job failed"
+		}
+
+		meta.SetStatusCondition(&terminal.Status.Conditions, condition)
+	}
+
+	if !terminal.Spec.RunToCompletion {
+		available, err := r.deploymentAvailable(ctx, terminal)
+		if err != nil {
+			return err
+		}
+
+		if available {
+			meta.SetStatusCondition(&terminal.Status.Conditions, metav1.Condition{
+				Type:               marinacorev1.TerminalConditionAvailable,
+				Status:             metav1.ConditionTrue,
+				Reason:             "DeploymentAvailable",
+				ObservedGeneration: terminal.Generation,
+			})
+		} else {
+			meta.SetStatusCondition(&terminal.Status.Conditions, metav1.Condition{
+				Type:               marinacorev1.TerminalConditionAvailable,
+				Status:             metav1.ConditionFalse,
+				Reason:             "DeploymentUnavailable",
+				Message:            "waiting for the terminal pods to become available",
+				ObservedGeneration: terminal.Generation,
+			})
+		}
+	}
+
+	service := serviceForTerminal(terminal, r.DefaultServiceAnnotations)
+	if err := r.Get(ctx, client.ObjectKeyFromObject(service), service); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("could not fetch service: %w", err)
+	} else if err == nil {
+		terminal.Status.ServiceName = service.Name
+		terminal.Status.Endpoint = fmt.Sprintf("%s.%s.svc:%d", service.Name, service.Namespace, service.Spec.Ports[0].Port)
+
+		terminal.Status.NodePort = 0
+		if service.Spec.Type == corev1.ServiceTypeNodePort {
+			terminal.Status.NodePort = service.Spec.Ports[0].NodePort
+		}
+	}
+
+	if len(terminal.Spec.SchedulingGates) > 0 {
+		meta.SetStatusCondition(&terminal.Status.Conditions, metav1.Condition{
+			Type:               marinacorev1.TerminalConditionGated,
+			Status:             metav1.ConditionTrue,
+			Reason:             "SchedulingGated",
+			Message:            "terminal pod is pending until all scheduling gates are removed",
+			ObservedGeneration: terminal.Generation,
+		})
+	} else {
+		meta.SetStatusCondition(&terminal.Status.Conditions, metav1.Condition{
+			Type:               marinacorev1.TerminalConditionGated,
+			Status:             metav1.ConditionFalse,
+			Reason:             "NoSchedulingGates",
+			ObservedGeneration: terminal.Generation,
+		})
+	}
+
+	return r.Status().Patch(ctx, terminal, client.MergeFrom(original))
+}
+
+// terminalPaused reports whether the terminal has been paused with the TerminalPausedAnnotation.
+func terminalPaused(terminal *marinacorev1.Terminal) bool {
+	return terminal.Annotations[marinacorev1.TerminalPausedAnnotation] == "true"
+}
+
+func (r *TerminalReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	defer observeReconcile(ctx, "terminal", time.Now())
+
+	result, err := r.reconcile(ctx, req)
+	if err != nil {
+		terminalReconcileErrors.Inc()
+	}
+
+	return result, err
+}
+
+func (r *TerminalReconciler) reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, err error) {
+	logger := log.FromContext(ctx)
+	logger.Info("reconciling terminal", "temrinal", req.NamespacedName)
+
+	terminal := &marinacorev1.Terminal{}
+	if err := r.Get(ctx, req.NamespacedName, terminal); errors.IsNotFound(err) {
+		logger.Info("terminal no longer exists", "terminal", req.NamespacedName)
+		return ctrl.Result{}, nil
+	} else if err != nil {
+		logger.Error(err, "error fetching terminal", "terminal", req.NamespacedName)
+		return ctrl.Result{}, err
+	}
+
+	// status is still kept up to date during maintenance since it does not touch any other resources
+	if r.MaintenanceWindow.Active(r.now()) {
+		logger.Info("maintenance window is active, skipping terminal", "terminal", req.NamespacedName)
+
+		if terminal.GetDeletionTimestamp() == nil {
+			if err := r.reconcileStatus(ctx, terminal); err != nil {
+				logger.Error(err, "error updating terminal status", "terminal", req.NamespacedName)
+				return ctrl.Result{}, err
+			}
+		}
+
+		return ctrl.Result{RequeueAfter: MaintenanceRequeueInterval}, nil
+	}
+
+	// removing the annotation triggers another reconcile, so paused terminals are not requeued
+	if terminalPaused(terminal) {
+		logger.Info("terminal is paused, skipping terminal", "terminal", req.NamespacedName)
+
+		if terminal.GetDeletionTimestamp() == nil {
+			if err := r.reconcileStatus(ctx, terminal); err != nil {
+				logger.Error(err, "error updating terminal status", "terminal", req.NamespacedName)
+				return ctrl.Result{}, err
+			}
+		}
+
+		return ctrl.Result{}, nil
+	}
+
+	// the child namespace is persisted before the terminal is copied below, since the patch refreshes the terminal
+	if terminal.GetDeletionTimestamp() == nil {
+		if err := r.placeChildren(ctx, terminal); err != nil {
+			logger.Error(err, "error placing terminal children", "terminal", req.NamespacedName)
+			r.recordEvent(terminal, corev1.EventTypeWarning, "ReconcileFailed", "error placing terminal children: %s", err)
+			return ctrl.Result{}, err
+		}
+	}
+
+	// terminals may be owned and updated by other controllers (ex a workspace), so we only patch the fields we manage
+	original := terminal.DeepCopy()
+	result := ctrl.Result{}
+
+	// the finalizers of a deleted terminal are persisted on their own, so those removed for children which were
+	// already cleaned up are kept when cleaning up another child fails
+	defer func() {
+		if finalizerErr := persistFinalizers(ctx, r.Client, "terminal", original, terminal); finalizerErr != nil && err == nil {
+			err = finalizerErr
+		}
+	}()
+
+	if terminal.GetDeletionTimestamp() == nil {
+		if err := r.validateTerminal(terminal); err != nil {
+			logger.Error(err, "terminal is invalid", "terminal", req.NamespacedName)
+			r.recordEvent(terminal, corev1.EventTypeWarning, "InvalidTerminal", "%s", err)
+			return ctrl.Result{}, err
+		}
+
+		if image, err := r.disallowedImage(ctx, terminal.Images()); err != nil {
+			logger.Error(err, "error checking terminal image", "terminal", req.NamespacedName)
+			return ctrl.Result{}, err
+		} else if image != "" {
+			// the children are left as is, since the allowlist may have changed after they were created
+			logger.Info("terminal image is not allowed", "terminal", req.NamespacedName, "image", image)
+			r.recordEvent(terminal, corev1.EventTypeWarning, "ImageNotAllowed", "image '%s' is not allowed", image)
+
+			if err := r.reconcileStatus(ctx, terminal); err != nil {
+				logger.Error(err, "error updating terminal status", "terminal", req.NamespacedName)
+				return ctrl.Result{}, err
+			}
+
+			return ctrl.Result{}, nil
+		}
+
+		timedOut, remaining, err := r.imagePullTimedOut(ctx, terminal)
+		if err != nil {
+			logger.Error(err, "error checking terminal image pulls", "terminal", req.NamespacedName)
+			return ctrl.Result{}, err
+		}
+
+		if timedOut && terminal.Spec.ImagePullBackOffPolicy.Action == marinacorev1.ImagePullBackOffDelete {
+			logger.Info("terminal image could not be pulled, deleting", "terminal", req.NamespacedName)
+
+			if err := r.Delete(ctx, terminal); client.IgnoreNotFound(err) != nil {
+				logger.Error(err, "error deleting terminal", "terminal", req.NamespacedName)
+				return ctrl.Result{}, err
+			}
+
+			return ctrl.Result{}, nil
+		}
+
+		expired, untilExpiry := r.ttlExpired(terminal)
+		if expired {
+			logger.Info("terminal ttl has expired, deleting", "terminal", req.NamespacedName)
+			r.recordEvent(terminal, corev1.EventTypeNormal, "Expired", "terminal ttl of %s has expired", terminal.Spec.TTL.Duration)
+
+			if err := r.Delete(ctx, terminal); client.IgnoreNotFound(err) != nil {
+				logger.Error(err, "error deleting terminal", "terminal", req.NamespacedName)
+				return ctrl.Result{}, err
+			}
+
+			return ctrl.Result{}, nil
+		}
+
+		idle, untilIdle := r.idleTimedOut(terminal)
+		if idle {
+			logger.Info("terminal is idle, deleting", "terminal", req.NamespacedName)
+			r.recordEvent(terminal, corev1.EventTypeNormal, "Idle", "terminal has been idle for %s", terminal.Spec.IdleTimeout.Duration)
+
+			if err := r.Delete(ctx, terminal); client.IgnoreNotFound(err) != nil {
+				logger.Error(err, "error deleting terminal", "terminal", req.NamespacedName)
+				return ctrl.Result{}, err
+			}
+
+			return ctrl.Result{}, nil
+		}
+
+		// pods are not watched, so we check back once the earliest failing pod would time out or the terminal would
+		// become idle or expire
+		result.RequeueAfter = soonest(remaining, untilIdle, untilExpiry)
+
+		if _, found := terminal.Annotations[marinacorev1.TerminalResetAnnotation]; found {
+			if err := r.resetTerminal(ctx, terminal); err != nil {
+				logger.Error(err, "error resetting terminal", "terminal", req.NamespacedName)
+				r.recordEvent(terminal, corev1.EventTypeWarning, "ReconcileFailed", "error resetting terminal: %s", err)
+				return ctrl.Result{}, err
+			}
+		}
+	}
+
+	// the webhook is notified before any children are removed so a failed notification can be retried cleanly
+	if err := r.reconcileShutdownWebhook(ctx, terminal); err != nil {
+		logger.Error(err, "error notifying terminal shutdown webhook", "terminal", req.NamespacedName)
+		r.recordEvent(terminal, corev1.EventTypeWarning, "ReconcileFailed", "error notifying terminal shutdown webhook: %s", err)
+		return ctrl.Result{}, err
+	}
+
+	if err := r.reconcileHome(ctx, terminal); err != nil {
+		logger.Error(err, "error reconciling terminal home", "terminal", req.NamespacedName)
+		r.recordEvent(terminal, corev1.EventTypeWarning, "ReconcileFailed", "error reconciling terminal home: %s", err)
+		return ctrl.Result{}, err
+	}
+
+	setupComplete, err := r.reconcileSetupJob(ctx, terminal)
+	if err != nil {
+		logger.Error(err, "error reconciling terminal setup job", "terminal", req.NamespacedName)
+		r.recordEvent(terminal, corev1.EventTypeWarning, "ReconcileFailed", "error reconciling terminal setup job: %s", err)
+		return ctrl.Result{}, err
+	}
+
+	// the child of the mode the terminal is not using is removed first, since it may be left from before the terminal
+	// switched modes
+	if terminal.Spec.RunToCompletion {
+		if err := r.reconcileDeployment(ctx, terminal); err != nil {
+			logger.Error(err, "error reconciling terminal deployment", "terminal", req.NamespacedName)
+			r.recordEvent(terminal, corev1.EventTypeWarning, "ReconcileFailed", "error reconciling terminal deployment: %s", err)
+			return ctrl.Result{}, err
+		}
+	} else if err := r.reconcileJob(ctx, terminal); err != nil {
+		logger.Error(err, "error reconciling terminal job", "terminal", req.NamespacedName)
+		r.recordEvent(terminal, corev1.EventTypeWarning, "ReconcileFailed", "error reconciling terminal job: %s", err)
+		return ctrl.Result{}, err
+	}
+
+	if !setupComplete {
+		logger.Info("waiting for terminal setup job to complete", "terminal", req.NamespacedName)
+	} else if terminal.Spec.RunToCompletion {
+		if err := r.reconcileJob(ctx, terminal); err != nil {
+			logger.Error(err, "error reconciling terminal job", "terminal", req.NamespacedName)
+			r.recordEvent(terminal, corev1.EventTypeWarning, "ReconcileFailed", "error reconciling terminal job: %s", err)
+			return ctrl.Result{}, err
+		}
+	} else {
+		if err := r.reconcileDeployment(ctx, terminal); err != nil {
+			logger.Error(err, "error reconciling terminal deployment", "terminal", req.NamespacedName)
+			r.recordEvent(terminal, corev1.EventTypeWarning, "ReconcileFailed", "error reconciling terminal deployment: %s", err)
+			return ctrl.Result{}, err
+		}
+
+		if terminal.GetDeletionTimestamp() == nil && r.AvailabilityRequeueInterval > 0 {
+			available, err := r.deploymentAvailable(ctx, terminal)
+			if err != nil {
+				logger.Error(err, "error checking terminal availability", "terminal", req.NamespacedName)
+				return ctrl.Result{}, err
+			}
+
+			if !available {
+				logger.Info("waiting for terminal deployment to become available", "terminal", req.NamespacedName)
+				result.RequeueAfter = soonest(result.RequeueAfter, r.AvailabilityRequeueInterval)
+			}
+		}
+	}
+
+	if err := r.reconcileService(ctx, terminal); err != nil {
+		logger.Error(err, "error reconciling terminal service", "terminal", req.NamespacedName)
+		r.recordEvent(terminal, corev1.EventTypeWarning, "ReconcileFailed", "error reconciling terminal service: %s", err)
+		return ctrl.Result{}, err
+	}
+
+	if err := r.reconcileConnectionConfigMap(ctx, terminal); err != nil {
+		logger.Error(err, "error reconciling terminal connection config map", "terminal", req.NamespacedName)
+		r.recordEvent(terminal, corev1.EventTypeWarning, "ReconcileFailed", "error reconciling terminal connection config map: %s", err)
+		return ctrl.Result{}, err
+	}
+
+	if err := r.reconcilePreview(ctx, terminal, setupComplete); err != nil {
+		logger.Error(err, "error reconciling terminal preview", "terminal", req.NamespacedName)
+		r.recordEvent(terminal, corev1.EventTypeWarning, "ReconcileFailed", "error reconciling terminal preview: %s", err)
+		return ctrl.Result{}, err
+	}
+
+	if err := r.reconcileIngress(ctx, terminal); err != nil {
+		logger.Error(err, "error reconciling terminal ingress", "terminal", req.NamespacedName)
+		r.recordEvent(terminal, corev1.EventTypeWarning, "ReconcileFailed", "error reconciling terminal ingress: %s", err)
+		return ctrl.Result{}, err
+	}
+
+	if err := r.reconcileNetworkPolicy(ctx, terminal); err != nil {
+		logger.Error(err, "error reconciling terminal network policy", "terminal", req.NamespacedName)
+		r.recordEvent(terminal, corev1.EventTypeWarning, "ReconcileFailed", "error reconciling terminal network policy: %s", err)
+		return ctrl.Result{}, err
+	}
+
+	if terminal.GetDeletionTimestamp() != nil {
+		return result, nil
+	}
+
+	// finalizers are patched as a whole list, so the lock keeps those added by others since the terminal was fetched
+	// from being dropped
+	if err := r.Patch(ctx, terminal, client.MergeFromWithOptions(original, client.MergeFromWithOptimisticLock{})); err != nil {
+		logger.Error(err, "error updating terminal", "terminal", req.NamespacedName)
+		return ctrl.Result{}, err
+	}
+
+	if err := r.reconcileStatus(ctx, terminal); err != nil {
+		logger.Error(err, "error updating terminal status", "terminal", req.NamespacedName)
+		return ctrl.Result{}, err
+	}
+
+	return result, nil
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *TerminalReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
+	if r.Recorder == nil {
+		r.Recorder = mgr.GetEventRecorderFor("terminal-controller")
+	}
+
+	children := []client.Object{
+		&corev1.Service{},
+		&appsv1.Deployment{},
+		&batchv1.Job{},
+		&networkingv1.Ingress{},
+		&networkingv1.NetworkPolicy{},
+		&corev1.ConfigMap{},
+	}
+
+	builder := ctrl.NewControllerManagedBy(mgr).
 		For(&marinacorev1.Terminal{}).
-		Owns(&corev1.Service{}).
-		Owns(&appsv1.Deployment{}).
-		Complete(r)
+		Watches(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(r.terminalsForConfigMap)).
+		WithOptions(controllerOptions(r.MaxConcurrentReconciles))
+
+	for _, child := range children {
+		builder = builder.Owns(child)
+
+		// children in another namespace have no owner reference to map them back to their terminal
+		if r.ChildNamespace != "" {
+			builder = builder.Watches(child, handler.EnqueueRequestsFromMapFunc(r.terminalForChild))
+		}
+	}
+
+	return builder.Complete(r)
 }
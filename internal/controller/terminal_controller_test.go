@@ -2,15 +2,32 @@ package controller
 
 import (
 	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	nodev1 "k8s.io/api/node/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/record"
+	clocktesting "k8s.io/utils/clock/testing"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	marinacorev1 "github.com/joshmeranda/marina-operator/api/v1"
 )
@@ -26,6 +43,7 @@ var _ = Describe("Terminal Controller", Ordered, func() {
 
 		reconciler = &TerminalReconciler{
 			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
 		}
 
 		namespace = &corev1.Namespace{
@@ -55,29 +73,53 @@ var _ = Describe("Terminal Controller", Ordered, func() {
 			err := k8sClient.Create(ctx, terminal)
 			Expect(err).ToNot(HaveOccurred())
 
-			req := ctrl.Request{
-				NamespacedName: types.NamespacedName{
-					Name:      terminal.Name,
-					Namespace: terminal.Namespace,
-				},
-			}
+			req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(terminal)}
 			result, err := reconciler.Reconcile(ctx, req)
 			Expect(err).ToNot(HaveOccurred())
 			Expect(result).To(Equal(ctrl.Result{}))
 
-			deployment := appsv1.Deployment{}
+			getTerminalDeployment(ctx, terminal)
+
+			service := corev1.Service{}
 			err = k8sClient.Get(ctx, types.NamespacedName{
 				Name:      "marina-terminal-" + terminal.Name,
 				Namespace: terminal.Namespace,
-			}, &deployment)
+			}, &service)
 			Expect(err).ToNot(HaveOccurred())
+		})
 
-			service := corev1.Service{}
-			err = k8sClient.Get(ctx, types.NamespacedName{
+		It("should declare the ssh port targeted by the service", func() {
+			childKey := types.NamespacedName{
 				Name:      "marina-terminal-" + terminal.Name,
 				Namespace: terminal.Namespace,
-			}, &service)
+			}
+
+			deployment := appsv1.Deployment{}
+			err := k8sClient.Get(ctx, childKey, &deployment)
+			Expect(err).ToNot(HaveOccurred())
+
+			service := corev1.Service{}
+			err = k8sClient.Get(ctx, childKey, &service)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(deployment.Spec.Template.Spec.Containers[0].Ports).To(ContainElement(corev1.ContainerPort{
+				Name:          "ssh",
+				ContainerPort: service.Spec.Ports[0].TargetPort.IntVal,
+				Protocol:      corev1.ProtocolTCP,
+			}))
+			Expect(service.Spec.Ports[0].TargetPort.IntVal).To(Equal(int32(22)))
+		})
+
+		It("should reconcile the existing resources again without error", func() {
+			req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(terminal)}
+
+			result, err := reconciler.Reconcile(ctx, req)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result).To(Equal(ctrl.Result{}))
+
+			result, err = reconciler.Reconcile(ctx, req)
 			Expect(err).ToNot(HaveOccurred())
+			Expect(result).To(Equal(ctrl.Result{}))
 		})
 	})
 
@@ -86,12 +128,7 @@ var _ = Describe("Terminal Controller", Ordered, func() {
 			err := k8sClient.Delete(ctx, terminal)
 			Expect(err).ToNot(HaveOccurred())
 
-			req := ctrl.Request{
-				NamespacedName: types.NamespacedName{
-					Name:      terminal.Name,
-					Namespace: terminal.Namespace,
-				},
-			}
+			req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(terminal)}
 			result, err := reconciler.Reconcile(ctx, req)
 			Expect(err).ToNot(HaveOccurred())
 			Expect(result).To(Equal(ctrl.Result{}))
@@ -111,4 +148,3707 @@ var _ = Describe("Terminal Controller", Ordered, func() {
 			Expect(err).To(HaveOccurred())
 		})
 	})
-})
+
+	When("a run to completion terminal is created", func() {
+		var jobTerminal *marinacorev1.Terminal
+
+		BeforeAll(func() {
+			jobTerminal = &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-job-terminal",
+					Namespace: namespace.Name,
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image:           "busybox: 1.36.0",
+					RunToCompletion: true,
+				},
+			}
+
+			err := k8sClient.Create(ctx, jobTerminal)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should create a job rather than a deployment", func() {
+			req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(jobTerminal)}
+			result, err := reconciler.Reconcile(ctx, req)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result).To(Equal(ctrl.Result{}))
+
+			job := batchv1.Job{}
+			err = k8sClient.Get(ctx, types.NamespacedName{
+				Name:      "marina-terminal-" + jobTerminal.Name,
+				Namespace: jobTerminal.Namespace,
+			}, &job)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(job.Spec.Template.Spec.RestartPolicy).To(Equal(corev1.RestartPolicyNever))
+
+			deployment := appsv1.Deployment{}
+			err = k8sClient.Get(ctx, types.NamespacedName{
+				Name:      "marina-terminal-" + jobTerminal.Name,
+				Namespace: jobTerminal.Namespace,
+			}, &deployment)
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("should delete the job", func() {
+			err := k8sClient.Delete(ctx, jobTerminal)
+			Expect(err).ToNot(HaveOccurred())
+
+			req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(jobTerminal)}
+			result, err := reconciler.Reconcile(ctx, req)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result).To(Equal(ctrl.Result{}))
+
+			job := batchv1.Job{}
+			err = k8sClient.Get(ctx, types.NamespacedName{
+				Name:      "marina-terminal-" + jobTerminal.Name,
+				Namespace: jobTerminal.Namespace,
+			}, &job)
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+
+	When("a terminal with pod annotations is created", func() {
+		var annotatedTerminal *marinacorev1.Terminal
+		var vaultAnnotations map[string]string
+
+		BeforeAll(func() {
+			vaultAnnotations = map[string]string{
+				"vault.hashicorp.com/agent-inject": "true",
+				"vault.hashicorp.com/role":         "marina-terminal",
+			}
+
+			annotatedTerminal = &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-annotated-terminal",
+					Namespace: namespace.Name,
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image:          "busybox: 1.36.0",
+					PodAnnotations: vaultAnnotations,
+				},
+			}
+
+			err := k8sClient.Create(ctx, annotatedTerminal)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		AfterAll(func() {
+			deleteAndReconcileTerminal(ctx, reconciler, annotatedTerminal)
+		})
+
+		It("should add the annotations to the pod template", func() {
+			req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(annotatedTerminal)}
+			result, err := reconciler.Reconcile(ctx, req)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result).To(Equal(ctrl.Result{}))
+
+			deployment := getTerminalDeployment(ctx, annotatedTerminal)
+
+			for k, v := range vaultAnnotations {
+				Expect(deployment.Spec.Template.Annotations).To(HaveKeyWithValue(k, v))
+			}
+		})
+
+		It("should restore stripped annotations on subsequent reconciles", func() {
+			key := types.NamespacedName{
+				Name:      "marina-terminal-" + annotatedTerminal.Name,
+				Namespace: annotatedTerminal.Namespace,
+			}
+
+			deployment := appsv1.Deployment{}
+			err := k8sClient.Get(ctx, key, &deployment)
+			Expect(err).ToNot(HaveOccurred())
+
+			deployment.Spec.Template.Annotations = map[string]string{
+				"vault.hashicorp.com/agent-inject-status": "injected",
+			}
+			err = k8sClient.Update(ctx, &deployment)
+			Expect(err).ToNot(HaveOccurred())
+
+			req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(annotatedTerminal)}
+			result, err := reconciler.Reconcile(ctx, req)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result).To(Equal(ctrl.Result{}))
+
+			err = k8sClient.Get(ctx, key, &deployment)
+			Expect(err).ToNot(HaveOccurred())
+
+			for k, v := range vaultAnnotations {
+				Expect(deployment.Spec.Template.Annotations).To(HaveKeyWithValue(k, v))
+			}
+			Expect(deployment.Spec.Template.Annotations).To(HaveKeyWithValue("vault.hashicorp.com/agent-inject-status", "injected"))
+		})
+	})
+
+	When("a terminal is frozen", func() {
+		var frozenTerminal *marinacorev1.Terminal
+		var req ctrl.Request
+
+		BeforeAll(func() {
+			frozenTerminal = &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-frozen-terminal",
+					Namespace: namespace.Name,
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image: "busybox: 1.36.0",
+				},
+			}
+
+			req = ctrl.Request{NamespacedName: client.ObjectKeyFromObject(frozenTerminal)}
+
+			createAndReconcileTerminal(ctx, reconciler, frozenTerminal)
+		})
+
+		AfterAll(func() {
+			deleteAndReconcileTerminal(ctx, reconciler, frozenTerminal)
+		})
+
+		It("should scale the deployment to zero", func() {
+			err := k8sClient.Get(ctx, req.NamespacedName, frozenTerminal)
+			Expect(err).ToNot(HaveOccurred())
+
+			frozenTerminal.Spec.Frozen = true
+			err = k8sClient.Update(ctx, frozenTerminal)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = reconciler.Reconcile(ctx, req)
+			Expect(err).ToNot(HaveOccurred())
+
+			deployment := getTerminalDeployment(ctx, frozenTerminal)
+			Expect(*deployment.Spec.Replicas).To(Equal(int32(0)))
+
+			err = k8sClient.Get(ctx, req.NamespacedName, frozenTerminal)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should restore replicas when thawed", func() {
+			err := k8sClient.Get(ctx, req.NamespacedName, frozenTerminal)
+			Expect(err).ToNot(HaveOccurred())
+
+			frozenTerminal.Spec.Frozen = false
+			err = k8sClient.Update(ctx, frozenTerminal)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = reconciler.Reconcile(ctx, req)
+			Expect(err).ToNot(HaveOccurred())
+
+			deployment := getTerminalDeployment(ctx, frozenTerminal)
+			Expect(*deployment.Spec.Replicas).To(Equal(int32(1)))
+		})
+	})
+
+	When("a terminal with service annotations is created", func() {
+		var annotatedReconciler *TerminalReconciler
+		var annotatedTerminal *marinacorev1.Terminal
+		var req ctrl.Request
+
+		BeforeAll(func() {
+			annotatedReconciler = &TerminalReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+				DefaultServiceAnnotations: map[string]string{
+					"service.beta.kubernetes.io/aws-load-balancer-internal": "true",
+					"marina.io/overridden": "default",
+				},
+			}
+
+			annotatedTerminal = &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-service-annotations",
+					Namespace: namespace.Name,
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image: "busybox: 1.36.0",
+					ServiceAnnotations: map[string]string{
+						"marina.io/overridden": "terminal",
+					},
+				},
+			}
+
+			req = ctrl.Request{NamespacedName: client.ObjectKeyFromObject(annotatedTerminal)}
+
+			err := k8sClient.Create(ctx, annotatedTerminal)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		AfterAll(func() {
+			deleteAndReconcileTerminal(ctx, annotatedReconciler, annotatedTerminal)
+		})
+
+		It("should add default and terminal annotations to the service", func() {
+			_, err := annotatedReconciler.Reconcile(ctx, req)
+			Expect(err).ToNot(HaveOccurred())
+
+			service := corev1.Service{}
+			err = k8sClient.Get(ctx, types.NamespacedName{
+				Name:      "marina-terminal-" + annotatedTerminal.Name,
+				Namespace: annotatedTerminal.Namespace,
+			}, &service)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(service.Annotations).To(HaveKeyWithValue("service.beta.kubernetes.io/aws-load-balancer-internal", "true"))
+			Expect(service.Annotations).To(HaveKeyWithValue("marina.io/overridden", "terminal"))
+		})
+	})
+
+	When("a terminal with a runtime class is created", func() {
+		var runtimeClass *nodev1.RuntimeClass
+		var sandboxedTerminal *marinacorev1.Terminal
+		var req ctrl.Request
+
+		BeforeAll(func() {
+			runtimeClass = &nodev1.RuntimeClass{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "kata",
+				},
+				Handler: "kata",
+				Overhead: &nodev1.Overhead{
+					PodFixed: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("250m"),
+						corev1.ResourceMemory: resource.MustParse("120Mi"),
+					},
+				},
+			}
+
+			sandboxedTerminal = &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-sandboxed-terminal",
+					Namespace: namespace.Name,
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image:            "busybox: 1.36.0",
+					RuntimeClassName: &runtimeClass.Name,
+				},
+			}
+
+			req = ctrl.Request{NamespacedName: client.ObjectKeyFromObject(sandboxedTerminal)}
+
+			err := k8sClient.Create(ctx, runtimeClass)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = k8sClient.Create(ctx, sandboxedTerminal)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		AfterAll(func() {
+			deleteAndReconcileTerminal(ctx, reconciler, sandboxedTerminal)
+
+			err := k8sClient.Delete(ctx, runtimeClass)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should apply the runtime class overhead to the pod", func() {
+			_, err := reconciler.Reconcile(ctx, req)
+			Expect(err).ToNot(HaveOccurred())
+
+			deployment := getTerminalDeployment(ctx, sandboxedTerminal)
+
+			podSpec := deployment.Spec.Template.Spec
+			Expect(podSpec.RuntimeClassName).To(Equal(&runtimeClass.Name))
+			Expect(podSpec.Overhead.Cpu().Equal(resource.MustParse("250m"))).To(BeTrue())
+			Expect(podSpec.Overhead.Memory().Equal(resource.MustParse("120Mi"))).To(BeTrue())
+		})
+	})
+
+	When("a terminal with capabilities is created", func() {
+		var capabilityReconciler *TerminalReconciler
+
+		BeforeAll(func() {
+			capabilityReconciler = &TerminalReconciler{
+				Client:              k8sClient,
+				Scheme:              k8sClient.Scheme(),
+				AllowedCapabilities: []corev1.Capability{"NET_RAW"},
+			}
+		})
+
+		It("should add allowed capabilities to the container", func() {
+			allowedTerminal := &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-allowed-capabilities",
+					Namespace: namespace.Name,
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image:        "busybox: 1.36.0",
+					Capabilities: []corev1.Capability{"NET_RAW"},
+				},
+			}
+
+			createAndReconcileTerminal(ctx, capabilityReconciler, allowedTerminal)
+
+			deployment := getTerminalDeployment(ctx, allowedTerminal)
+
+			container := deployment.Spec.Template.Spec.Containers[0]
+			Expect(container.SecurityContext.Capabilities.Add).To(Equal([]corev1.Capability{"NET_RAW"}))
+
+			deleteAndReconcileTerminal(ctx, capabilityReconciler, allowedTerminal)
+		})
+
+		It("should reject disallowed capabilities", func() {
+			disallowedTerminal := &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-disallowed-capabilities",
+					Namespace: namespace.Name,
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image:        "busybox: 1.36.0",
+					Capabilities: []corev1.Capability{"SYS_ADMIN"},
+				},
+			}
+
+			req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(disallowedTerminal)}
+
+			err := k8sClient.Create(ctx, disallowedTerminal)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = capabilityReconciler.Reconcile(ctx, req)
+			Expect(err).To(HaveOccurred())
+
+			deployment := appsv1.Deployment{}
+			err = k8sClient.Get(ctx, types.NamespacedName{
+				Name:      "marina-terminal-" + disallowedTerminal.Name,
+				Namespace: disallowedTerminal.Namespace,
+			}, &deployment)
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+
+			err = k8sClient.Delete(ctx, disallowedTerminal)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		expectRejected := func(name string, securityContext *corev1.SecurityContext, message string) {
+			rejectedTerminal := &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: namespace.Name,
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image:           "busybox: 1.36.0",
+					SecurityContext: securityContext,
+				},
+			}
+
+			err := k8sClient.Create(ctx, rejectedTerminal)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = capabilityReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(rejectedTerminal)})
+			Expect(err).To(MatchError(ContainSubstring(message)))
+
+			err = k8sClient.Delete(ctx, rejectedTerminal)
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		It("should reject disallowed capabilities added by the security context", func() {
+			expectRejected("test-security-context-capabilities", &corev1.SecurityContext{
+				Capabilities: &corev1.Capabilities{Add: []corev1.Capability{"SYS_ADMIN"}},
+			}, "capability 'SYS_ADMIN' is not allowed")
+		})
+
+		It("should reject a privileged security context", func() {
+			privileged := true
+			expectRejected("test-privileged-security-context", &corev1.SecurityContext{
+				Privileged: &privileged,
+			}, "privileged containers are not allowed")
+		})
+
+		It("should reject a security context allowing privilege escalation", func() {
+			escalation := true
+			expectRejected("test-escalating-security-context", &corev1.SecurityContext{
+				AllowPrivilegeEscalation: &escalation,
+			}, "privilege escalation is not allowed")
+		})
+	})
+
+	When("a terminal deployment has an outdated selector", func() {
+		var outdatedTerminal *marinacorev1.Terminal
+		var outdatedDeployment *appsv1.Deployment
+		var req ctrl.Request
+
+		BeforeAll(func() {
+			outdatedTerminal = &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-outdated-selector",
+					Namespace: namespace.Name,
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image: "busybox: 1.36.0",
+				},
+			}
+
+			outdatedLabels := map[string]string{
+				"app": "marina-terminal-outdated",
+			}
+
+			outdatedDeployment = deploymentForTerminal(outdatedTerminal)
+			outdatedDeployment.Labels = outdatedLabels
+			outdatedDeployment.Spec.Selector.MatchLabels = outdatedLabels
+			outdatedDeployment.Spec.Template.Labels = outdatedLabels
+
+			req = ctrl.Request{NamespacedName: client.ObjectKeyFromObject(outdatedTerminal)}
+
+			err := k8sClient.Create(ctx, outdatedTerminal)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = k8sClient.Create(ctx, outdatedDeployment)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		AfterAll(func() {
+			deleteAndReconcileTerminal(ctx, reconciler, outdatedTerminal)
+		})
+
+		It("should recreate the deployment", func() {
+			_, err := reconciler.Reconcile(ctx, req)
+			Expect(err).ToNot(HaveOccurred())
+
+			deployment := appsv1.Deployment{}
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(outdatedDeployment), &deployment)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deployment.Spec.Selector.MatchLabels).To(Equal(selectorLabelsForTerminal(outdatedTerminal)))
+			Expect(deployment.Spec.Template.Labels).To(Equal(podLabelsForTerminal(outdatedTerminal)))
+		})
+	})
+
+	When("a terminal with pod labels is created", func() {
+		var labeledTerminal *marinacorev1.Terminal
+		var req ctrl.Request
+
+		BeforeAll(func() {
+			labeledTerminal = &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-pod-labels",
+					Namespace: namespace.Name,
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image: "busybox: 1.36.0",
+					PodLabels: map[string]string{
+						"team":            "fellowship",
+						TerminalNameLabel: "spoofed",
+					},
+				},
+			}
+
+			req = ctrl.Request{NamespacedName: client.ObjectKeyFromObject(labeledTerminal)}
+
+			err := k8sClient.Create(ctx, labeledTerminal)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		AfterAll(func() {
+			deleteAndReconcileTerminal(ctx, reconciler, labeledTerminal)
+		})
+
+		It("should select pods with the same labels everywhere", func() {
+			_, err := reconciler.Reconcile(ctx, req)
+			Expect(err).ToNot(HaveOccurred())
+
+			key := types.NamespacedName{
+				Name:      "marina-terminal-" + labeledTerminal.Name,
+				Namespace: labeledTerminal.Namespace,
+			}
+
+			deployment := appsv1.Deployment{}
+			err = k8sClient.Get(ctx, key, &deployment)
+			Expect(err).ToNot(HaveOccurred())
+
+			service := corev1.Service{}
+			err = k8sClient.Get(ctx, key, &service)
+			Expect(err).ToNot(HaveOccurred())
+
+			selector := selectorLabelsForTerminal(labeledTerminal)
+			Expect(deployment.Spec.Selector.MatchLabels).To(Equal(selector))
+			Expect(service.Spec.Selector).To(Equal(selector))
+
+			for k, v := range selector {
+				Expect(deployment.Spec.Template.Labels).To(HaveKeyWithValue(k, v))
+			}
+			Expect(deployment.Spec.Template.Labels).To(HaveKeyWithValue("team", "fellowship"))
+		})
+	})
+
+	When("a terminal is owned by another controller", func() {
+		var ownedTerminal *marinacorev1.Terminal
+		var owner metav1.OwnerReference
+		var req ctrl.Request
+
+		BeforeAll(func() {
+			owner = metav1.OwnerReference{
+				APIVersion:         "workspace.example.io/v1",
+				Kind:               "Workspace",
+				Name:               "test-workspace",
+				UID:                "6f1d5a6e-8c0b-4b0e-9a0d-2f7e4c9b1a3d",
+				Controller:         ToPtr(true),
+				BlockOwnerDeletion: ToPtr(true),
+			}
+
+			ownedTerminal = &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            "test-owned-terminal",
+					Namespace:       namespace.Name,
+					OwnerReferences: []metav1.OwnerReference{owner},
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image: "busybox: 1.36.0",
+				},
+			}
+
+			req = ctrl.Request{NamespacedName: client.ObjectKeyFromObject(ownedTerminal)}
+
+			err := k8sClient.Create(ctx, ownedTerminal)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		AfterAll(func() {
+			deleteAndReconcileTerminal(ctx, reconciler, ownedTerminal)
+		})
+
+		It("should reconcile children without removing the foreign owner", func() {
+			_, err := reconciler.Reconcile(ctx, req)
+			Expect(err).ToNot(HaveOccurred())
+
+			getTerminalDeployment(ctx, ownedTerminal)
+
+			err = k8sClient.Get(ctx, req.NamespacedName, ownedTerminal)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ownedTerminal.OwnerReferences).To(ConsistOf(owner))
+			Expect(ownedTerminal.Finalizers).To(ContainElement(TerminalDeploymentFinalizer))
+		})
+	})
+
+	When("a terminal with readiness gates is created", func() {
+		var gatedTerminal *marinacorev1.Terminal
+		var req ctrl.Request
+
+		BeforeAll(func() {
+			gatedTerminal = &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-readiness-gates",
+					Namespace: namespace.Name,
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image: "busybox: 1.36.0",
+					ReadinessGates: []corev1.PodReadinessGate{
+						{ConditionType: "marina.io/session-broker-ready"},
+					},
+				},
+			}
+
+			req = ctrl.Request{NamespacedName: client.ObjectKeyFromObject(gatedTerminal)}
+
+			err := k8sClient.Create(ctx, gatedTerminal)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		AfterAll(func() {
+			deleteAndReconcileTerminal(ctx, reconciler, gatedTerminal)
+		})
+
+		It("should add the readiness gates to the pod template", func() {
+			_, err := reconciler.Reconcile(ctx, req)
+			Expect(err).ToNot(HaveOccurred())
+
+			deployment := getTerminalDeployment(ctx, gatedTerminal)
+			Expect(deployment.Spec.Template.Spec.ReadinessGates).To(ConsistOf(corev1.PodReadinessGate{
+				ConditionType: "marina.io/session-broker-ready",
+			}))
+		})
+	})
+
+	When("a terminal with resources is created", func() {
+		var resourceReconciler *TerminalReconciler
+
+		BeforeAll(func() {
+			resourceReconciler = &TerminalReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+				DefaultResources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("100m"),
+						corev1.ResourceMemory: resource.MustParse("128Mi"),
+					},
+				},
+			}
+		})
+
+		It("should copy the resources to the container", func() {
+			resources := corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceCPU: resource.MustParse("500m"),
+				},
+				Limits: corev1.ResourceList{
+					corev1.ResourceCPU: resource.MustParse("1"),
+				},
+			}
+
+			resourceTerminal := &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-resources",
+					Namespace: namespace.Name,
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image:     "busybox: 1.36.0",
+					Resources: resources,
+				},
+			}
+
+			createAndReconcileTerminal(ctx, resourceReconciler, resourceTerminal)
+
+			deployment := getTerminalDeployment(ctx, resourceTerminal)
+
+			container := deployment.Spec.Template.Spec.Containers[0]
+			Expect(container.Resources.Requests.Cpu().Equal(resource.MustParse("500m"))).To(BeTrue())
+			Expect(container.Resources.Limits.Cpu().Equal(resource.MustParse("1"))).To(BeTrue())
+
+			deleteAndReconcileTerminal(ctx, resourceReconciler, resourceTerminal)
+		})
+
+		It("should use the default resources when none are specified", func() {
+			defaultTerminal := &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-default-resources",
+					Namespace: namespace.Name,
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image: "busybox: 1.36.0",
+				},
+			}
+
+			createAndReconcileTerminal(ctx, resourceReconciler, defaultTerminal)
+
+			deployment := getTerminalDeployment(ctx, defaultTerminal)
+
+			container := deployment.Spec.Template.Spec.Containers[0]
+			Expect(container.Resources.Requests.Cpu().Equal(resource.MustParse("100m"))).To(BeTrue())
+			Expect(container.Resources.Requests.Memory().Equal(resource.MustParse("128Mi"))).To(BeTrue())
+
+			deleteAndReconcileTerminal(ctx, resourceReconciler, defaultTerminal)
+		})
+
+		It("should reject limits below requests", func() {
+			invalidTerminal := &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-invalid-resources",
+					Namespace: namespace.Name,
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image: "busybox: 1.36.0",
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceMemory: resource.MustParse("1Gi"),
+						},
+						Limits: corev1.ResourceList{
+							corev1.ResourceMemory: resource.MustParse("512Mi"),
+						},
+					},
+				},
+			}
+
+			req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(invalidTerminal)}
+
+			err := k8sClient.Create(ctx, invalidTerminal)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = resourceReconciler.Reconcile(ctx, req)
+			Expect(err).To(HaveOccurred())
+
+			deployment := appsv1.Deployment{}
+			err = k8sClient.Get(ctx, types.NamespacedName{
+				Name:      "marina-terminal-" + invalidTerminal.Name,
+				Namespace: invalidTerminal.Namespace,
+			}, &deployment)
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+
+			err = k8sClient.Delete(ctx, invalidTerminal)
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
+	When("a terminal is scaled", func() {
+		var scaledTerminal *marinacorev1.Terminal
+		var req ctrl.Request
+
+		BeforeAll(func() {
+			scaledTerminal = &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-scaled-terminal",
+					Namespace: namespace.Name,
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image: "busybox: 1.36.0",
+				},
+			}
+
+			req = ctrl.Request{NamespacedName: client.ObjectKeyFromObject(scaledTerminal)}
+
+			createAndReconcileTerminal(ctx, reconciler, scaledTerminal)
+		})
+
+		AfterAll(func() {
+			deleteAndReconcileTerminal(ctx, reconciler, scaledTerminal)
+		})
+
+		It("should default to a single replica", func() {
+			deployment := getTerminalDeployment(ctx, scaledTerminal)
+			Expect(*deployment.Spec.Replicas).To(Equal(int32(1)))
+		})
+
+		It("should update the deployment replicas", func() {
+			err := k8sClient.Get(ctx, req.NamespacedName, scaledTerminal)
+			Expect(err).ToNot(HaveOccurred())
+
+			scaledTerminal.Spec.Replicas = ToPtr[int32](3)
+			err = k8sClient.Update(ctx, scaledTerminal)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = reconciler.Reconcile(ctx, req)
+			Expect(err).ToNot(HaveOccurred())
+
+			deployment := getTerminalDeployment(ctx, scaledTerminal)
+			Expect(*deployment.Spec.Replicas).To(Equal(int32(3)))
+		})
+	})
+
+	When("a terminal is reconciled during a maintenance window", func() {
+		var maintenanceReconciler *TerminalReconciler
+		var maintenanceTerminal *marinacorev1.Terminal
+		var req ctrl.Request
+		var deploymentKey types.NamespacedName
+
+		BeforeAll(func() {
+			window, err := ParseMaintenanceWindow("* * * * *", time.Hour)
+			Expect(err).ToNot(HaveOccurred())
+
+			maintenanceReconciler = &TerminalReconciler{
+				Client:            k8sClient,
+				Scheme:            k8sClient.Scheme(),
+				MaintenanceWindow: window,
+			}
+
+			maintenanceTerminal = &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-maintenance-terminal",
+					Namespace: namespace.Name,
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image: "busybox: 1.36.0",
+				},
+			}
+
+			req = ctrl.Request{NamespacedName: client.ObjectKeyFromObject(maintenanceTerminal)}
+
+			deploymentKey = types.NamespacedName{
+				Name:      "marina-terminal-" + maintenanceTerminal.Name,
+				Namespace: maintenanceTerminal.Namespace,
+			}
+
+			err = k8sClient.Create(ctx, maintenanceTerminal)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		AfterAll(func() {
+			deleteAndReconcileTerminal(ctx, reconciler, maintenanceTerminal)
+		})
+
+		It("should not mutate any children", func() {
+			result, err := maintenanceReconciler.Reconcile(ctx, req)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(MaintenanceRequeueInterval))
+
+			err = k8sClient.Get(ctx, deploymentKey, &appsv1.Deployment{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+
+			err = k8sClient.Get(ctx, req.NamespacedName, maintenanceTerminal)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(maintenanceTerminal.Finalizers).To(BeEmpty())
+		})
+
+		It("should resume mutations outside the window", func() {
+			_, err := reconciler.Reconcile(ctx, req)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = k8sClient.Get(ctx, deploymentKey, &appsv1.Deployment{})
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
+	When("a terminal image is changed", func() {
+		var imageTerminal *marinacorev1.Terminal
+		var req ctrl.Request
+
+		BeforeAll(func() {
+			imageTerminal = &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-image-terminal",
+					Namespace: namespace.Name,
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image: "busybox: 1.36.0",
+				},
+			}
+
+			req = ctrl.Request{NamespacedName: client.ObjectKeyFromObject(imageTerminal)}
+
+			createAndReconcileTerminal(ctx, reconciler, imageTerminal)
+		})
+
+		AfterAll(func() {
+			deleteAndReconcileTerminal(ctx, reconciler, imageTerminal)
+		})
+
+		It("should update the deployment image", func() {
+			err := k8sClient.Get(ctx, req.NamespacedName, imageTerminal)
+			Expect(err).ToNot(HaveOccurred())
+
+			imageTerminal.Spec.Image = "busybox: 1.37.0"
+			err = k8sClient.Update(ctx, imageTerminal)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = reconciler.Reconcile(ctx, req)
+			Expect(err).ToNot(HaveOccurred())
+
+			deployment := getTerminalDeployment(ctx, imageTerminal)
+			Expect(deployment.Spec.Template.Spec.Containers[0].Image).To(Equal("busybox: 1.37.0"))
+		})
+
+		It("should apply every other change to the pod template", func() {
+			err := k8sClient.Get(ctx, req.NamespacedName, imageTerminal)
+			Expect(err).ToNot(HaveOccurred())
+
+			gracePeriod := int64(5)
+			imageTerminal.Spec.ImagePullPolicy = corev1.PullAlways
+			imageTerminal.Spec.Env = []corev1.EnvVar{{Name: "EDITOR", Value: "vim"}}
+			imageTerminal.Spec.TerminationGracePeriodSeconds = &gracePeriod
+			imageTerminal.Spec.Sidecars = []corev1.Container{{Name: "log-shipper", Image: "busybox: 1.36.0"}}
+			imageTerminal.Spec.Volumes = []corev1.Volume{
+				{Name: "scratch", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+			}
+			err = k8sClient.Update(ctx, imageTerminal)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = reconciler.Reconcile(ctx, req)
+			Expect(err).ToNot(HaveOccurred())
+
+			deployment := getTerminalDeployment(ctx, imageTerminal)
+
+			podSpec := deployment.Spec.Template.Spec
+			Expect(podSpec.Containers[0].ImagePullPolicy).To(Equal(corev1.PullAlways))
+			Expect(podSpec.Containers[0].Env).To(ContainElement(imageTerminal.Spec.Env[0]))
+			Expect(podSpec.TerminationGracePeriodSeconds).To(Equal(&gracePeriod))
+			Expect(podSpec.Containers).To(ContainElement(HaveField("Name", "log-shipper")))
+			Expect(podSpec.Volumes).To(ContainElement(imageTerminal.Spec.Volumes[0]))
+		})
+	})
+
+	When("a terminal with scheduling gates is created", func() {
+		var gatedTerminal *marinacorev1.Terminal
+		var req ctrl.Request
+
+		BeforeAll(func() {
+			gatedTerminal = &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-gated-terminal",
+					Namespace: namespace.Name,
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image: "busybox: 1.36.0",
+					SchedulingGates: []corev1.PodSchedulingGate{
+						{Name: "marina.io/quota"},
+					},
+				},
+			}
+
+			req = ctrl.Request{NamespacedName: client.ObjectKeyFromObject(gatedTerminal)}
+
+			createAndReconcileTerminal(ctx, reconciler, gatedTerminal)
+		})
+
+		AfterAll(func() {
+			deleteAndReconcileTerminal(ctx, reconciler, gatedTerminal)
+		})
+
+		It("should add the scheduling gates to the pod template", func() {
+			deployment := getTerminalDeployment(ctx, gatedTerminal)
+			Expect(deployment.Spec.Template.Spec.SchedulingGates).To(Equal(gatedTerminal.Spec.SchedulingGates))
+		})
+
+		It("should report the terminal as gated", func() {
+			err := k8sClient.Get(ctx, req.NamespacedName, gatedTerminal)
+			Expect(err).ToNot(HaveOccurred())
+
+			condition := meta.FindStatusCondition(gatedTerminal.Status.Conditions, marinacorev1.TerminalConditionGated)
+			Expect(condition).ToNot(BeNil())
+			Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+			Expect(condition.Reason).To(Equal("SchedulingGated"))
+		})
+	})
+
+	When("a terminal has children", func() {
+		var ownedTerminal *marinacorev1.Terminal
+		var req ctrl.Request
+
+		BeforeAll(func() {
+			ownedTerminal = &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-owned-children",
+					Namespace: namespace.Name,
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image: "busybox: 1.36.0",
+				},
+			}
+
+			req = ctrl.Request{NamespacedName: client.ObjectKeyFromObject(ownedTerminal)}
+
+			createAndReconcileTerminal(ctx, reconciler, ownedTerminal)
+
+			err := k8sClient.Get(ctx, req.NamespacedName, ownedTerminal)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		AfterAll(func() {
+			deleteAndReconcileTerminal(ctx, reconciler, ownedTerminal)
+		})
+
+		It("should set the terminal as the owner of its children", func() {
+			childKey := types.NamespacedName{
+				Name:      "marina-terminal-" + ownedTerminal.Name,
+				Namespace: ownedTerminal.Namespace,
+			}
+
+			deployment := appsv1.Deployment{}
+			err := k8sClient.Get(ctx, childKey, &deployment)
+			Expect(err).ToNot(HaveOccurred())
+
+			service := corev1.Service{}
+			err = k8sClient.Get(ctx, childKey, &service)
+			Expect(err).ToNot(HaveOccurred())
+
+			for _, owners := range [][]metav1.OwnerReference{deployment.OwnerReferences, service.OwnerReferences} {
+				Expect(owners).To(HaveLen(1))
+				Expect(owners[0].Kind).To(Equal("Terminal"))
+				Expect(owners[0].Name).To(Equal(ownedTerminal.Name))
+				Expect(owners[0].UID).To(Equal(ownedTerminal.UID))
+				Expect(*owners[0].Controller).To(BeTrue())
+			}
+		})
+	})
+
+	When("a terminal with a pod template is created", func() {
+		var templateTerminal *marinacorev1.Terminal
+		var configMap *corev1.ConfigMap
+		var req ctrl.Request
+
+		BeforeAll(func() {
+			configMap = &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-pod-template",
+					Namespace: namespace.Name,
+				},
+				Data: map[string]string{
+					"template.yaml": `metadata:
+  labels:
+    team: dwarves
+spec:
+  containers:
+  - name: custom-shell
+    image: alpine:3.20
+    command: ["sleep", "infinity"]
+`,
+				},
+			}
+
+			err := k8sClient.Create(ctx, configMap)
+			Expect(err).ToNot(HaveOccurred())
+
+			templateTerminal = &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-template-terminal",
+					Namespace: namespace.Name,
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image: "busybox: 1.36.0",
+					PodTemplateRef: &corev1.ConfigMapKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: configMap.Name},
+						Key:                  "template.yaml",
+					},
+				},
+			}
+
+			req = ctrl.Request{NamespacedName: client.ObjectKeyFromObject(templateTerminal)}
+
+			createAndReconcileTerminal(ctx, reconciler, templateTerminal)
+		})
+
+		AfterAll(func() {
+			deleteAndReconcileTerminal(ctx, reconciler, templateTerminal)
+
+			err := k8sClient.Delete(ctx, configMap)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should use the referenced pod template with the managed labels", func() {
+			deployment := getTerminalDeployment(ctx, templateTerminal)
+
+			Expect(deployment.Spec.Template.Spec.Containers).To(HaveLen(1))
+			Expect(deployment.Spec.Template.Spec.Containers[0].Name).To(Equal("custom-shell"))
+			Expect(deployment.Spec.Template.Spec.Containers[0].Image).To(Equal("alpine:3.20"))
+
+			Expect(deployment.Spec.Template.Labels).To(HaveKeyWithValue("team", "dwarves"))
+			for k, v := range selectorLabelsForTerminal(templateTerminal) {
+				Expect(deployment.Spec.Template.Labels).To(HaveKeyWithValue(k, v))
+			}
+		})
+
+		It("should update the deployment when the pod template changes", func() {
+			configMap.Data["template.yaml"] = strings.Replace(configMap.Data["template.yaml"], "alpine:3.20", "alpine:3.21", 1)
+			err := k8sClient.Update(ctx, configMap)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(reconciler.terminalsForConfigMap(ctx, configMap)).To(ConsistOf(req))
+
+			_, err = reconciler.Reconcile(ctx, req)
+			Expect(err).ToNot(HaveOccurred())
+
+			deployment := getTerminalDeployment(ctx, templateTerminal)
+			Expect(deployment.Spec.Template.Spec.Containers[0].Image).To(Equal("alpine:3.21"))
+		})
+
+		It("should reject a privileged pod template", func() {
+			configMap.Data["template.yaml"] += "    securityContext:\n      privileged: true\n"
+			err := k8sClient.Update(ctx, configMap)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = reconciler.Reconcile(ctx, req)
+			Expect(err).To(MatchError(ContainSubstring("container 'custom-shell': privileged containers are not allowed")))
+		})
+
+		It("should reject a pod template using the host's namespaces", func() {
+			configMap.Data["template.yaml"] = strings.Replace(configMap.Data["template.yaml"], "spec:\n", "spec:\n  hostPID: true\n", 1)
+			err := k8sClient.Update(ctx, configMap)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = reconciler.Reconcile(ctx, req)
+			Expect(err).To(MatchError(ContainSubstring("host namespaces are not allowed")))
+		})
+	})
+
+	When("a terminal is reset", func() {
+		var resetTerminal *marinacorev1.Terminal
+		var req ctrl.Request
+		var childKey types.NamespacedName
+
+		BeforeAll(func() {
+			resetTerminal = &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-reset-terminal",
+					Namespace: namespace.Name,
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image: "busybox: 1.36.0",
+				},
+			}
+
+			req = ctrl.Request{NamespacedName: client.ObjectKeyFromObject(resetTerminal)}
+
+			childKey = types.NamespacedName{
+				Name:      "marina-terminal-" + resetTerminal.Name,
+				Namespace: resetTerminal.Namespace,
+			}
+
+			createAndReconcileTerminal(ctx, reconciler, resetTerminal)
+		})
+
+		AfterAll(func() {
+			deleteAndReconcileTerminal(ctx, reconciler, resetTerminal)
+		})
+
+		It("should recreate the children and keep the terminal", func() {
+			deployment := appsv1.Deployment{}
+			err := k8sClient.Get(ctx, childKey, &deployment)
+			Expect(err).ToNot(HaveOccurred())
+
+			deployment.Labels["stale"] = "true"
+			err = k8sClient.Update(ctx, &deployment)
+			Expect(err).ToNot(HaveOccurred())
+
+			service := corev1.Service{}
+			err = k8sClient.Get(ctx, childKey, &service)
+			Expect(err).ToNot(HaveOccurred())
+
+			service.Labels = map[string]string{"stale": "true"}
+			err = k8sClient.Update(ctx, &service)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = k8sClient.Get(ctx, req.NamespacedName, resetTerminal)
+			Expect(err).ToNot(HaveOccurred())
+
+			resetTerminal.Annotations = map[string]string{marinacorev1.TerminalResetAnnotation: "true"}
+			err = k8sClient.Update(ctx, resetTerminal)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = reconciler.Reconcile(ctx, req)
+			Expect(err).ToNot(HaveOccurred())
+
+			deployment = appsv1.Deployment{}
+			err = k8sClient.Get(ctx, childKey, &deployment)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deployment.Labels).ToNot(HaveKey("stale"))
+
+			service = corev1.Service{}
+			err = k8sClient.Get(ctx, childKey, &service)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(service.Labels).ToNot(HaveKey("stale"))
+
+			err = k8sClient.Get(ctx, req.NamespacedName, resetTerminal)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resetTerminal.Annotations).ToNot(HaveKey(marinacorev1.TerminalResetAnnotation))
+			Expect(resetTerminal.GetDeletionTimestamp()).To(BeNil())
+		})
+	})
+
+	When("a terminal service is created", func() {
+		var endpointTerminal *marinacorev1.Terminal
+		var req ctrl.Request
+
+		BeforeAll(func() {
+			endpointTerminal = &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-endpoint-terminal",
+					Namespace: namespace.Name,
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image:       "busybox: 1.36.0",
+					ServiceType: corev1.ServiceTypeNodePort,
+				},
+			}
+
+			req = ctrl.Request{NamespacedName: client.ObjectKeyFromObject(endpointTerminal)}
+
+			createAndReconcileTerminal(ctx, reconciler, endpointTerminal)
+		})
+
+		AfterAll(func() {
+			deleteAndReconcileTerminal(ctx, reconciler, endpointTerminal)
+		})
+
+		It("should report the service endpoint", func() {
+			service := corev1.Service{}
+			err := k8sClient.Get(ctx, types.NamespacedName{
+				Name:      "marina-terminal-" + endpointTerminal.Name,
+				Namespace: endpointTerminal.Namespace,
+			}, &service)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(service.Spec.Type).To(Equal(corev1.ServiceTypeNodePort))
+
+			err = k8sClient.Get(ctx, req.NamespacedName, endpointTerminal)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(endpointTerminal.Status.ServiceName).To(Equal(service.Name))
+			Expect(endpointTerminal.Status.Endpoint).To(Equal(service.Name + "." + service.Namespace + ".svc:22"))
+			Expect(endpointTerminal.Status.NodePort).To(Equal(service.Spec.Ports[0].NodePort))
+		})
+	})
+
+	When("a terminal image is removed from the allowlist", func() {
+		var allowlistReconciler *TerminalReconciler
+		var allowlist *corev1.ConfigMap
+		var allowlistTerminal *marinacorev1.Terminal
+		var req ctrl.Request
+
+		BeforeAll(func() {
+			allowlist = &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-image-allowlist",
+					Namespace: namespace.Name,
+				},
+				Data: map[string]string{
+					ImageAllowlistKey: "busybox*\n",
+				},
+			}
+
+			err := k8sClient.Create(ctx, allowlist)
+			Expect(err).ToNot(HaveOccurred())
+
+			allowlistReconciler = &TerminalReconciler{
+				Client:         k8sClient,
+				Scheme:         k8sClient.Scheme(),
+				ImageAllowlist: client.ObjectKeyFromObject(allowlist),
+			}
+
+			allowlistTerminal = &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-allowlist-terminal",
+					Namespace: namespace.Name,
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image: "busybox: 1.36.0",
+				},
+			}
+
+			req = ctrl.Request{NamespacedName: client.ObjectKeyFromObject(allowlistTerminal)}
+
+			createAndReconcileTerminal(ctx, allowlistReconciler, allowlistTerminal)
+		})
+
+		AfterAll(func() {
+			deleteAndReconcileTerminal(ctx, allowlistReconciler, allowlistTerminal)
+
+			err := k8sClient.Delete(ctx, allowlist)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should not fail an allowed terminal", func() {
+			err := k8sClient.Get(ctx, req.NamespacedName, allowlistTerminal)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(meta.IsStatusConditionFalse(allowlistTerminal.Status.Conditions, marinacorev1.TerminalConditionFailed)).To(BeTrue())
+		})
+
+		It("should fail the terminal once its image is disallowed", func() {
+			allowlist.Data[ImageAllowlistKey] = "alpine*\n"
+			err := k8sClient.Update(ctx, allowlist)
+			Expect(err).ToNot(HaveOccurred())
+
+			requests := allowlistReconciler.terminalsForConfigMap(ctx, allowlist)
+			Expect(requests).To(ContainElement(req))
+
+			_, err = allowlistReconciler.Reconcile(ctx, req)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = k8sClient.Get(ctx, req.NamespacedName, allowlistTerminal)
+			Expect(err).ToNot(HaveOccurred())
+
+			condition := meta.FindStatusCondition(allowlistTerminal.Status.Conditions, marinacorev1.TerminalConditionFailed)
+			Expect(condition).ToNot(BeNil())
+			Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+			Expect(condition.Reason).To(Equal("ImageNotAllowed"))
+		})
+
+		It("should fail the terminal when an init container image is disallowed", func() {
+			allowlist.Data[ImageAllowlistKey] = "busybox*\n"
+			err := k8sClient.Update(ctx, allowlist)
+			Expect(err).ToNot(HaveOccurred())
+
+			allowlistTerminal.Spec.InitContainers = []corev1.Container{{Name: "setup", Image: "alpine:3.20"}}
+			err = k8sClient.Update(ctx, allowlistTerminal)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = allowlistReconciler.Reconcile(ctx, req)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = k8sClient.Get(ctx, req.NamespacedName, allowlistTerminal)
+			Expect(err).ToNot(HaveOccurred())
+
+			condition := meta.FindStatusCondition(allowlistTerminal.Status.Conditions, marinacorev1.TerminalConditionFailed)
+			Expect(condition).ToNot(BeNil())
+			Expect(condition.Reason).To(Equal("ImageNotAllowed"))
+			Expect(condition.Message).To(ContainSubstring("alpine:3.20"))
+		})
+
+		It("should fail the terminal when its setup job image is disallowed", func() {
+			allowlistTerminal.Spec.InitContainers = nil
+			allowlistTerminal.Spec.SetupJob = &marinacorev1.TerminalSetupJob{Image: "alpine:3.21"}
+			err := k8sClient.Update(ctx, allowlistTerminal)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = allowlistReconciler.Reconcile(ctx, req)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = k8sClient.Get(ctx, req.NamespacedName, allowlistTerminal)
+			Expect(err).ToNot(HaveOccurred())
+
+			condition := meta.FindStatusCondition(allowlistTerminal.Status.Conditions, marinacorev1.TerminalConditionFailed)
+			Expect(condition).ToNot(BeNil())
+			Expect(condition.Reason).To(Equal("ImageNotAllowed"))
+			Expect(condition.Message).To(ContainSubstring("alpine:3.21"))
+		})
+	})
+
+	When("a terminal with a custom port is created", func() {
+		var portTerminal *marinacorev1.Terminal
+		var req ctrl.Request
+		var childKey types.NamespacedName
+
+		BeforeAll(func() {
+			portTerminal = &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-port-terminal",
+					Namespace: namespace.Name,
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image: "busybox: 1.36.0",
+					Port:  2222,
+				},
+			}
+
+			req = ctrl.Request{NamespacedName: client.ObjectKeyFromObject(portTerminal)}
+
+			childKey = types.NamespacedName{
+				Name:      "marina-terminal-" + portTerminal.Name,
+				Namespace: portTerminal.Namespace,
+			}
+
+			createAndReconcileTerminal(ctx, reconciler, portTerminal)
+		})
+
+		AfterAll(func() {
+			deleteAndReconcileTerminal(ctx, reconciler, portTerminal)
+		})
+
+		It("should use the port for the container and service target", func() {
+			deployment := appsv1.Deployment{}
+			err := k8sClient.Get(ctx, childKey, &deployment)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deployment.Spec.Template.Spec.Containers[0].Ports).To(ContainElement(HaveField("ContainerPort", int32(2222))))
+
+			service := corev1.Service{}
+			err = k8sClient.Get(ctx, childKey, &service)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(service.Spec.Ports[0].Port).To(Equal(int32(22)))
+			Expect(service.Spec.Ports[0].TargetPort.IntVal).To(Equal(int32(2222)))
+		})
+
+		It("should update the container port when the port changes", func() {
+			err := k8sClient.Get(ctx, req.NamespacedName, portTerminal)
+			Expect(err).ToNot(HaveOccurred())
+
+			portTerminal.Spec.Port = 2200
+			err = k8sClient.Update(ctx, portTerminal)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = reconciler.Reconcile(ctx, req)
+			Expect(err).ToNot(HaveOccurred())
+
+			deployment := appsv1.Deployment{}
+			err = k8sClient.Get(ctx, childKey, &deployment)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deployment.Spec.Template.Spec.Containers[0].Ports).To(ConsistOf(HaveField("ContainerPort", int32(2200))))
+		})
+	})
+
+	When("a terminal with a setup job is created", func() {
+		var setupTerminal *marinacorev1.Terminal
+		var req ctrl.Request
+		var deploymentKey types.NamespacedName
+
+		BeforeAll(func() {
+			setupTerminal = &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-setup-terminal",
+					Namespace: namespace.Name,
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image: "busybox: 1.36.0",
+					SetupJob: &marinacorev1.TerminalSetupJob{
+						Image:   "busybox: 1.36.0",
+						Command: []string{"echo", "setup"},
+					},
+				},
+			}
+
+			req = ctrl.Request{NamespacedName: client.ObjectKeyFromObject(setupTerminal)}
+
+			deploymentKey = types.NamespacedName{
+				Name:      "marina-terminal-" + setupTerminal.Name,
+				Namespace: setupTerminal.Namespace,
+			}
+
+			createAndReconcileTerminal(ctx, reconciler, setupTerminal)
+		})
+
+		AfterAll(func() {
+			deleteAndReconcileTerminal(ctx, reconciler, setupTerminal)
+		})
+
+		It("should not create the deployment until the setup job completes", func() {
+			err := k8sClient.Get(ctx, deploymentKey, &appsv1.Deployment{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+
+			err = k8sClient.Get(ctx, req.NamespacedName, setupTerminal)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(meta.IsStatusConditionFalse(setupTerminal.Status.Conditions, marinacorev1.TerminalConditionSetupComplete)).To(BeTrue())
+		})
+
+		It("should create the deployment once the setup job completes", func() {
+			job := batchv1.Job{}
+			err := k8sClient.Get(ctx, types.NamespacedName{
+				Name:      "marina-terminal-" + setupTerminal.Name + "-setup",
+				Namespace: setupTerminal.Namespace,
+			}, &job)
+			Expect(err).ToNot(HaveOccurred())
+
+			now := metav1.Now()
+			job.Status.StartTime = &now
+			job.Status.CompletionTime = &now
+			job.Status.Succeeded = 1
+			job.Status.Conditions = []batchv1.JobCondition{
+				{Type: batchv1.JobComplete, Status: corev1.ConditionTrue},
+			}
+			err = k8sClient.Status().Update(ctx, &job)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = reconciler.Reconcile(ctx, req)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = k8sClient.Get(ctx, deploymentKey, &appsv1.Deployment{})
+			Expect(err).ToNot(HaveOccurred())
+
+			err = k8sClient.Get(ctx, req.NamespacedName, setupTerminal)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(meta.IsStatusConditionTrue(setupTerminal.Status.Conditions, marinacorev1.TerminalConditionSetupComplete)).To(BeTrue())
+		})
+	})
+
+	When("a terminal with environment variables is created", func() {
+		var envTerminal *marinacorev1.Terminal
+
+		BeforeAll(func() {
+			envTerminal = &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-env-terminal",
+					Namespace: namespace.Name,
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image: "busybox: 1.36.0",
+					Env: []corev1.EnvVar{
+						{Name: "PS1", Value: "marina$ "},
+						{
+							Name: "API_TOKEN",
+							ValueFrom: &corev1.EnvVarSource{
+								SecretKeyRef: &corev1.SecretKeySelector{
+									LocalObjectReference: corev1.LocalObjectReference{Name: "api-token"},
+									Key:                  "token",
+								},
+							},
+						},
+					},
+				},
+			}
+
+			createAndReconcileTerminal(ctx, reconciler, envTerminal)
+		})
+
+		AfterAll(func() {
+			deleteAndReconcileTerminal(ctx, reconciler, envTerminal)
+		})
+
+		It("should add the environment to the container", func() {
+			deployment := getTerminalDeployment(ctx, envTerminal)
+			Expect(deployment.Spec.Template.Spec.Containers[0].Env).To(Equal(envTerminal.Spec.Env))
+		})
+	})
+
+	When("a terminal with a persistent home is created", func() {
+		var homeTerminal *marinacorev1.Terminal
+		var req ctrl.Request
+		var claimKey types.NamespacedName
+
+		BeforeEach(func() {
+			homeTerminal = &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-home-terminal",
+					Namespace: namespace.Name,
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image: "busybox: 1.36.0",
+					PersistentHome: &marinacorev1.PersistentHome{
+						Size: resource.MustParse("1Gi"),
+					},
+				},
+			}
+
+			req = ctrl.Request{NamespacedName: client.ObjectKeyFromObject(homeTerminal)}
+
+			claimKey = types.NamespacedName{
+				Name:      "marina-terminal-" + homeTerminal.Name + "-home",
+				Namespace: homeTerminal.Namespace,
+			}
+		})
+
+		AfterEach(func() {
+			claim := &corev1.PersistentVolumeClaim{}
+			if err := k8sClient.Get(ctx, claimKey, claim); err == nil {
+				claim.Finalizers = nil
+				Expect(k8sClient.Update(ctx, claim)).To(Succeed())
+				Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, claim))).To(Succeed())
+			}
+		})
+
+		It("should mount the home volume and delete it with the terminal", func() {
+			createAndReconcileTerminal(ctx, reconciler, homeTerminal)
+
+			claim := corev1.PersistentVolumeClaim{}
+			err := k8sClient.Get(ctx, claimKey, &claim)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(claim.Spec.Resources.Requests.Storage().Equal(resource.MustParse("1Gi"))).To(BeTrue())
+
+			deployment := getTerminalDeployment(ctx, homeTerminal)
+			Expect(deployment.Spec.Template.Spec.Volumes).To(ContainElement(HaveField("VolumeSource.PersistentVolumeClaim.ClaimName", claimKey.Name)))
+			Expect(deployment.Spec.Template.Spec.Containers[0].VolumeMounts).To(ContainElement(corev1.VolumeMount{
+				Name:      "home",
+				MountPath: DefaultHomeMountPath,
+			}))
+
+			deleteAndReconcileTerminal(ctx, reconciler, homeTerminal)
+
+			// the claim may be held by the pvc protection finalizer, but should at least be deleting
+			claim = corev1.PersistentVolumeClaim{}
+			err = k8sClient.Get(ctx, claimKey, &claim)
+			if err == nil {
+				Expect(claim.GetDeletionTimestamp()).ToNot(BeNil())
+			} else {
+				Expect(errors.IsNotFound(err)).To(BeTrue())
+			}
+		})
+
+		It("should retain the home volume when requested", func() {
+			homeTerminal.Spec.PersistentHome.RetainVolume = true
+
+			createAndReconcileTerminal(ctx, reconciler, homeTerminal)
+
+			deleteAndReconcileTerminal(ctx, reconciler, homeTerminal)
+
+			err := k8sClient.Get(ctx, req.NamespacedName, &marinacorev1.Terminal{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+
+			claim := corev1.PersistentVolumeClaim{}
+			err = k8sClient.Get(ctx, claimKey, &claim)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(claim.GetDeletionTimestamp()).To(BeNil())
+		})
+
+		It("should mount the home volume at the working directory", func() {
+			homeTerminal.Spec.WorkingDir = "/home/marina"
+
+			createAndReconcileTerminal(ctx, reconciler, homeTerminal)
+
+			deployment := getTerminalDeployment(ctx, homeTerminal)
+
+			container := deployment.Spec.Template.Spec.Containers[0]
+			Expect(container.WorkingDir).To(Equal("/home/marina"))
+			Expect(container.VolumeMounts).To(ContainElement(corev1.VolumeMount{
+				Name:      "home",
+				MountPath: "/home/marina",
+			}))
+
+			deleteAndReconcileTerminal(ctx, reconciler, homeTerminal)
+		})
+	})
+
+	When("a terminal for a user is created", func() {
+		var user *marinacorev1.User
+		var userTerminal *marinacorev1.Terminal
+
+		BeforeAll(func() {
+			user = &marinacorev1.User{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-terminal-user",
+					Namespace: namespace.Name,
+				},
+				Spec: marinacorev1.UserSpec{
+					Name:     "ori",
+					Password: []byte("nori"),
+				},
+			}
+
+			err := k8sClient.Create(ctx, user)
+			Expect(err).ToNot(HaveOccurred())
+
+			user.Status.UID = 10042
+			err = k8sClient.Status().Update(ctx, user)
+			Expect(err).ToNot(HaveOccurred())
+
+			userTerminal = &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-user-terminal",
+					Namespace: namespace.Name,
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image: "busybox: 1.36.0",
+					User:  user.Name,
+				},
+			}
+
+			createAndReconcileTerminal(ctx, reconciler, userTerminal)
+		})
+
+		AfterAll(func() {
+			deleteAndReconcileTerminal(ctx, reconciler, userTerminal)
+
+			err := k8sClient.Delete(ctx, user)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should run the terminal as the user's uid", func() {
+			deployment := getTerminalDeployment(ctx, userTerminal)
+
+			securityContext := deployment.Spec.Template.Spec.SecurityContext
+			Expect(securityContext).ToNot(BeNil())
+			Expect(*securityContext.RunAsUser).To(Equal(int64(10042)))
+			Expect(*securityContext.RunAsGroup).To(Equal(int64(10042)))
+		})
+
+		It("should mount the user's credentials", func() {
+			deployment := getTerminalDeployment(ctx, userTerminal)
+
+			podSpec := deployment.Spec.Template.Spec
+			Expect(podSpec.Volumes).To(ContainElement(HaveField("VolumeSource.Secret.SecretName", user.Name)))
+			Expect(podSpec.Containers[0].VolumeMounts).To(ContainElement(corev1.VolumeMount{
+				Name:      "credentials",
+				MountPath: TerminalCredentialsMountPath,
+				ReadOnly:  true,
+			}))
+		})
+
+		It("should fail to reconcile a terminal whose user does not exist", func() {
+			orphanTerminal := &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-orphan-terminal",
+					Namespace: namespace.Name,
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image: "busybox: 1.36.0",
+					User:  "missing-user",
+				},
+			}
+
+			err := k8sClient.Create(ctx, orphanTerminal)
+			Expect(err).ToNot(HaveOccurred())
+
+			orphanReq := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(orphanTerminal)}
+			_, err = reconciler.Reconcile(ctx, orphanReq)
+			Expect(err).To(MatchError(ContainSubstring("user 'missing-user' does not exist")))
+
+			err = k8sClient.Delete(ctx, orphanTerminal)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = reconciler.Reconcile(ctx, orphanReq)
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
+	When("a terminal cannot pull its image", func() {
+		var pullTerminal *marinacorev1.Terminal
+		var pod *corev1.Pod
+		var clock *clocktesting.FakePassiveClock
+		var pullReconciler *TerminalReconciler
+		var req ctrl.Request
+
+		BeforeAll(func() {
+			pullTerminal = &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-pull-terminal",
+					Namespace: namespace.Name,
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image: "busybox: 1.36.0",
+					ImagePullBackOffPolicy: &marinacorev1.ImagePullBackOffPolicy{
+						Action:  marinacorev1.ImagePullBackOffFail,
+						Timeout: &metav1.Duration{Duration: 5 * time.Minute},
+					},
+				},
+			}
+
+			req = ctrl.Request{NamespacedName: client.ObjectKeyFromObject(pullTerminal)}
+
+			err := k8sClient.Create(ctx, pullTerminal)
+			Expect(err).ToNot(HaveOccurred())
+
+			pod = &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "marina-terminal-" + pullTerminal.Name + "-abcde",
+					Namespace: namespace.Name,
+					Labels:    selectorLabelsForTerminal(pullTerminal),
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{containerForTerminal(pullTerminal)},
+				},
+			}
+
+			err = k8sClient.Create(ctx, pod)
+			Expect(err).ToNot(HaveOccurred())
+
+			pod.Status.ContainerStatuses = []corev1.ContainerStatus{
+				{
+					Name: "terminal",
+					State: corev1.ContainerState{
+						Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff"},
+					},
+				},
+			}
+			err = k8sClient.Status().Update(ctx, pod)
+			Expect(err).ToNot(HaveOccurred())
+
+			clock = clocktesting.NewFakePassiveClock(pod.CreationTimestamp.Time)
+
+			pullReconciler = &TerminalReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+				Clock:  clock,
+			}
+		})
+
+		AfterAll(func() {
+			err := k8sClient.Delete(ctx, pod)
+			Expect(client.IgnoreNotFound(err)).ToNot(HaveOccurred())
+
+			err = k8sClient.Delete(ctx, pullTerminal)
+			Expect(client.IgnoreNotFound(err)).ToNot(HaveOccurred())
+
+			_, err = pullReconciler.Reconcile(ctx, req)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should requeue until the pull times out", func() {
+			clock.SetTime(pod.CreationTimestamp.Add(2 * time.Minute))
+
+			result, err := pullReconciler.Reconcile(ctx, req)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(3 * time.Minute))
+
+			err = k8sClient.Get(ctx, req.NamespacedName, pullTerminal)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(meta.IsStatusConditionTrue(pullTerminal.Status.Conditions, marinacorev1.TerminalConditionFailed)).To(BeFalse())
+		})
+
+		It("should fail the terminal once the pull times out", func() {
+			clock.SetTime(pod.CreationTimestamp.Add(6 * time.Minute))
+
+			_, err := pullReconciler.Reconcile(ctx, req)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = k8sClient.Get(ctx, req.NamespacedName, pullTerminal)
+			Expect(err).ToNot(HaveOccurred())
+
+			condition := meta.FindStatusCondition(pullTerminal.Status.Conditions, marinacorev1.TerminalConditionFailed)
+			Expect(condition).ToNot(BeNil())
+			Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+			Expect(condition.Reason).To(Equal("ImagePullBackOff"))
+		})
+
+		It("should delete the terminal when configured to", func() {
+			pullTerminal.Spec.ImagePullBackOffPolicy.Action = marinacorev1.ImagePullBackOffDelete
+			err := k8sClient.Update(ctx, pullTerminal)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = pullReconciler.Reconcile(ctx, req)
+			Expect(err).ToNot(HaveOccurred())
+
+			// the terminal's finalizers are removed on the following reconcile
+			_, err = pullReconciler.Reconcile(ctx, req)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = k8sClient.Get(ctx, req.NamespacedName, &marinacorev1.Terminal{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+
+	When("a terminal with a shutdown webhook is deleted", func() {
+		var shutdownTerminal *marinacorev1.Terminal
+		var server *httptest.Server
+		var payloads chan TerminalShutdownPayload
+		var status int
+		var clock *clocktesting.FakePassiveClock
+		var shutdownReconciler *TerminalReconciler
+		var req ctrl.Request
+
+		BeforeEach(func() {
+			payloads = make(chan TerminalShutdownPayload, 10)
+			status = http.StatusOK
+
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				defer GinkgoRecover()
+
+				payload := TerminalShutdownPayload{}
+				Expect(json.NewDecoder(r.Body).Decode(&payload)).To(Succeed())
+				payloads <- payload
+
+				w.WriteHeader(status)
+			}))
+
+			shutdownTerminal = &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-shutdown-terminal",
+					Namespace: namespace.Name,
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image:              "busybox: 1.36.0",
+					ShutdownWebhookURL: server.URL,
+				},
+			}
+
+			req = ctrl.Request{NamespacedName: client.ObjectKeyFromObject(shutdownTerminal)}
+
+			clock = clocktesting.NewFakePassiveClock(time.Now())
+
+			shutdownReconciler = &TerminalReconciler{
+				Client:                 k8sClient,
+				Scheme:                 k8sClient.Scheme(),
+				Clock:                  clock,
+				ShutdownWebhookTimeout: time.Minute,
+				ShutdownWebhookHosts:   []string{"127.0.0.1"},
+			}
+
+			createAndReconcileTerminal(ctx, shutdownReconciler, shutdownTerminal)
+
+			err := k8sClient.Delete(ctx, shutdownTerminal)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("should notify the webhook with the final status", func() {
+			_, err := shutdownReconciler.Reconcile(ctx, req)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(payloads).To(HaveLen(1))
+
+			payload := <-payloads
+			Expect(payload.Name).To(Equal(shutdownTerminal.Name))
+			Expect(payload.Namespace).To(Equal(shutdownTerminal.Namespace))
+			Expect(payload.Status.ServiceName).To(Equal("marina-terminal-" + shutdownTerminal.Name))
+
+			err = k8sClient.Get(ctx, req.NamespacedName, &marinacorev1.Terminal{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("should not notify the webhook in a dry run", func() {
+			shutdownReconciler.DryRun = true
+
+			_, err := shutdownReconciler.Reconcile(ctx, req)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(payloads).To(BeEmpty())
+		})
+
+		It("should retry a failing webhook until it times out", func() {
+			status = http.StatusInternalServerError
+
+			_, err := shutdownReconciler.Reconcile(ctx, req)
+			Expect(err).To(HaveOccurred())
+
+			err = k8sClient.Get(ctx, req.NamespacedName, &marinacorev1.Terminal{})
+			Expect(err).ToNot(HaveOccurred())
+
+			clock.SetTime(clock.Now().Add(2 * time.Minute))
+
+			_, err = shutdownReconciler.Reconcile(ctx, req)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(payloads).To(HaveLen(2))
+
+			err = k8sClient.Get(ctx, req.NamespacedName, &marinacorev1.Terminal{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+
+	When("a terminal specifies a shutdown webhook on another host", func() {
+		It("should only send the shutdown webhook to allowed hosts", func() {
+			shutdownReconciler := &TerminalReconciler{
+				ShutdownWebhookURL:   "https://hooks.example.com/default",
+				ShutdownWebhookHosts: []string{"hooks.example.com"},
+			}
+
+			terminal := &marinacorev1.Terminal{Spec: marinacorev1.TerminalSpec{
+				Image:              "busybox: 1.36.0",
+				ShutdownWebhookURL: "http://169.254.169.254/latest/meta-data",
+			}}
+
+			Expect(shutdownReconciler.validateShutdownWebhook(terminal)).To(MatchError(ContainSubstring("not an http(s) url on an allowed host")))
+			Expect(shutdownReconciler.shutdownWebhookURL(terminal)).To(Equal("https://hooks.example.com/default"))
+
+			terminal.Spec.ShutdownWebhookURL = "https://hooks.example.com/terminals"
+			Expect(shutdownReconciler.validateShutdownWebhook(terminal)).To(Succeed())
+			Expect(shutdownReconciler.shutdownWebhookURL(terminal)).To(Equal("https://hooks.example.com/terminals"))
+		})
+	})
+
+	When("a terminal with a preview image is created", func() {
+		var previewTerminal *marinacorev1.Terminal
+		var req ctrl.Request
+
+		BeforeAll(func() {
+			previewTerminal = &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-preview-terminal",
+					Namespace: namespace.Name,
+					Labels:    map[string]string{"team": "fellowship"},
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image:        "busybox: 1.36.0",
+					PreviewImage: "busybox: 1.37.0",
+				},
+			}
+
+			req = ctrl.Request{NamespacedName: client.ObjectKeyFromObject(previewTerminal)}
+
+			createAndReconcileTerminal(ctx, reconciler, previewTerminal)
+		})
+
+		AfterAll(func() {
+			deleteAndReconcileTerminal(ctx, reconciler, previewTerminal)
+		})
+
+		It("should create both the stable and preview deployments", func() {
+			stable := appsv1.Deployment{}
+			err := k8sClient.Get(ctx, types.NamespacedName{
+				Name:      "marina-terminal-" + previewTerminal.Name,
+				Namespace: previewTerminal.Namespace,
+			}, &stable)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stable.Spec.Template.Spec.Containers[0].Image).To(Equal("busybox: 1.36.0"))
+
+			preview := appsv1.Deployment{}
+			err = k8sClient.Get(ctx, types.NamespacedName{
+				Name:      "marina-terminal-" + previewTerminal.Name + "-preview",
+				Namespace: previewTerminal.Namespace,
+			}, &preview)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(preview.Spec.Template.Spec.Containers[0].Image).To(Equal("busybox: 1.37.0"))
+			Expect(*preview.Spec.Replicas).To(Equal(int32(1)))
+			Expect(preview.Labels).To(HaveKeyWithValue("team", "fellowship"))
+			Expect(preview.Labels).ToNot(HaveKey(TerminalNameLabel))
+			Expect(preview.Labels).To(HaveKeyWithValue(TerminalPreviewLabel, previewTerminal.Name))
+			Expect(preview.Spec.Template.Labels).ToNot(HaveKey(TerminalNameLabel))
+			Expect(preview.Spec.Template.Labels).To(HaveKeyWithValue(TerminalPreviewLabel, previewTerminal.Name))
+		})
+
+		It("should create both the stable and preview services", func() {
+			stable := corev1.Service{}
+			err := k8sClient.Get(ctx, types.NamespacedName{
+				Name:      "marina-terminal-" + previewTerminal.Name,
+				Namespace: previewTerminal.Namespace,
+			}, &stable)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stable.Spec.Selector).To(HaveKeyWithValue(TerminalNameLabel, previewTerminal.Name))
+
+			preview := corev1.Service{}
+			err = k8sClient.Get(ctx, types.NamespacedName{
+				Name:      "marina-terminal-" + previewTerminal.Name + "-preview",
+				Namespace: previewTerminal.Namespace,
+			}, &preview)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(preview.Spec.Selector).To(HaveKeyWithValue(TerminalPreviewLabel, previewTerminal.Name))
+		})
+
+		It("should update the preview when the preview image changes", func() {
+			err := k8sClient.Get(ctx, req.NamespacedName, previewTerminal)
+			Expect(err).ToNot(HaveOccurred())
+
+			previewTerminal.Spec.PreviewImage = "busybox: 1.38.0"
+			err = k8sClient.Update(ctx, previewTerminal)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = reconciler.Reconcile(ctx, req)
+			Expect(err).ToNot(HaveOccurred())
+
+			preview := appsv1.Deployment{}
+			err = k8sClient.Get(ctx, types.NamespacedName{
+				Name:      "marina-terminal-" + previewTerminal.Name + "-preview",
+				Namespace: previewTerminal.Namespace,
+			}, &preview)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(preview.Spec.Template.Spec.Containers[0].Image).To(Equal("busybox: 1.38.0"))
+
+			stable := getTerminalDeployment(ctx, previewTerminal)
+			Expect(stable.Spec.Template.Spec.Containers[0].Image).To(Equal("busybox: 1.36.0"))
+		})
+
+		It("should remove the preview when the preview image is unset", func() {
+			err := k8sClient.Get(ctx, req.NamespacedName, previewTerminal)
+			Expect(err).ToNot(HaveOccurred())
+
+			previewTerminal.Spec.PreviewImage = ""
+			err = k8sClient.Update(ctx, previewTerminal)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = reconciler.Reconcile(ctx, req)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = k8sClient.Get(ctx, types.NamespacedName{
+				Name:      "marina-terminal-" + previewTerminal.Name + "-preview",
+				Namespace: previewTerminal.Namespace,
+			}, &appsv1.Deployment{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+
+			err = k8sClient.Get(ctx, types.NamespacedName{
+				Name:      "marina-terminal-" + previewTerminal.Name + "-preview",
+				Namespace: previewTerminal.Namespace,
+			}, &corev1.Service{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+
+	When("a terminal reconciler has an event recorder", func() {
+		var recorder *record.FakeRecorder
+		var eventReconciler *TerminalReconciler
+
+		BeforeAll(func() {
+			recorder = record.NewFakeRecorder(16)
+			eventReconciler = &TerminalReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: recorder,
+			}
+		})
+
+		It("should record events for created children", func() {
+			eventTerminal := &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-events",
+					Namespace: namespace.Name,
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image: "busybox: 1.36.0",
+				},
+			}
+
+			createAndReconcileTerminal(ctx, eventReconciler, eventTerminal)
+
+			Expect(recorder.Events).To(Receive(Equal("Normal Created created deployment marina-terminal-" + eventTerminal.Name)))
+			Expect(recorder.Events).To(Receive(Equal("Normal Created created service marina-terminal-" + eventTerminal.Name)))
+			Expect(recorder.Events).To(Receive(Equal("Normal Created created config map marina-terminal-" + eventTerminal.Name)))
+
+			deleteAndReconcileTerminal(ctx, eventReconciler, eventTerminal)
+		})
+
+		It("should record a warning for an invalid terminal", func() {
+			invalidTerminal := &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-events-invalid",
+					Namespace: namespace.Name,
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image:        "busybox: 1.36.0",
+					Capabilities: []corev1.Capability{"SYS_ADMIN"},
+				},
+			}
+
+			req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(invalidTerminal)}
+
+			err := k8sClient.Create(ctx, invalidTerminal)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = eventReconciler.Reconcile(ctx, req)
+			Expect(err).To(HaveOccurred())
+
+			Expect(recorder.Events).To(Receive(HavePrefix("Warning InvalidTerminal")))
+
+			err = k8sClient.Delete(ctx, invalidTerminal)
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
+	When("a terminal with an idle timeout is created", func() {
+		var idleTerminal *marinacorev1.Terminal
+		var clock *clocktesting.FakePassiveClock
+		var idleReconciler *TerminalReconciler
+		var req ctrl.Request
+
+		BeforeAll(func() {
+			idleTerminal = &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-idle-terminal",
+					Namespace: namespace.Name,
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image:       "busybox: 1.36.0",
+					IdleTimeout: &metav1.Duration{Duration: time.Hour},
+				},
+			}
+
+			req = ctrl.Request{NamespacedName: client.ObjectKeyFromObject(idleTerminal)}
+
+			clock = clocktesting.NewFakePassiveClock(time.Now().Truncate(time.Second))
+
+			idleReconciler = &TerminalReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+				Clock:  clock,
+			}
+
+			err := k8sClient.Create(ctx, idleTerminal)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		AfterAll(func() {
+			err := k8sClient.Delete(ctx, idleTerminal)
+			Expect(client.IgnoreNotFound(err)).ToNot(HaveOccurred())
+
+			_, err = idleReconciler.Reconcile(ctx, req)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should requeue until the terminal is idle", func() {
+			idleTerminal.Status.LastActivityTime = &metav1.Time{Time: clock.Now().Add(-15 * time.Minute)}
+			err := k8sClient.Status().Update(ctx, idleTerminal)
+			Expect(err).ToNot(HaveOccurred())
+
+			result, err := idleReconciler.Reconcile(ctx, req)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(45 * time.Minute))
+
+			err = k8sClient.Get(ctx, req.NamespacedName, idleTerminal)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(idleTerminal.GetDeletionTimestamp()).To(BeNil())
+		})
+
+		It("should delete the terminal once idle", func() {
+			idleTerminal.Status.LastActivityTime = &metav1.Time{Time: clock.Now().Add(-2 * time.Hour)}
+			err := k8sClient.Status().Update(ctx, idleTerminal)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = idleReconciler.Reconcile(ctx, req)
+			Expect(err).ToNot(HaveOccurred())
+
+			// the terminal's finalizers are removed on the following reconcile
+			_, err = idleReconciler.Reconcile(ctx, req)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = k8sClient.Get(ctx, req.NamespacedName, &marinacorev1.Terminal{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+
+	When("a terminal with a ttl is created", func() {
+		var ttlTerminal *marinacorev1.Terminal
+		var clock *clocktesting.FakePassiveClock
+		var ttlReconciler *TerminalReconciler
+		var req ctrl.Request
+
+		BeforeAll(func() {
+			ttlTerminal = &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-ttl-terminal",
+					Namespace: namespace.Name,
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image: "busybox: 1.36.0",
+					TTL:   &metav1.Duration{Duration: 10 * time.Minute},
+				},
+			}
+
+			req = ctrl.Request{NamespacedName: client.ObjectKeyFromObject(ttlTerminal)}
+
+			err := k8sClient.Create(ctx, ttlTerminal)
+			Expect(err).ToNot(HaveOccurred())
+
+			clock = clocktesting.NewFakePassiveClock(ttlTerminal.CreationTimestamp.Time)
+
+			ttlReconciler = &TerminalReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+				Clock:  clock,
+			}
+		})
+
+		AfterAll(func() {
+			err := k8sClient.Delete(ctx, ttlTerminal)
+			Expect(client.IgnoreNotFound(err)).ToNot(HaveOccurred())
+
+			_, err = ttlReconciler.Reconcile(ctx, req)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should requeue until the ttl expires", func() {
+			clock.SetTime(ttlTerminal.CreationTimestamp.Add(4 * time.Minute))
+
+			result, err := ttlReconciler.Reconcile(ctx, req)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(6 * time.Minute))
+
+			err = k8sClient.Get(ctx, req.NamespacedName, &marinacorev1.Terminal{})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should delete the terminal once the ttl expires", func() {
+			clock.SetTime(ttlTerminal.CreationTimestamp.Add(10 * time.Minute))
+
+			_, err := ttlReconciler.Reconcile(ctx, req)
+			Expect(err).ToNot(HaveOccurred())
+
+			// the terminal's finalizers are removed on the following reconcile
+			_, err = ttlReconciler.Reconcile(ctx, req)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = k8sClient.Get(ctx, req.NamespacedName, &marinacorev1.Terminal{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+
+	When("a labeled terminal is created", func() {
+		var labeledTerminal *marinacorev1.Terminal
+		var req ctrl.Request
+
+		BeforeAll(func() {
+			labeledTerminal = &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-labeled-terminal",
+					Namespace: namespace.Name,
+					Labels: map[string]string{
+						"team": "foo",
+						"app":  "not-marina",
+					},
+					Annotations: map[string]string{
+						"cost-center": "1234",
+					},
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image: "busybox: 1.36.0",
+				},
+			}
+
+			req = ctrl.Request{NamespacedName: client.ObjectKeyFromObject(labeledTerminal)}
+
+			createAndReconcileTerminal(ctx, reconciler, labeledTerminal)
+		})
+
+		AfterAll(func() {
+			deleteAndReconcileTerminal(ctx, reconciler, labeledTerminal)
+		})
+
+		It("should propagate the terminal metadata to the deployment and its pods", func() {
+			deployment := getTerminalDeployment(ctx, labeledTerminal)
+
+			Expect(deployment.Labels).To(HaveKeyWithValue("team", "foo"))
+			Expect(deployment.Labels).To(HaveKeyWithValue("app", "marina-terminal"))
+			Expect(deployment.Annotations).To(HaveKeyWithValue("cost-center", "1234"))
+			Expect(deployment.Spec.Template.Labels).To(HaveKeyWithValue("team", "foo"))
+			Expect(deployment.Spec.Template.Labels).To(HaveKeyWithValue("app", "marina-terminal"))
+		})
+
+		It("should propagate the terminal metadata to the service", func() {
+			service := corev1.Service{}
+			err := k8sClient.Get(ctx, types.NamespacedName{
+				Name:      "marina-terminal-" + labeledTerminal.Name,
+				Namespace: labeledTerminal.Namespace,
+			}, &service)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(service.Labels).To(HaveKeyWithValue("team", "foo"))
+			Expect(service.Annotations).To(HaveKeyWithValue("cost-center", "1234"))
+			Expect(service.Spec.Selector).To(HaveKeyWithValue("app", "marina-terminal"))
+		})
+
+		It("should propagate labels added to an existing terminal", func() {
+			err := k8sClient.Get(ctx, req.NamespacedName, labeledTerminal)
+			Expect(err).ToNot(HaveOccurred())
+
+			labeledTerminal.Labels["env"] = "dev"
+			err = k8sClient.Update(ctx, labeledTerminal)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = reconciler.Reconcile(ctx, req)
+			Expect(err).ToNot(HaveOccurred())
+
+			deployment := getTerminalDeployment(ctx, labeledTerminal)
+			Expect(deployment.Labels).To(HaveKeyWithValue("env", "dev"))
+
+			service := corev1.Service{}
+			err = k8sClient.Get(ctx, types.NamespacedName{
+				Name:      "marina-terminal-" + labeledTerminal.Name,
+				Namespace: labeledTerminal.Namespace,
+			}, &service)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(service.Labels).To(HaveKeyWithValue("env", "dev"))
+		})
+	})
+
+	When("a terminal which runs as non-root is created", func() {
+		var nonRootTerminal *marinacorev1.Terminal
+
+		BeforeAll(func() {
+			nonRootTerminal = &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-non-root-terminal",
+					Namespace: namespace.Name,
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image:        "busybox: 1.36.0",
+					RunAsNonRoot: true,
+					SecurityContext: &corev1.SecurityContext{
+						ReadOnlyRootFilesystem: ToPtr(true),
+					},
+					PodSecurityContext: &corev1.PodSecurityContext{
+						RunAsUser: ToPtr[int64](1000),
+					},
+				},
+			}
+
+			createAndReconcileTerminal(ctx, reconciler, nonRootTerminal)
+		})
+
+		AfterAll(func() {
+			deleteAndReconcileTerminal(ctx, reconciler, nonRootTerminal)
+		})
+
+		It("should populate the container and pod security contexts", func() {
+			deployment := getTerminalDeployment(ctx, nonRootTerminal)
+
+			securityContext := deployment.Spec.Template.Spec.Containers[0].SecurityContext
+			Expect(securityContext).ToNot(BeNil())
+			Expect(securityContext.RunAsNonRoot).To(Equal(ToPtr(true)))
+			Expect(securityContext.AllowPrivilegeEscalation).To(Equal(ToPtr(false)))
+			Expect(securityContext.ReadOnlyRootFilesystem).To(Equal(ToPtr(true)))
+			Expect(securityContext.Capabilities.Drop).To(Equal([]corev1.Capability{"ALL"}))
+
+			podSecurityContext := deployment.Spec.Template.Spec.SecurityContext
+			Expect(podSecurityContext).ToNot(BeNil())
+			Expect(podSecurityContext.RunAsUser).To(Equal(ToPtr[int64](1000)))
+			Expect(podSecurityContext.RunAsNonRoot).To(Equal(ToPtr(true)))
+			Expect(podSecurityContext.SeccompProfile.Type).To(Equal(corev1.SeccompProfileTypeRuntimeDefault))
+		})
+	})
+
+	When("a terminal with a service account is created", func() {
+		var user *marinacorev1.User
+		var accountTerminal *marinacorev1.Terminal
+		var userTerminal *marinacorev1.Terminal
+		var accountReconciler *TerminalReconciler
+
+		BeforeAll(func() {
+			user = &marinacorev1.User{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-service-account-user",
+					Namespace: namespace.Name,
+				},
+				Spec: marinacorev1.UserSpec{
+					Name:     "dori",
+					Password: []byte("dwalin"),
+				},
+			}
+
+			err := k8sClient.Create(ctx, user)
+			Expect(err).ToNot(HaveOccurred())
+
+			user.Status.UID = 10043
+			err = k8sClient.Status().Update(ctx, user)
+			Expect(err).ToNot(HaveOccurred())
+
+			accountTerminal = &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-service-account-terminal",
+					Namespace: namespace.Name,
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image:              "busybox: 1.36.0",
+					User:               user.Name,
+					ServiceAccountName: "scoped",
+				},
+			}
+
+			userTerminal = &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-user-service-account-terminal",
+					Namespace: namespace.Name,
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image: "busybox: 1.36.0",
+					User:  user.Name,
+				},
+			}
+
+			accountReconciler = &TerminalReconciler{
+				Client:                 k8sClient,
+				Scheme:                 k8sClient.Scheme(),
+				UseUserServiceAccount:  true,
+				AllowedServiceAccounts: []string{"scoped"},
+			}
+
+			for _, terminal := range []*marinacorev1.Terminal{accountTerminal, userTerminal} {
+				createAndReconcileTerminal(ctx, accountReconciler, terminal)
+			}
+		})
+
+		AfterAll(func() {
+			for _, terminal := range []*marinacorev1.Terminal{accountTerminal, userTerminal} {
+				deleteAndReconcileTerminal(ctx, accountReconciler, terminal)
+			}
+
+			err := k8sClient.Delete(ctx, user)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should run the terminal as its service account", func() {
+			deployment := getTerminalDeployment(ctx, accountTerminal)
+			Expect(deployment.Spec.Template.Spec.ServiceAccountName).To(Equal("scoped"))
+		})
+
+		It("should default to the user's service account", func() {
+			deployment := getTerminalDeployment(ctx, userTerminal)
+			Expect(deployment.Spec.Template.Spec.ServiceAccountName).To(Equal(serviceAccountForUser(user).Name))
+		})
+
+		It("should reject a service account which is not allowed", func() {
+			privilegedTerminal := &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-disallowed-service-account-terminal",
+					Namespace: namespace.Name,
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image:              "busybox: 1.36.0",
+					ServiceAccountName: "cluster-admin",
+				},
+			}
+
+			err := k8sClient.Create(ctx, privilegedTerminal)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = accountReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(privilegedTerminal)})
+			Expect(err).To(MatchError(ContainSubstring("service account 'cluster-admin' is not allowed")))
+
+			err = k8sClient.Delete(ctx, privilegedTerminal)
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
+	When("a terminal with probe overrides is created", func() {
+		var probeTerminal *marinacorev1.Terminal
+
+		BeforeAll(func() {
+			probeTerminal = &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-probe-terminal",
+					Namespace: namespace.Name,
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image: "busybox: 1.36.0",
+					Port:  2222,
+					Probe: &marinacorev1.TerminalProbe{
+						InitialDelaySeconds: 5,
+						PeriodSeconds:       30,
+					},
+				},
+			}
+
+			createAndReconcileTerminal(ctx, reconciler, probeTerminal)
+		})
+
+		AfterAll(func() {
+			deleteAndReconcileTerminal(ctx, reconciler, probeTerminal)
+		})
+
+		It("should probe the ssh port", func() {
+			deployment := getTerminalDeployment(ctx, probeTerminal)
+
+			container := deployment.Spec.Template.Spec.Containers[0]
+			for _, probe := range []*corev1.Probe{container.ReadinessProbe, container.LivenessProbe} {
+				Expect(probe).ToNot(BeNil())
+				Expect(probe.TCPSocket).ToNot(BeNil())
+				Expect(probe.TCPSocket.Port.IntVal).To(Equal(int32(2222)))
+				Expect(probe.InitialDelaySeconds).To(Equal(int32(5)))
+				Expect(probe.PeriodSeconds).To(Equal(int32(30)))
+			}
+		})
+	})
+
+	When("a terminal with init containers is created", func() {
+		It("should add the init containers to the deployment", func() {
+			initTerminal := &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-init-terminal",
+					Namespace: namespace.Name,
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image: "busybox: 1.36.0",
+					InitContainers: []corev1.Container{
+						{
+							Name:    "dotfiles",
+							Image:   "alpine/git:2.43.0",
+							Command: []string{"git", "clone", "https://example.com/dotfiles.git", "/root/dotfiles"},
+						},
+					},
+				},
+			}
+
+			createAndReconcileTerminal(ctx, reconciler, initTerminal)
+
+			deployment := getTerminalDeployment(ctx, initTerminal)
+
+			initContainers := deployment.Spec.Template.Spec.InitContainers
+			Expect(initContainers).To(HaveLen(1))
+			Expect(initContainers[0].Name).To(Equal("dotfiles"))
+
+			deleteAndReconcileTerminal(ctx, reconciler, initTerminal)
+		})
+
+		It("should reject init containers named after the terminal container", func() {
+			collidingTerminal := &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-colliding-init-terminal",
+					Namespace: namespace.Name,
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image: "busybox: 1.36.0",
+					InitContainers: []corev1.Container{
+						{
+							Name:  TerminalContainerName,
+							Image: "busybox: 1.36.0",
+						},
+					},
+				},
+			}
+
+			err := k8sClient.Create(ctx, collidingTerminal)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(collidingTerminal)})
+			Expect(err).To(MatchError(ContainSubstring("container name 'exec-shell' is used more than once")))
+
+			err = k8sClient.Delete(ctx, collidingTerminal)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should reject privileged init containers", func() {
+			privileged := true
+			privilegedTerminal := &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-privileged-init-terminal",
+					Namespace: namespace.Name,
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image: "busybox: 1.36.0",
+					InitContainers: []corev1.Container{
+						{
+							Name:            "setup",
+							Image:           "busybox: 1.36.0",
+							SecurityContext: &corev1.SecurityContext{Privileged: &privileged},
+						},
+					},
+				},
+			}
+
+			err := k8sClient.Create(ctx, privilegedTerminal)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(privilegedTerminal)})
+			Expect(err).To(MatchError(ContainSubstring("container 'setup': privileged containers are not allowed")))
+
+			err = k8sClient.Delete(ctx, privilegedTerminal)
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
+	When("a terminal with sidecars is created", func() {
+		It("should add the sidecars after the terminal container", func() {
+			sidecarTerminal := &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-sidecar-terminal",
+					Namespace: namespace.Name,
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image: "busybox: 1.36.0",
+					Sidecars: []corev1.Container{
+						{
+							Name:  "log-shipper",
+							Image: "fluent/fluent-bit:3.0",
+						},
+						{
+							Name:  "session-recorder",
+							Image: "busybox: 1.36.0",
+						},
+					},
+				},
+			}
+
+			createAndReconcileTerminal(ctx, reconciler, sidecarTerminal)
+
+			deployment := getTerminalDeployment(ctx, sidecarTerminal)
+
+			containers := deployment.Spec.Template.Spec.Containers
+			Expect(containers).To(HaveLen(3))
+			Expect(containers[0].Name).To(Equal(TerminalContainerName))
+			Expect(containers[1].Name).To(Equal("log-shipper"))
+			Expect(containers[2].Name).To(Equal("session-recorder"))
+
+			deleteAndReconcileTerminal(ctx, reconciler, sidecarTerminal)
+		})
+
+		It("should reject sidecars adding disallowed capabilities", func() {
+			capableTerminal := &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-capable-sidecar-terminal",
+					Namespace: namespace.Name,
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image: "busybox: 1.36.0",
+					Sidecars: []corev1.Container{
+						{
+							Name:  "debugger",
+							Image: "busybox: 1.36.0",
+							SecurityContext: &corev1.SecurityContext{
+								Capabilities: &corev1.Capabilities{Add: []corev1.Capability{"SYS_PTRACE"}},
+							},
+						},
+					},
+				},
+			}
+
+			err := k8sClient.Create(ctx, capableTerminal)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(capableTerminal)})
+			Expect(err).To(MatchError(ContainSubstring("container 'debugger': capability 'SYS_PTRACE' is not allowed")))
+
+			err = k8sClient.Delete(ctx, capableTerminal)
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
+	When("a terminal with an ingress is created", func() {
+		var ingressTerminal *marinacorev1.Terminal
+		var req ctrl.Request
+
+		BeforeAll(func() {
+			ingressTerminal = &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-ingress-terminal",
+					Namespace: namespace.Name,
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image: "busybox: 1.36.0",
+					Ingress: &marinacorev1.TerminalIngress{
+						Host:             "terminal.example.com",
+						IngressClassName: ToPtr("nginx"),
+					},
+				},
+			}
+
+			req = ctrl.Request{NamespacedName: client.ObjectKeyFromObject(ingressTerminal)}
+
+			createAndReconcileTerminal(ctx, reconciler, ingressTerminal)
+		})
+
+		It("should route the ingress to the terminal service", func() {
+			ingress := networkingv1.Ingress{}
+			err := k8sClient.Get(ctx, types.NamespacedName{
+				Name:      "marina-terminal-" + ingressTerminal.Name,
+				Namespace: ingressTerminal.Namespace,
+			}, &ingress)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(ingress.Spec.IngressClassName).To(Equal(ToPtr("nginx")))
+			Expect(ingress.Spec.Rules).To(HaveLen(1))
+			Expect(ingress.Spec.Rules[0].Host).To(Equal("terminal.example.com"))
+
+			path := ingress.Spec.Rules[0].HTTP.Paths[0]
+			Expect(path.Path).To(Equal("/"))
+			Expect(path.Backend.Service.Name).To(Equal("marina-terminal-" + ingressTerminal.Name))
+			Expect(path.Backend.Service.Port.Name).To(Equal("ssh"))
+		})
+
+		It("should delete the ingress with the terminal", func() {
+			deleteAndReconcileTerminal(ctx, reconciler, ingressTerminal)
+
+			err := k8sClient.Get(ctx, types.NamespacedName{
+				Name:      "marina-terminal-" + ingressTerminal.Name,
+				Namespace: ingressTerminal.Namespace,
+			}, &networkingv1.Ingress{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+
+			err = k8sClient.Get(ctx, req.NamespacedName, &marinacorev1.Terminal{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+
+	When("a terminal with a network policy is created", func() {
+		var isolatedTerminal *marinacorev1.Terminal
+
+		BeforeAll(func() {
+			isolatedTerminal = &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-isolated-terminal",
+					Namespace: namespace.Name,
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image: "busybox: 1.36.0",
+					NetworkPolicy: &marinacorev1.TerminalNetworkPolicy{
+						Egress: []networkingv1.NetworkPolicyEgressRule{
+							{
+								To: []networkingv1.NetworkPolicyPeer{
+									{IPBlock: &networkingv1.IPBlock{CIDR: "0.0.0.0/0", Except: []string{"10.0.0.0/8"}}},
+								},
+							},
+						},
+					},
+				},
+			}
+
+			createAndReconcileTerminal(ctx, reconciler, isolatedTerminal)
+		})
+
+		It("should isolate the terminal pods", func() {
+			policy := networkingv1.NetworkPolicy{}
+			err := k8sClient.Get(ctx, types.NamespacedName{
+				Name:      "marina-terminal-" + isolatedTerminal.Name,
+				Namespace: isolatedTerminal.Namespace,
+			}, &policy)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(policy.Spec.PodSelector.MatchLabels).To(Equal(selectorLabelsForTerminal(isolatedTerminal)))
+			Expect(policy.Spec.PolicyTypes).To(ConsistOf(networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress))
+			Expect(policy.Spec.Ingress).To(HaveLen(1))
+			Expect(policy.Spec.Ingress[0].Ports[0].Port.IntVal).To(Equal(int32(22)))
+			Expect(policy.Spec.Egress).To(Equal(isolatedTerminal.Spec.NetworkPolicy.Egress))
+		})
+
+		It("should delete the network policy with the terminal", func() {
+			deleteAndReconcileTerminal(ctx, reconciler, isolatedTerminal)
+
+			err := k8sClient.Get(ctx, types.NamespacedName{
+				Name:      "marina-terminal-" + isolatedTerminal.Name,
+				Namespace: isolatedTerminal.Namespace,
+			}, &networkingv1.NetworkPolicy{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+
+	When("a terminal's deployment is not yet available", Ordered, func() {
+		var pendingTerminal *marinacorev1.Terminal
+		var availabilityReconciler *TerminalReconciler
+		var req ctrl.Request
+
+		BeforeAll(func() {
+			availabilityReconciler = &TerminalReconciler{
+				Client:                      k8sClient,
+				Scheme:                      k8sClient.Scheme(),
+				AvailabilityRequeueInterval: 10 * time.Second,
+			}
+
+			pendingTerminal = &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-pending-terminal",
+					Namespace: namespace.Name,
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image: "busybox: 1.36.0",
+				},
+			}
+
+			req = ctrl.Request{NamespacedName: client.ObjectKeyFromObject(pendingTerminal)}
+
+			err := k8sClient.Create(ctx, pendingTerminal)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		AfterAll(func() {
+			deleteAndReconcileTerminal(ctx, availabilityReconciler, pendingTerminal)
+		})
+
+		It("should requeue until the deployment is available", func() {
+			result, err := availabilityReconciler.Reconcile(ctx, req)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(10 * time.Second))
+
+			err = k8sClient.Get(ctx, req.NamespacedName, pendingTerminal)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(meta.IsStatusConditionFalse(pendingTerminal.Status.Conditions, marinacorev1.TerminalConditionAvailable)).To(BeTrue())
+		})
+
+		It("should stop requeueing once the deployment is available", func() {
+			deployment := getTerminalDeployment(ctx, pendingTerminal)
+
+			deployment.Status.Replicas = 1
+			deployment.Status.AvailableReplicas = 1
+			err := k8sClient.Status().Update(ctx, deployment)
+			Expect(err).ToNot(HaveOccurred())
+
+			result, err := availabilityReconciler.Reconcile(ctx, req)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.RequeueAfter).To(BeZero())
+
+			err = k8sClient.Get(ctx, req.NamespacedName, pendingTerminal)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(meta.IsStatusConditionTrue(pendingTerminal.Status.Conditions, marinacorev1.TerminalConditionAvailable)).To(BeTrue())
+		})
+	})
+
+	When("a terminal cannot be fetched", func() {
+		It("should return the error so the terminal is requeued", func() {
+			fetchErr := errors.NewServiceUnavailable("etcd is unavailable")
+			failingReconciler := &TerminalReconciler{
+				Client: failingGetClient{Client: k8sClient, err: fetchErr},
+				Scheme: k8sClient.Scheme(),
+			}
+
+			_, err := failingReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{
+				Name:      "test-unreachable-terminal",
+				Namespace: namespace.Name,
+			}})
+			Expect(err).To(MatchError(fetchErr))
+		})
+
+		It("should not return an error for a terminal which no longer exists", func() {
+			_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{
+				Name:      "test-missing-terminal",
+				Namespace: namespace.Name,
+			}})
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
+	When("a terminal's service has drifted", Ordered, func() {
+		var driftedTerminal *marinacorev1.Terminal
+		var req ctrl.Request
+		var serviceKey types.NamespacedName
+
+		BeforeAll(func() {
+			driftedTerminal = &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-drifted-terminal",
+					Namespace: namespace.Name,
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image: "busybox: 1.36.0",
+				},
+			}
+
+			req = ctrl.Request{NamespacedName: client.ObjectKeyFromObject(driftedTerminal)}
+			serviceKey = types.NamespacedName{
+				Name:      "marina-terminal-" + driftedTerminal.Name,
+				Namespace: driftedTerminal.Namespace,
+			}
+
+			createAndReconcileTerminal(ctx, reconciler, driftedTerminal)
+		})
+
+		AfterAll(func() {
+			deleteAndReconcileTerminal(ctx, reconciler, driftedTerminal)
+		})
+
+		It("should update the service when the terminal port and service type change", func() {
+			service := corev1.Service{}
+			err := k8sClient.Get(ctx, serviceKey, &service)
+			Expect(err).ToNot(HaveOccurred())
+			clusterIP := service.Spec.ClusterIP
+
+			err = k8sClient.Get(ctx, req.NamespacedName, driftedTerminal)
+			Expect(err).ToNot(HaveOccurred())
+
+			driftedTerminal.Spec.Port = 2222
+			driftedTerminal.Spec.ServiceType = corev1.ServiceTypeNodePort
+			err = k8sClient.Update(ctx, driftedTerminal)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = reconciler.Reconcile(ctx, req)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = k8sClient.Get(ctx, serviceKey, &service)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(service.Spec.Type).To(Equal(corev1.ServiceTypeNodePort))
+			Expect(service.Spec.Ports[0].TargetPort.IntVal).To(Equal(int32(2222)))
+			Expect(service.Spec.ClusterIP).To(Equal(clusterIP))
+		})
+
+		It("should restore a service which was edited by hand", func() {
+			service := corev1.Service{}
+			err := k8sClient.Get(ctx, serviceKey, &service)
+			Expect(err).ToNot(HaveOccurred())
+
+			service.Spec.Ports[0].TargetPort = intstr.FromInt32(8022)
+			err = k8sClient.Update(ctx, &service)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = reconciler.Reconcile(ctx, req)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = k8sClient.Get(ctx, serviceKey, &service)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(service.Spec.Ports[0].TargetPort.IntVal).To(Equal(int32(2222)))
+		})
+	})
+
+	When("terminal children are created in another namespace", Ordered, func() {
+		var redirectedTerminal *marinacorev1.Terminal
+		var childNamespace *corev1.Namespace
+		var redirectReconciler *TerminalReconciler
+		var req ctrl.Request
+		var childKey types.NamespacedName
+
+		BeforeAll(func() {
+			childNamespace = &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "marina-terminals",
+				},
+			}
+
+			err := k8sClient.Create(ctx, childNamespace)
+			if !errors.IsAlreadyExists(err) {
+				Expect(err).ToNot(HaveOccurred())
+			}
+
+			redirectReconciler = &TerminalReconciler{
+				Client:         k8sClient,
+				Scheme:         k8sClient.Scheme(),
+				ChildNamespace: childNamespace.Name,
+			}
+
+			redirectedTerminal = &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-redirected-terminal",
+					Namespace: namespace.Name,
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image: "busybox: 1.36.0",
+				},
+			}
+
+			req = ctrl.Request{NamespacedName: client.ObjectKeyFromObject(redirectedTerminal)}
+			childKey = types.NamespacedName{
+				Name:      "marina-terminal-" + namespace.Name + "-" + redirectedTerminal.Name,
+				Namespace: childNamespace.Name,
+			}
+
+			createAndReconcileTerminal(ctx, redirectReconciler, redirectedTerminal)
+		})
+
+		It("should record the child namespace on the terminal", func() {
+			err := k8sClient.Get(ctx, req.NamespacedName, redirectedTerminal)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(redirectedTerminal.Status.ChildNamespace).To(Equal(childNamespace.Name))
+		})
+
+		It("should create the children in the child namespace", func() {
+			deployment := appsv1.Deployment{}
+			err := k8sClient.Get(ctx, childKey, &deployment)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deployment.OwnerReferences).To(BeEmpty())
+			Expect(deployment.Annotations).ToNot(HaveKey(marinacorev1.TerminalChildNamespaceAnnotation))
+			Expect(deployment.Spec.Selector.MatchLabels).To(HaveKeyWithValue(TerminalNamespaceLabel, namespace.Name))
+
+			service := corev1.Service{}
+			err = k8sClient.Get(ctx, childKey, &service)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(service.OwnerReferences).To(BeEmpty())
+
+			Expect(redirectReconciler.terminalForChild(ctx, &service)).To(ConsistOf(req))
+		})
+
+		It("should delete the children with the terminal", func() {
+			deleteAndReconcileTerminal(ctx, redirectReconciler, redirectedTerminal)
+
+			err := k8sClient.Get(ctx, childKey, &appsv1.Deployment{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+
+			err = k8sClient.Get(ctx, childKey, &corev1.Service{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+
+	When("the child namespace is the terminal's own namespace", Ordered, func() {
+		var localTerminal *marinacorev1.Terminal
+		var localReconciler *TerminalReconciler
+		var req ctrl.Request
+
+		BeforeAll(func() {
+			localReconciler = &TerminalReconciler{
+				Client:         k8sClient,
+				Scheme:         k8sClient.Scheme(),
+				ChildNamespace: namespace.Name,
+			}
+
+			localTerminal = &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-local-terminal",
+					Namespace: namespace.Name,
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image: "busybox: 1.36.0",
+				},
+			}
+
+			req = ctrl.Request{NamespacedName: client.ObjectKeyFromObject(localTerminal)}
+
+			createAndReconcileTerminal(ctx, localReconciler, localTerminal)
+		})
+
+		AfterAll(func() {
+			deleteAndReconcileTerminal(ctx, localReconciler, localTerminal)
+		})
+
+		It("should create owned children alongside the terminal", func() {
+			err := k8sClient.Get(ctx, req.NamespacedName, localTerminal)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(localTerminal.Status.ChildNamespace).To(BeEmpty())
+
+			deployment := getTerminalDeployment(ctx, localTerminal)
+			Expect(deployment.OwnerReferences).To(HaveLen(1))
+			Expect(deployment.Spec.Selector.MatchLabels).ToNot(HaveKey(TerminalNamespaceLabel))
+		})
+	})
+
+	When("a terminal requests its children be created in another namespace", Ordered, func() {
+		var forgedTerminal *marinacorev1.Terminal
+		var forgedReconciler *TerminalReconciler
+		var req ctrl.Request
+
+		BeforeAll(func() {
+			forgedReconciler = &TerminalReconciler{
+				Client:         k8sClient,
+				Scheme:         k8sClient.Scheme(),
+				ChildNamespace: "marina-terminals",
+			}
+
+			forgedTerminal = &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-forged-terminal",
+					Namespace: namespace.Name,
+					Annotations: map[string]string{
+						marinacorev1.TerminalChildNamespaceAnnotation: "default",
+					},
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image: "busybox: 1.36.0",
+				},
+			}
+
+			req = ctrl.Request{NamespacedName: client.ObjectKeyFromObject(forgedTerminal)}
+
+			createAndReconcileTerminal(ctx, forgedReconciler, forgedTerminal)
+		})
+
+		AfterAll(func() {
+			deleteAndReconcileTerminal(ctx, forgedReconciler, forgedTerminal)
+		})
+
+		It("should ignore the annotation and use the operator's child namespace", func() {
+			err := k8sClient.Get(ctx, req.NamespacedName, forgedTerminal)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(forgedTerminal.Status.ChildNamespace).To(Equal("marina-terminals"))
+
+			err = k8sClient.Get(ctx, types.NamespacedName{
+				Name:      "marina-terminal-" + namespace.Name + "-" + forgedTerminal.Name,
+				Namespace: "default",
+			}, &appsv1.Deployment{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+
+			err = k8sClient.Get(ctx, types.NamespacedName{
+				Name:      "marina-terminal-" + namespace.Name + "-" + forgedTerminal.Name,
+				Namespace: "marina-terminals",
+			}, &appsv1.Deployment{})
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
+	When("the operator finalizes deleted terminals on shutdown", Ordered, func() {
+		var deletedTerminal *marinacorev1.Terminal
+		var liveTerminal *marinacorev1.Terminal
+
+		BeforeAll(func() {
+			deletedTerminal = &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-deleted-terminal",
+					Namespace: namespace.Name,
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image: "busybox: 1.36.0",
+				},
+			}
+
+			liveTerminal = &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-live-terminal",
+					Namespace: namespace.Name,
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image: "busybox: 1.36.0",
+				},
+			}
+
+			for _, terminal := range []*marinacorev1.Terminal{deletedTerminal, liveTerminal} {
+				createAndReconcileTerminal(ctx, reconciler, terminal)
+			}
+
+			err := k8sClient.Delete(ctx, deletedTerminal)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		AfterAll(func() {
+			deleteAndReconcileTerminal(ctx, reconciler, liveTerminal)
+		})
+
+		It("should only finalize terminals which are being deleted", func() {
+			err := reconciler.FinalizeDeleted(ctx)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(deletedTerminal), &marinacorev1.Terminal{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(liveTerminal), &marinacorev1.Terminal{})
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
+	When("a terminal is reconciled in a dry run", func() {
+		It("should not persist any changes", func() {
+			dryRunTerminal := &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-dry-run-terminal",
+					Namespace: namespace.Name,
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image: "busybox: 1.36.0",
+				},
+			}
+
+			err := k8sClient.Create(ctx, dryRunTerminal)
+			Expect(err).ToNot(HaveOccurred())
+
+			dryRunReconciler := &TerminalReconciler{
+				Client: NewDryRunClient(k8sClient),
+				Scheme: k8sClient.Scheme(),
+			}
+
+			_, err = dryRunReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(dryRunTerminal)})
+			Expect(err).ToNot(HaveOccurred())
+
+			err = k8sClient.Get(ctx, types.NamespacedName{Name: "marina-terminal-test-dry-run-terminal", Namespace: namespace.Name}, &appsv1.Deployment{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+
+			err = k8sClient.Get(ctx, types.NamespacedName{Name: "marina-terminal-test-dry-run-terminal", Namespace: namespace.Name}, &corev1.Service{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(dryRunTerminal), dryRunTerminal)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(dryRunTerminal.Finalizers).To(BeEmpty())
+
+			err = k8sClient.Delete(ctx, dryRunTerminal)
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
+	When("terminals with and without an image pull policy are created", Ordered, func() {
+		var pullReconciler *TerminalReconciler
+		var pinnedTerminal *marinacorev1.Terminal
+		var defaultedTerminal *marinacorev1.Terminal
+
+		BeforeAll(func() {
+			pullReconciler = &TerminalReconciler{
+				Client:                 k8sClient,
+				Scheme:                 k8sClient.Scheme(),
+				DefaultImagePullPolicy: corev1.PullNever,
+			}
+
+			pinnedTerminal = &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-pinned-pull-terminal",
+					Namespace: namespace.Name,
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image:           "busybox: 1.36.0",
+					ImagePullPolicy: corev1.PullAlways,
+				},
+			}
+
+			defaultedTerminal = &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-defaulted-pull-terminal",
+					Namespace: namespace.Name,
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image: "busybox: 1.36.0",
+				},
+			}
+
+			for _, terminal := range []*marinacorev1.Terminal{pinnedTerminal, defaultedTerminal} {
+				createAndReconcileTerminal(ctx, pullReconciler, terminal)
+			}
+		})
+
+		AfterAll(func() {
+			for _, terminal := range []*marinacorev1.Terminal{pinnedTerminal, defaultedTerminal} {
+				deleteAndReconcileTerminal(ctx, pullReconciler, terminal)
+			}
+		})
+
+		It("should use the terminal's pull policy", func() {
+			deployment := getTerminalDeployment(ctx, pinnedTerminal)
+			Expect(deployment.Spec.Template.Spec.Containers[0].ImagePullPolicy).To(Equal(corev1.PullAlways))
+		})
+
+		It("should fall back to the default pull policy", func() {
+			deployment := getTerminalDeployment(ctx, defaultedTerminal)
+			Expect(deployment.Spec.Template.Spec.Containers[0].ImagePullPolicy).To(Equal(corev1.PullNever))
+		})
+	})
+
+	When("a terminal runs sshd", func() {
+		It("should only sleep by default", func() {
+			terminal := &marinacorev1.Terminal{Spec: marinacorev1.TerminalSpec{Image: "busybox: 1.36.0"}}
+
+			Expect(containerForTerminal(terminal).Command).To(Equal([]string{"/bin/sh", "-ec", "trap : TERM INT; sleep infinity & wait"}))
+		})
+
+		It("should start sshd on the terminal's port before sleeping", func() {
+			terminal := &marinacorev1.Terminal{Spec: marinacorev1.TerminalSpec{
+				Image:   "busybox: 1.36.0",
+				Port:    2222,
+				RunSshd: true,
+			}}
+
+			command := containerForTerminal(terminal).Command
+			Expect(command).To(HaveLen(3))
+			Expect(command[2]).To(ContainSubstring("/usr/sbin/sshd -p 2222; trap : TERM INT; sleep infinity & wait"))
+		})
+
+		It("should only probe the ssh port when something listens on it", func() {
+			terminal := &marinacorev1.Terminal{Spec: marinacorev1.TerminalSpec{Image: "busybox: 1.36.0", Port: 2222}}
+			Expect(probeForTerminal(terminal)).To(BeNil())
+
+			terminal.Spec.RunSshd = true
+			probe := probeForTerminal(terminal)
+			Expect(probe).ToNot(BeNil())
+			Expect(probe.TCPSocket.Port.IntVal).To(Equal(int32(2222)))
+
+			terminal.Spec.Probe = &marinacorev1.TerminalProbe{Disabled: true}
+			Expect(probeForTerminal(terminal)).To(BeNil())
+		})
+	})
+
+	When("a terminal with a termination grace period is created", Ordered, func() {
+		var graceTerminal *marinacorev1.Terminal
+
+		BeforeAll(func() {
+			gracePeriod := int64(120)
+
+			graceTerminal = &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-grace-terminal",
+					Namespace: namespace.Name,
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image:                         "busybox: 1.36.0",
+					TerminationGracePeriodSeconds: &gracePeriod,
+				},
+			}
+
+			createAndReconcileTerminal(ctx, reconciler, graceTerminal)
+		})
+
+		AfterAll(func() {
+			deleteAndReconcileTerminal(ctx, reconciler, graceTerminal)
+		})
+
+		It("should set the grace period on the pod", func() {
+			deployment := getTerminalDeployment(ctx, graceTerminal)
+			Expect(deployment.Spec.Template.Spec.TerminationGracePeriodSeconds).To(HaveValue(BeEquivalentTo(120)))
+		})
+
+		It("should use the terminal's restart policy when it runs to completion", func() {
+			terminal := graceTerminal.DeepCopy()
+			terminal.Spec.RunToCompletion = true
+
+			Expect(jobForTerminal(terminal).Spec.Template.Spec.RestartPolicy).To(Equal(corev1.RestartPolicyNever))
+
+			terminal.Spec.RestartPolicy = corev1.RestartPolicyOnFailure
+			Expect(jobForTerminal(terminal).Spec.Template.Spec.RestartPolicy).To(Equal(corev1.RestartPolicyOnFailure))
+		})
+	})
+
+	When("a terminal switches between running to completion and not", Ordered, func() {
+		var switchTerminal *marinacorev1.Terminal
+		var req ctrl.Request
+		var childKey types.NamespacedName
+
+		BeforeAll(func() {
+			switchTerminal = &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-switch-terminal",
+					Namespace: namespace.Name,
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image: "busybox: 1.36.0",
+				},
+			}
+
+			req = ctrl.Request{NamespacedName: client.ObjectKeyFromObject(switchTerminal)}
+
+			childKey = types.NamespacedName{
+				Name:      "marina-terminal-" + switchTerminal.Name,
+				Namespace: switchTerminal.Namespace,
+			}
+
+			createAndReconcileTerminal(ctx, reconciler, switchTerminal)
+		})
+
+		AfterAll(func() {
+			deleteAndReconcileTerminal(ctx, reconciler, switchTerminal)
+
+			err := k8sClient.Get(ctx, req.NamespacedName, &marinacorev1.Terminal{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("should replace the deployment with a job", func() {
+			err := k8sClient.Get(ctx, req.NamespacedName, switchTerminal)
+			Expect(err).ToNot(HaveOccurred())
+
+			switchTerminal.Spec.RunToCompletion = true
+			err = k8sClient.Update(ctx, switchTerminal)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = reconciler.Reconcile(ctx, req)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = k8sClient.Get(ctx, childKey, &appsv1.Deployment{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+
+			err = k8sClient.Get(ctx, childKey, &batchv1.Job{})
+			Expect(err).ToNot(HaveOccurred())
+
+			err = k8sClient.Get(ctx, req.NamespacedName, switchTerminal)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(switchTerminal.Finalizers).ToNot(ContainElement(TerminalDeploymentFinalizer))
+			Expect(switchTerminal.Finalizers).To(ContainElement(TerminalJobFinalizer))
+		})
+
+		It("should replace the job with a deployment", func() {
+			switchTerminal.Spec.RunToCompletion = false
+			err := k8sClient.Update(ctx, switchTerminal)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = reconciler.Reconcile(ctx, req)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = k8sClient.Get(ctx, childKey, &batchv1.Job{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+
+			getTerminalDeployment(ctx, switchTerminal)
+
+			err = k8sClient.Get(ctx, req.NamespacedName, switchTerminal)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(switchTerminal.Finalizers).ToNot(ContainElement(TerminalJobFinalizer))
+			Expect(switchTerminal.Finalizers).To(ContainElement(TerminalDeploymentFinalizer))
+		})
+	})
+
+	When("a paused terminal is created", Ordered, func() {
+		var pausedTerminal *marinacorev1.Terminal
+		var req ctrl.Request
+
+		BeforeAll(func() {
+			pausedTerminal = &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-paused-terminal",
+					Namespace: namespace.Name,
+					Annotations: map[string]string{
+						marinacorev1.TerminalPausedAnnotation: "true",
+					},
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image: "busybox: 1.36.0",
+				},
+			}
+
+			req = ctrl.Request{NamespacedName: client.ObjectKeyFromObject(pausedTerminal)}
+
+			createAndReconcileTerminal(ctx, reconciler, pausedTerminal)
+		})
+
+		AfterAll(func() {
+			deleteAndReconcileTerminal(ctx, reconciler, pausedTerminal)
+		})
+
+		It("should leave the terminal untouched", func() {
+			err := k8sClient.Get(ctx, types.NamespacedName{Name: "marina-terminal-" + pausedTerminal.Name, Namespace: namespace.Name}, &appsv1.Deployment{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+
+			err = k8sClient.Get(ctx, types.NamespacedName{Name: "marina-terminal-" + pausedTerminal.Name, Namespace: namespace.Name}, &corev1.Service{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+
+			err = k8sClient.Get(ctx, req.NamespacedName, pausedTerminal)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pausedTerminal.Finalizers).To(BeEmpty())
+			Expect(meta.IsStatusConditionTrue(pausedTerminal.Status.Conditions, marinacorev1.TerminalConditionPaused)).To(BeTrue())
+		})
+
+		It("should reconcile the terminal once it is unpaused", func() {
+			delete(pausedTerminal.Annotations, marinacorev1.TerminalPausedAnnotation)
+			err := k8sClient.Update(ctx, pausedTerminal)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = reconciler.Reconcile(ctx, req)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = k8sClient.Get(ctx, types.NamespacedName{Name: "marina-terminal-" + pausedTerminal.Name, Namespace: namespace.Name}, &appsv1.Deployment{})
+			Expect(err).ToNot(HaveOccurred())
+
+			err = k8sClient.Get(ctx, req.NamespacedName, pausedTerminal)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(meta.FindStatusCondition(pausedTerminal.Status.Conditions, marinacorev1.TerminalConditionPaused)).To(BeNil())
+		})
+	})
+
+	When("a terminal's connection info is published", Ordered, func() {
+		var connectionTerminal *marinacorev1.Terminal
+		var req ctrl.Request
+		var childKey types.NamespacedName
+
+		BeforeAll(func() {
+			connectionTerminal = &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-connection-terminal",
+					Namespace: namespace.Name,
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image: "busybox: 1.36.0",
+				},
+			}
+
+			req = ctrl.Request{NamespacedName: client.ObjectKeyFromObject(connectionTerminal)}
+			childKey = types.NamespacedName{Name: "marina-terminal-" + connectionTerminal.Name, Namespace: namespace.Name}
+
+			createAndReconcileTerminal(ctx, reconciler, connectionTerminal)
+		})
+
+		It("should describe the terminal's service", func() {
+			service := corev1.Service{}
+			err := k8sClient.Get(ctx, childKey, &service)
+			Expect(err).ToNot(HaveOccurred())
+
+			configMap := corev1.ConfigMap{}
+			err = k8sClient.Get(ctx, childKey, &configMap)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(configMap.Data).To(Equal(map[string]string{
+				TerminalConnectionHostKey:  service.Name + "." + service.Namespace + ".svc",
+				TerminalConnectionPortKey:  "22",
+				TerminalConnectionReadyKey: "false",
+			}))
+			Expect(configMap.OwnerReferences).To(ContainElement(HaveField("Name", connectionTerminal.Name)))
+		})
+
+		It("should be ready once the deployment is available", func() {
+			deployment := appsv1.Deployment{}
+			err := k8sClient.Get(ctx, childKey, &deployment)
+			Expect(err).ToNot(HaveOccurred())
+
+			deployment.Status.AvailableReplicas = 1
+			err = k8sClient.Status().Update(ctx, &deployment)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = reconciler.Reconcile(ctx, req)
+			Expect(err).ToNot(HaveOccurred())
+
+			configMap := corev1.ConfigMap{}
+			err = k8sClient.Get(ctx, childKey, &configMap)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(configMap.Data).To(HaveKeyWithValue(TerminalConnectionReadyKey, "true"))
+		})
+
+		It("should delete the config map with the terminal", func() {
+			deleteAndReconcileTerminal(ctx, reconciler, connectionTerminal)
+
+			err := k8sClient.Get(ctx, childKey, &corev1.ConfigMap{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+
+	When("a terminal with additional volumes is created", func() {
+		var volumeTerminal *marinacorev1.Terminal
+		var req ctrl.Request
+
+		BeforeEach(func() {
+			volumeTerminal = &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-volume-terminal",
+					Namespace: namespace.Name,
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image: "busybox: 1.36.0",
+					Volumes: []corev1.Volume{
+						{
+							Name: "scripts",
+							VolumeSource: corev1.VolumeSource{
+								ConfigMap: &corev1.ConfigMapVolumeSource{
+									LocalObjectReference: corev1.LocalObjectReference{Name: "terminal-scripts"},
+								},
+							},
+						},
+					},
+					VolumeMounts: []corev1.VolumeMount{
+						{Name: "scripts", MountPath: "/opt/scripts", ReadOnly: true},
+					},
+				},
+			}
+
+			req = ctrl.Request{NamespacedName: client.ObjectKeyFromObject(volumeTerminal)}
+		})
+
+		AfterEach(func() {
+			deleteAndReconcileTerminal(ctx, reconciler, volumeTerminal)
+		})
+
+		It("should add the volumes to the pod and mount them in the container", func() {
+			createAndReconcileTerminal(ctx, reconciler, volumeTerminal)
+
+			deployment := getTerminalDeployment(ctx, volumeTerminal)
+			Expect(deployment.Spec.Template.Spec.Volumes).To(ContainElement(volumeTerminal.Spec.Volumes[0]))
+			Expect(deployment.Spec.Template.Spec.Containers[0].VolumeMounts).To(ContainElement(volumeTerminal.Spec.VolumeMounts[0]))
+		})
+
+		It("should reject a mount which does not match any volume", func() {
+			volumeTerminal.Spec.VolumeMounts[0].Name = "dataset"
+
+			err := k8sClient.Create(ctx, volumeTerminal)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = reconciler.Reconcile(ctx, req)
+			Expect(err).To(MatchError(ContainSubstring("volume mount 'dataset' does not match any volume")))
+		})
+
+		It("should reject a volume exposing the node", func() {
+			volumeTerminal.Spec.Volumes[0].VolumeSource = corev1.VolumeSource{
+				HostPath: &corev1.HostPathVolumeSource{Path: "/"},
+			}
+
+			err := k8sClient.Create(ctx, volumeTerminal)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = reconciler.Reconcile(ctx, req)
+			Expect(err).To(MatchError(ContainSubstring("volume 'scripts' uses a source which is not allowed")))
+		})
+	})
+
+	When("a deleted terminal's finalizers cannot be removed", Ordered, func() {
+		var finalizingTerminal *marinacorev1.Terminal
+		var req ctrl.Request
+
+		finalizerErrorCount := func() float64 {
+			metric := &dto.Metric{}
+			err := finalizerErrors.WithLabelValues("terminal").(prometheus.Metric).Write(metric)
+			Expect(err).ToNot(HaveOccurred())
+
+			return metric.GetCounter().GetValue()
+		}
+
+		BeforeAll(func() {
+			finalizingTerminal = &marinacorev1.Terminal{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-finalizing-terminal",
+					Namespace: namespace.Name,
+				},
+				Spec: marinacorev1.TerminalSpec{
+					Image: "busybox: 1.36.0",
+				},
+			}
+
+			req = ctrl.Request{NamespacedName: client.ObjectKeyFromObject(finalizingTerminal)}
+
+			createAndReconcileTerminal(ctx, reconciler, finalizingTerminal)
+
+			err := k8sClient.Delete(ctx, finalizingTerminal)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should keep the finalizers of children which were cleaned up when another child fails", func() {
+			deleteErr := errors.NewServiceUnavailable("etcd is unavailable")
+			failingReconciler := &TerminalReconciler{
+				Client: failingDeleteClient{Client: k8sClient, obj: &corev1.Service{}, err: deleteErr},
+				Scheme: k8sClient.Scheme(),
+			}
+
+			_, err := failingReconciler.Reconcile(ctx, req)
+			Expect(err).To(MatchError(deleteErr))
+
+			err = k8sClient.Get(ctx, req.NamespacedName, finalizingTerminal)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(finalizingTerminal.Finalizers).ToNot(ContainElement(TerminalDeploymentFinalizer))
+			Expect(finalizingTerminal.Finalizers).To(ContainElement(TerminalServiceFinalizer))
+		})
+
+		It("should count finalizers which cannot be persisted", func() {
+			before := finalizerErrorCount()
+
+			patchErr := errors.NewServiceUnavailable("etcd is unavailable")
+			failingReconciler := &TerminalReconciler{
+				Client: failingPatchClient{Client: k8sClient, err: patchErr},
+				Scheme: k8sClient.Scheme(),
+			}
+
+			_, err := failingReconciler.Reconcile(ctx, req)
+			Expect(err).To(MatchError(patchErr))
+			Expect(finalizerErrorCount()).To(Equal(before + 1))
+
+			err = k8sClient.Get(ctx, req.NamespacedName, finalizingTerminal)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(finalizingTerminal.Finalizers).To(ContainElement(TerminalServiceFinalizer))
+		})
+
+		It("should remove the finalizers once they can be persisted", func() {
+			_, err := reconciler.Reconcile(ctx, req)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = k8sClient.Get(ctx, req.NamespacedName, &marinacorev1.Terminal{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+})
+
+// failingGetClient is a client whose Get always fails with err.
+type failingGetClient struct {
+	client.Client
+
+	err error
+}
+
+func (c failingGetClient) Get(context.Context, client.ObjectKey, client.Object, ...client.GetOption) error {
+	return c.err
+}
+
+// failingPatchClient is a client whose Patch always fails with err.
+type failingPatchClient struct {
+	client.Client
+
+	err error
+}
+
+func (c failingPatchClient) Patch(context.Context, client.Object, client.Patch, ...client.PatchOption) error {
+	return c.err
+}
+
+// failingDeleteClient is a client whose Delete fails with err for objects of the same type as obj.
+type failingDeleteClient struct {
+	client.Client
+
+	obj client.Object
+	err error
+}
+
+func (c failingDeleteClient) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	if reflect.TypeOf(obj) == reflect.TypeOf(c.obj) {
+		return c.err
+	}
+
+	return c.Client.Delete(ctx, obj, opts...)
+}
+
+// createAndReconcileTerminal creates the terminal and reconciles it once.
+func createAndReconcileTerminal(ctx context.Context, reconciler *TerminalReconciler, terminal *marinacorev1.Terminal) {
+	GinkgoHelper()
+
+	err := k8sClient.Create(ctx, terminal)
+	Expect(err).ToNot(HaveOccurred())
+
+	_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(terminal)})
+	Expect(err).ToNot(HaveOccurred())
+}
+
+// deleteAndReconcileTerminal deletes the terminal and reconciles it once, so its finalizers clean up its children.
+func deleteAndReconcileTerminal(ctx context.Context, reconciler *TerminalReconciler, terminal *marinacorev1.Terminal) {
+	GinkgoHelper()
+
+	err := k8sClient.Delete(ctx, terminal)
+	Expect(err).ToNot(HaveOccurred())
+
+	_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(terminal)})
+	Expect(err).ToNot(HaveOccurred())
+}
+
+// getTerminalDeployment fetches the deployment of a terminal whose children are in its own namespace.
+func getTerminalDeployment(ctx context.Context, terminal *marinacorev1.Terminal) *appsv1.Deployment {
+	GinkgoHelper()
+
+	deployment := &appsv1.Deployment{}
+	err := k8sClient.Get(ctx, types.NamespacedName{Name: "marina-terminal-" + terminal.Name, Namespace: terminal.Namespace}, deployment)
+	Expect(err).ToNot(HaveOccurred())
+
+	return deployment
+}
@@ -3,12 +3,22 @@ package controller
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 
+	"golang.org/x/crypto/bcrypt"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/clock"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -21,10 +31,53 @@ const (
 	UserServiceAccountFinalizer = "marina.io.serviceaccount/finalizer"
 	UserRoleBindingFinalizer    = "marina.io.rolebinding/finalizer"
 	UserSelfRoleFinalizerFormat = "marina.io.selfrole.%s/finalizer"
+	UserOIDCGroupsFinalizer     = "marina.io.oidcgroups/finalizer"
+	UserCredentialsFinalizer    = "marina.io.credentials/finalizer"
+	UserClusterRoleFinalizer    = "marina.io.clusterrolebinding/finalizer"
+	UserNamespaceFinalizer      = "marina.io.namespace/finalizer"
+	UserInlineRoleFinalizer     = "marina.io.inlinerole/finalizer"
+
+	// UserUsernameKey is the key of the credentials secret holding the user's username.
+	UserUsernameKey = "username"
+
+	// UserPasswordHashKey is the key of the credentials secret holding the bcrypt hash of the user's password.
+	UserPasswordHashKey = "hash"
+
+	// UserAuthorizedKeysKey is the key of the credentials secret holding the user's authorized ssh public keys.
+	UserAuthorizedKeysKey = "authorized_keys"
+
+	// UserNameLabel and UserNamespaceLabel identify the user a role binding belongs to.
+	UserNameLabel      = "marina.io/user"
+	UserNamespaceLabel = "marina.io/user-namespace"
+
+	// OIDCGroupsConfigMapName is the name of the ConfigMap mapping each user in a namespace to their OIDC groups.
+	OIDCGroupsConfigMapName = "marina-oidc-groups"
+
+	// FailedUserRequeueInterval is how long to wait before retrying a user which has run out of reconcile retries.
+	FailedUserRequeueInterval = 10 * time.Minute
+
+	// MinUserUID is the first UID assigned to users, chosen to avoid colliding with any system users in terminal
+	// images.
+	MinUserUID = 10000
+
+	// MaxConcurrentRoleBindings is the maximum number of role bindings reconciled at once for a single user.
+	MaxConcurrentRoleBindings = 4
+
+	// DefaultRoleBindingPageSize is the number of role bindings listed at once when pruning a user's role bindings.
+	DefaultRoleBindingPageSize = 100
 )
 
 func serviceAccountForUser(user *marinacorev1.User) *corev1.ServiceAccount {
 	return &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      user.Name,
+			Namespace: serviceAccountNamespace(user),
+		},
+	}
+}
+
+func credentialsSecretForUser(user *marinacorev1.User) *corev1.Secret {
+	return &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      user.Name,
 			Namespace: user.Namespace,
@@ -54,17 +107,37 @@ func selfRoleForUser(user *marinacorev1.User) *rbacv1.Role {
 	}
 }
 
+// roleBindingNamespace returns the namespace the binding for the given role is created in. The self role always lives
+// alongside the user, so it is always bound there.
+func roleBindingNamespace(user *marinacorev1.User, role string) string {
+	if user.Spec.GrantNamespace == "" || role == selfRoleForUser(user).Name {
+		return user.Namespace
+	}
+
+	return user.Spec.GrantNamespace
+}
+
+// labelsForUser returns the labels tying a resource to the given user. Since role bindings may be in a different
+// namespace than their user, these are used rather than owner references.
+func labelsForUser(user *marinacorev1.User) map[string]string {
+	return map[string]string{
+		UserNameLabel:      user.Name,
+		UserNamespaceLabel: user.Namespace,
+	}
+}
+
 func userRoleBindingForRole(user *marinacorev1.User, role string) *rbacv1.RoleBinding {
 	return &rbacv1.RoleBinding{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      user.Name + "-" + role,
-			Namespace: user.Namespace,
+			Namespace: roleBindingNamespace(user, role),
+			Labels:    labelsForUser(user),
 		},
 		Subjects: []rbacv1.Subject{
 			{
 				Kind:      rbacv1.ServiceAccountKind,
 				Name:      user.Name,
-				Namespace: user.Namespace,
+				Namespace: serviceAccountNamespace(user),
 			},
 		},
 		RoleRef: rbacv1.RoleRef{
@@ -75,18 +148,151 @@ func userRoleBindingForRole(user *marinacorev1.User, role string) *rbacv1.RoleBi
 	}
 }
 
+func userClusterRoleBindingForClusterRole(user *marinacorev1.User, clusterRole string) *rbacv1.ClusterRoleBinding {
+	return &rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			// cluster role bindings are not namespaced, so the user's namespace is included to avoid collisions
+			Name:   "marina-" + user.Namespace + "-" + user.Name + "-" + clusterRole,
+			Labels: labelsForUser(user),
+		},
+		Subjects: []rbacv1.Subject{
+			{
+				Kind:      rbacv1.ServiceAccountKind,
+				Name:      user.Name,
+				Namespace: serviceAccountNamespace(user),
+			},
+		},
+		RoleRef: rbacv1.RoleRef{
+			Kind:     "ClusterRole",
+			Name:     clusterRole,
+			APIGroup: "rbac.authorization.k8s.io",
+		},
+	}
+}
+
 // UserReconciler reconciles a User object
 type UserReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+
+	// APIReader reads straight from the api server rather than the cache, so long lists can be paged. Defaults to the
+	// reconciler's client when nil.
+	APIReader client.Reader
+
+	// RoleBindingPageSize is the number of role bindings listed at once when pruning a user's role bindings,
+	// defaulting to DefaultRoleBindingPageSize when 0.
+	RoleBindingPageSize int64
+
+	// MaxConcurrentReconciles is the number of workers reconciling users at once, defaulting to 1.
+	MaxConcurrentReconciles int
+
+	// CleanupTokenSecrets enables deleting manually created token secrets for a user's service account when the user
+	// is deleted.
+	CleanupTokenSecrets bool
+
+	// MaintenanceWindow pauses all mutations except to the user status while active.
+	MaintenanceWindow *MaintenanceWindow
+
+	// ExpirySuspensionWindow is how long before expiring a user is suspended.
+	ExpirySuspensionWindow time.Duration
+
+	// SharedRoleBindings grants each role through a single role binding per namespace shared by every user with the
+	// role, rather than a role binding per user.
+	SharedRoleBindings bool
+
+	// MaxReconcileRetries is how many times in a row a user may fail to reconcile before it is marked as failed and
+	// only retried every FailedUserRequeueInterval. Users are retried with backoff forever when 0.
+	MaxReconcileRetries int
+
+	// Recorder emits events for users, for example when they are suspended. No events are emitted when nil.
+	Recorder record.EventRecorder
+
+	// Clock is used to determine when users expire, defaulting to the real clock when nil.
+	Clock clock.PassiveClock
+
+	// PasswordHashCost is the bcrypt cost used to hash user passwords, defaulting to bcrypt.DefaultCost when 0.
+	PasswordHashCost int
+
+	// InlineRolesReviewed reports whether the user webhook reviews inline roles against the permissions of their
+	// requester. When false inline roles are refused, since the operator would otherwise grant any permission it holds
+	// on the requester's behalf.
+	InlineRolesReviewed bool
+
+	// KubeconfigServer is the API server URL written to user kubeconfigs. When empty no kubeconfigs are generated.
+	KubeconfigServer string
+
+	// KubeconfigCAData is the certificate authority data written to user kubeconfigs.
+	KubeconfigCAData []byte
+
+	// KubeconfigTokenTTL is how long the tokens in user kubeconfigs are valid for, defaulting to
+	// DefaultKubeconfigTokenTTL when 0.
+	KubeconfigTokenTTL time.Duration
+
+	retries retryTracker
+
+	// uidMu serializes UID assignment, so each UID is persisted before the next one is chosen.
+	uidMu sync.Mutex
 }
 
 // +kubebuilder:rbac:groups=core.marina.io,resources=users,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core.marina.io,resources=users/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=core.marina.io,resources=users/finalizers,verbs=update
 // +kubebuilder:rbac:groups=*,resources=serviceaccounts,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=*,resources=serviceaccounts/token,verbs=create
+// +kubebuilder:rbac:groups=*,resources=configmaps,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=*,resources=secrets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=*,resources=events,verbs=create;patch
 // +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=roles,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=rolebindings,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=clusterroles,verbs=get;list;watch
+// +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=clusterroles,verbs=bind,resourceNames=admin
+// +kubebuilder:rbac:groups=*,resources=namespaces,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=clusterrolebindings,verbs=get;list;watch;create;update;patch;delete
+
+// deleteTokenSecrets deletes any manually created token secrets for the given service account. Since these secrets are
+// not owned by the service account they will not be garbage collected with it.
+func (r *UserReconciler) deleteTokenSecrets(ctx context.Context, serviceAccount *corev1.ServiceAccount) error {
+	logger := log.FromContext(ctx)
+
+	secrets := &corev1.SecretList{}
+	if err := r.List(ctx, secrets, client.InNamespace(serviceAccount.Namespace)); err != nil {
+		return fmt.Errorf("could not list secrets: %w", err)
+	}
+
+	for _, secret := range secrets.Items {
+		if secret.Type != corev1.SecretTypeServiceAccountToken || secret.Annotations[corev1.ServiceAccountNameKey] != serviceAccount.Name {
+			continue
+		}
+
+		if err := r.Delete(ctx, &secret); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("could not delete token secret: %w", err)
+		}
+
+		logger.Info("deleted service account token secret", "secret", client.ObjectKeyFromObject(&secret))
+	}
+
+	return nil
+}
+
+// recordEvent records an event for the user, doing nothing when the reconciler has no recorder.
+func (r *UserReconciler) recordEvent(user *marinacorev1.User, eventType string, reason string, messageFmt string, args ...any) {
+	if r.Recorder == nil {
+		return
+	}
+
+	r.Recorder.Eventf(user, eventType, reason, messageFmt, args...)
+}
+
+// setUserOwner makes the user the controller of the given child, so the child is garbage collected with the user even
+// if the operator is down when the user is deleted. Owner references may not cross namespaces, so children in other
+// namespaces are only cleaned up by the user's finalizers.
+func (r *UserReconciler) setUserOwner(user *marinacorev1.User, child client.Object) error {
+	if child.GetNamespace() != user.Namespace {
+		return nil
+	}
+
+	return controllerutil.SetControllerReference(user, child, r.Scheme)
+}
 
 func (r *UserReconciler) reconcileServiceAccount(ctx context.Context, user *marinacorev1.User) error {
 	logger := log.FromContext(ctx)
@@ -94,59 +300,378 @@ func (r *UserReconciler) reconcileServiceAccount(ctx context.Context, user *mari
 
 	if user.GetDeletionTimestamp() != nil {
 		if controllerutil.ContainsFinalizer(user, UserServiceAccountFinalizer) {
-			if err := r.Delete(ctx, serviceAccount); err != nil {
+			// the service account may have already been garbage collected through its owner reference
+			if err := r.Delete(ctx, serviceAccount); client.IgnoreNotFound(err) != nil {
 				logger.Error(err, "could not delete service account", "serviceaccount", client.ObjectKeyFromObject(serviceAccount))
 				return err
 			}
 
+			if r.CleanupTokenSecrets {
+				if err := r.deleteTokenSecrets(ctx, serviceAccount); err != nil {
+					logger.Error(err, "could not delete service account token secrets", "serviceaccount", client.ObjectKeyFromObject(serviceAccount))
+					return err
+				}
+			}
+
 			controllerutil.RemoveFinalizer(user, UserServiceAccountFinalizer)
 		}
 
 		return nil
 	}
 
-	_ = controllerutil.AddFinalizer(user, UserServiceAccountFinalizer)
+	// the first time the service account finalizer is added is when the user is first reconciled
+	if controllerutil.AddFinalizer(user, UserServiceAccountFinalizer) {
+		usersTotal.Inc()
+	}
+
+	result, err := controllerutil.CreateOrUpdate(ctx, r.Client, serviceAccount, func() error {
+		return r.setUserOwner(user, serviceAccount)
+	})
+	if err != nil {
+		return fmt.Errorf("could not reconcile service account: %w", err)
+	}
+
+	if result == controllerutil.OperationResultCreated {
+		logger.Info("created service account", "serviceaccount", client.ObjectKeyFromObject(serviceAccount))
+		r.recordEvent(user, corev1.EventTypeNormal, "Created", "created service account %s", serviceAccount.Name)
+	}
+
+	return nil
+}
+
+// reconcileCredentialsSecret keeps the user's credentials secret, which terminals mount to authenticate the user,
+// up to date. A hash of the user's password is stored in the secret and the plaintext password is cleared from the
+// user.
+func (r *UserReconciler) reconcileCredentialsSecret(ctx context.Context, user *marinacorev1.User) error {
+	logger := log.FromContext(ctx)
+	secret := credentialsSecretForUser(user)
+
+	if user.GetDeletionTimestamp() != nil {
+		if controllerutil.ContainsFinalizer(user, UserCredentialsFinalizer) {
+			if err := r.Delete(ctx, secret); client.IgnoreNotFound(err) != nil {
+				logger.Error(err, "could not delete credentials secret", "secret", client.ObjectKeyFromObject(secret))
+				return err
+			}
+
+			controllerutil.RemoveFinalizer(user, UserCredentialsFinalizer)
+		}
+
+		return nil
+	}
+
+	_ = controllerutil.AddFinalizer(user, UserCredentialsFinalizer)
+
+	var hash []byte
+	if len(user.Spec.Password) > 0 {
+		cost := r.PasswordHashCost
+		if cost == 0 {
+			cost = bcrypt.DefaultCost
+		}
+
+		var err error
+		if hash, err = bcrypt.GenerateFromPassword(user.Spec.Password, cost); err != nil {
+			return fmt.Errorf("could not hash password: %w", err)
+		}
+	}
+
+	result, err := controllerutil.CreateOrUpdate(ctx, r.Client, secret, func() error {
+		if secret.Data == nil {
+			secret.Data = make(map[string][]byte)
+		}
+
+		secret.Data[UserUsernameKey] = []byte(user.Username())
+
+		// the existing hash is kept once the plaintext password has been cleared
+		if hash != nil {
+			secret.Data[UserPasswordHashKey] = hash
+		}
+
+		if len(user.Spec.AuthorizedKeys) > 0 {
+			secret.Data[UserAuthorizedKeysKey] = []byte(strings.Join(user.Spec.AuthorizedKeys, "\n") + "\n")
+		} else {
+			delete(secret.Data, UserAuthorizedKeysKey)
+		}
+
+		return controllerutil.SetControllerReference(user, secret, r.Scheme)
+	})
+	if err != nil {
+		return fmt.Errorf("could not store credentials: %w", err)
+	}
+
+	if result != controllerutil.OperationResultNone {
+		logger.Info("updated credentials secret for user", "secret", client.ObjectKeyFromObject(secret))
+	}
+
+	if hash == nil {
+		return nil
+	}
+
+	return r.clearPassword(ctx, user)
+}
+
+// clearPassword persists the removal of the user's plaintext password and records when it was rotated, as soon as its
+// hash is stored, so the password is not left on the user when a later step fails. The patches are applied to a copy
+// so the changes not yet written to the user are kept.
+func (r *UserReconciler) clearPassword(ctx context.Context, user *marinacorev1.User) error {
+	cleared := user.DeepCopy()
+
+	original := cleared.DeepCopy()
+	cleared.Spec.Password = nil
+
+	if err := r.Patch(ctx, cleared, client.MergeFrom(original)); err != nil {
+		return fmt.Errorf("could not clear password: %w", err)
+	}
+
+	original = cleared.DeepCopy()
+	cleared.Status.PasswordRotatedAt = &metav1.Time{Time: r.now()}
+
+	if err := r.Status().Patch(ctx, cleared, client.MergeFrom(original)); err != nil {
+		return fmt.Errorf("could not record password rotation: %w", err)
+	}
+
+	user.Spec.Password = nil
+	user.Status.PasswordRotatedAt = cleared.Status.PasswordRotatedAt
+	user.ResourceVersion = cleared.ResourceVersion
+
+	return nil
+}
+
+// ensureRoleExists returns an error if the given role does not exist in the given namespace.
+func (r *UserReconciler) ensureRoleExists(ctx context.Context, user *marinacorev1.User, role string, namespace string) error {
+	if err := r.Get(ctx, types.NamespacedName{Name: role, Namespace: namespace}, &rbacv1.Role{}); err != nil {
+		if errors.IsNotFound(err) {
+			r.recordEvent(user, corev1.EventTypeWarning, "RoleNotFound", "role '%s' does not exist in namespace '%s'", role, namespace)
+			return fmt.Errorf("role '%s' does not exist in namespace '%s'", role, namespace)
+		}
+
+		return fmt.Errorf("could not fetch role '%s': %w", role, err)
+	}
+
+	return nil
+}
+
+func (r *UserReconciler) reconcileRoleBinding(ctx context.Context, user *marinacorev1.User, role string, suspended bool) error {
+	if r.SharedRoleBindings {
+		return r.reconcileSharedRoleBinding(ctx, user, role, suspended)
+	}
+
+	logger := log.FromContext(ctx)
+	binding := userRoleBindingForRole(user, role)
+
+	if user.GetDeletionTimestamp() != nil {
+		// the binding may have already been revoked by a suspension
+		if err := r.Delete(ctx, binding); client.IgnoreNotFound(err) != nil {
+			logger.Error(err, "error deleting role binding", "rolebinding", client.ObjectKeyFromObject(binding))
+			return err
+		}
+
+		logger.Info("deleted role binding", "rolebinding", client.ObjectKeyFromObject(binding))
+		r.recordEvent(user, corev1.EventTypeNormal, "Deleted", "deleted role binding %s", binding.Name)
+
+		return nil
+	}
+
+	// suspended users keep their roles in their spec, so the bindings are restored if the suspension is lifted
+	if suspended {
+		if err := r.Delete(ctx, binding); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+
+		logger.Info("revoked role binding", "rolebinding", client.ObjectKeyFromObject(binding))
+		r.recordEvent(user, corev1.EventTypeNormal, "Deleted", "revoked role binding %s", binding.Name)
 
-	if err := r.Create(ctx, serviceAccount); err != nil {
-		return client.IgnoreAlreadyExists(err)
+		return nil
 	}
 
-	logger.Info("created service account", "serviceaccount", client.ObjectKeyFromObject(serviceAccount))
+	if err := r.ensureRoleExists(ctx, user, role, binding.Namespace); err != nil {
+		return err
+	}
+
+	result, err := controllerutil.CreateOrUpdate(ctx, r.Client, binding, func() error {
+		return r.setUserOwner(user, binding)
+	})
+	if err != nil {
+		return fmt.Errorf("could not reconcile role binding: %w", err)
+	}
+
+	if result == controllerutil.OperationResultCreated {
+		logger.Info("created role binding", "rolebinding", client.ObjectKeyFromObject(binding))
+		r.recordEvent(user, corev1.EventTypeNormal, "Created", "created role binding %s", binding.Name)
+	}
 
 	return nil
 }
 
-func (r *UserReconciler) reconcileRoleBindings(ctx context.Context, user *marinacorev1.User) error {
+// pruneRoleBindings deletes the user's role bindings for roles which are no longer in the user's spec. All of the
+// user's own role bindings are deleted when shared role bindings are enabled.
+func (r *UserReconciler) pruneRoleBindings(ctx context.Context, user *marinacorev1.User) error {
 	logger := log.FromContext(ctx)
+
+	desired := make(map[types.NamespacedName]bool, len(user.Spec.Roles))
+	if !r.SharedRoleBindings && user.GetDeletionTimestamp() == nil {
+		for _, role := range user.Spec.Roles {
+			desired[client.ObjectKeyFromObject(userRoleBindingForRole(user, role))] = true
+		}
+	}
+
+	pageSize := r.RoleBindingPageSize
+	if pageSize == 0 {
+		pageSize = DefaultRoleBindingPageSize
+	}
+
+	// the cache cannot continue a list, so users with many role bindings are paged through the api server instead
+	reader := r.APIReader
+	if reader == nil {
+		reader = r.Client
+	}
+
+	continueToken := ""
+
+	for {
+		bindings := &rbacv1.RoleBindingList{}
+		if err := reader.List(ctx, bindings, client.MatchingLabels(labelsForUser(user)), client.Limit(pageSize), client.Continue(continueToken)); err != nil {
+			return fmt.Errorf("could not list role bindings: %w", err)
+		}
+
+		for _, binding := range bindings.Items {
+			if desired[client.ObjectKeyFromObject(&binding)] {
+				continue
+			}
+
+			if err := r.Delete(ctx, &binding); client.IgnoreNotFound(err) != nil {
+				return fmt.Errorf("could not delete obsolete role binding: %w", err)
+			}
+
+			logger.Info("deleted obsolete role binding", "rolebinding", client.ObjectKeyFromObject(&binding))
+			r.recordEvent(user, corev1.EventTypeNormal, "Deleted", "deleted obsolete role binding %s", binding.Name)
+		}
+
+		if continueToken = bindings.Continue; continueToken == "" {
+			return nil
+		}
+	}
+}
+
+func (r *UserReconciler) reconcileRoleBindings(ctx context.Context, user *marinacorev1.User, suspended bool) error {
 	isDeleting := user.GetDeletionTimestamp() != nil
 
+	if isDeleting && !controllerutil.ContainsFinalizer(user, UserRoleBindingFinalizer) {
+		return nil
+	}
+
 	if !isDeleting {
 		_ = controllerutil.AddFinalizer(user, UserRoleBindingFinalizer)
 	}
 
-	for _, role := range user.Spec.Roles {
-		binding := userRoleBindingForRole(user, role)
+	// bindings are independent of each other, so we reconcile them concurrently with a bounded number of workers
+	errs := make([]error, len(user.Spec.Roles))
+	sem := make(chan struct{}, MaxConcurrentRoleBindings)
+	wg := sync.WaitGroup{}
 
-		if isDeleting {
-			if controllerutil.ContainsFinalizer(user, UserRoleBindingFinalizer) {
-				if err := r.Delete(ctx, binding); err != nil {
-					logger.Error(err, "error deleting role binding", "rolebinding", client.ObjectKeyFromObject(binding))
-					return err
+	for i, role := range user.Spec.Roles {
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			errs[i] = r.reconcileRoleBinding(ctx, user, role, suspended)
+		}()
+	}
+
+	wg.Wait()
+
+	if err := utilerrors.NewAggregate(errs); err != nil {
+		return err
+	}
+
+	if err := r.pruneRoleBindings(ctx, user); err != nil {
+		return err
+	}
+
+	if err := r.pruneSharedRoleBindings(ctx, user); err != nil {
+		return err
+	}
+
+	if isDeleting {
+		_ = controllerutil.RemoveFinalizer(user, UserRoleBindingFinalizer)
+	}
+
+	return nil
+}
+
+// reconcileClusterRoleBindings binds the user's cluster roles, deleting the bindings for any cluster roles removed from
+// the user. Suspended users have all of their cluster role bindings revoked. The api server only lets the operator
+// bind cluster roles whose permissions it already holds or which it may bind, and it is only granted bind on the
+// admin role it binds in provisioned namespaces.
+func (r *UserReconciler) reconcileClusterRoleBindings(ctx context.Context, user *marinacorev1.User, suspended bool) error {
+	logger := log.FromContext(ctx)
+	isDeleting := user.GetDeletionTimestamp() != nil
+
+	if isDeleting && !controllerutil.ContainsFinalizer(user, UserClusterRoleFinalizer) {
+		return nil
+	}
+
+	// avoid listing cluster role bindings for users who never had any cluster roles
+	if len(user.Spec.ClusterRoles) == 0 && !controllerutil.ContainsFinalizer(user, UserClusterRoleFinalizer) {
+		return nil
+	}
+
+	desired := make(map[string]bool, len(user.Spec.ClusterRoles))
+
+	if !isDeleting {
+		_ = controllerutil.AddFinalizer(user, UserClusterRoleFinalizer)
+
+		for _, clusterRole := range user.Spec.ClusterRoles {
+			// suspended users keep their cluster roles in their spec, so the bindings are restored if the suspension is
+			// lifted
+			if suspended {
+				continue
+			}
+
+			binding := userClusterRoleBindingForClusterRole(user, clusterRole)
+			desired[binding.Name] = true
+
+			if err := r.Get(ctx, types.NamespacedName{Name: clusterRole}, &rbacv1.ClusterRole{}); err != nil {
+				if errors.IsNotFound(err) {
+					return fmt.Errorf("cluster role '%s' does not exist", clusterRole)
 				}
 
-				logger.Info("deleted role binding", "rolebinding", client.ObjectKeyFromObject(binding))
+				return fmt.Errorf("could not fetch cluster role '%s': %w", clusterRole, err)
 			}
-		} else {
-			// assumed roles are validated before we reach this point
+
 			if err := r.Create(ctx, binding); err != nil {
-				return client.IgnoreAlreadyExists(err)
+				if !errors.IsAlreadyExists(err) {
+					return fmt.Errorf("could not create cluster role binding: %w", err)
+				}
+
+				continue
 			}
-			logger.Info("created role binding", "rolebinding", client.ObjectKeyFromObject(binding))
+
+			logger.Info("created cluster role binding", "clusterrolebinding", binding.Name)
 		}
 	}
 
+	bindings := &rbacv1.ClusterRoleBindingList{}
+	if err := r.List(ctx, bindings, client.MatchingLabels(labelsForUser(user))); err != nil {
+		return fmt.Errorf("could not list cluster role bindings: %w", err)
+	}
+
+	for _, binding := range bindings.Items {
+		if desired[binding.Name] {
+			continue
+		}
+
+		if err := r.Delete(ctx, &binding); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("could not delete cluster role binding: %w", err)
+		}
+
+		logger.Info("deleted cluster role binding", "clusterrolebinding", binding.Name)
+	}
+
 	if isDeleting {
-		_ = controllerutil.RemoveFinalizer(user, UserRoleBindingFinalizer)
+		_ = controllerutil.RemoveFinalizer(user, UserClusterRoleFinalizer)
 	}
 
 	return nil
@@ -160,7 +685,7 @@ func (r *UserReconciler) reconcileUserSelfRole(ctx context.Context, user *marina
 
 	if user.GetDeletionTimestamp() != nil {
 		if controllerutil.ContainsFinalizer(user, finalizerName) {
-			if err := r.Delete(ctx, selfRole); err != nil {
+			if err := r.Delete(ctx, selfRole); client.IgnoreNotFound(err) != nil {
 				logger.Error(err, "could not delete self role", "role", client.ObjectKeyFromObject(selfRole))
 				return err
 			}
@@ -173,8 +698,15 @@ func (r *UserReconciler) reconcileUserSelfRole(ctx context.Context, user *marina
 
 	_ = controllerutil.AddFinalizer(user, finalizerName)
 
-	if err := r.Create(ctx, selfRole); err != nil {
-		return client.IgnoreAlreadyExists(err)
+	result, err := controllerutil.CreateOrUpdate(ctx, r.Client, selfRole, func() error {
+		return r.setUserOwner(user, selfRole)
+	})
+	if err != nil {
+		return fmt.Errorf("could not reconcile self role: %w", err)
+	}
+
+	if result != controllerutil.OperationResultCreated {
+		return nil
 	}
 
 	logger.Info("created self role for user", "role", client.ObjectKeyFromObject(selfRole))
@@ -184,7 +716,325 @@ func (r *UserReconciler) reconcileUserSelfRole(ctx context.Context, user *marina
 	return nil
 }
 
+func (r *UserReconciler) reconcileOIDCGroups(ctx context.Context, user *marinacorev1.User) error {
+	logger := log.FromContext(ctx)
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      OIDCGroupsConfigMapName,
+			Namespace: user.Namespace,
+		},
+	}
+
+	if user.GetDeletionTimestamp() != nil {
+		if controllerutil.ContainsFinalizer(user, UserOIDCGroupsFinalizer) {
+			if err := r.Get(ctx, client.ObjectKeyFromObject(configMap), configMap); client.IgnoreNotFound(err) != nil {
+				return fmt.Errorf("could not fetch oidc groups: %w", err)
+			} else if err == nil {
+				delete(configMap.Data, user.Name)
+
+				if err := r.Update(ctx, configMap); err != nil {
+					return fmt.Errorf("could not remove oidc groups: %w", err)
+				}
+			}
+
+			controllerutil.RemoveFinalizer(user, UserOIDCGroupsFinalizer)
+
+			logger.Info("removed oidc groups for user", "configmap", client.ObjectKeyFromObject(configMap))
+		}
+
+		return nil
+	}
+
+	// avoid creating the config map for users who never had any groups
+	if len(user.Spec.OIDCGroups) == 0 && !controllerutil.ContainsFinalizer(user, UserOIDCGroupsFinalizer) {
+		return nil
+	}
+
+	_ = controllerutil.AddFinalizer(user, UserOIDCGroupsFinalizer)
+
+	result, err := controllerutil.CreateOrUpdate(ctx, r.Client, configMap, func() error {
+		if configMap.Data == nil {
+			configMap.Data = make(map[string]string)
+		}
+
+		if len(user.Spec.OIDCGroups) == 0 {
+			delete(configMap.Data, user.Name)
+		} else {
+			configMap.Data[user.Name] = strings.Join(user.Spec.OIDCGroups, ",")
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("could not record oidc groups: %w", err)
+	}
+
+	if result != controllerutil.OperationResultNone {
+		logger.Info("recorded oidc groups for user", "configmap", client.ObjectKeyFromObject(configMap))
+	}
+
+	return nil
+}
+
+// nextUID returns the next UID not yet assigned to any user. Users are listed from the api server, since the cache may
+// not yet hold the UIDs most recently assigned.
+func (r *UserReconciler) nextUID(ctx context.Context) (int64, error) {
+	reader := r.APIReader
+	if reader == nil {
+		reader = r.Client
+	}
+
+	users := &marinacorev1.UserList{}
+	if err := reader.List(ctx, users); err != nil {
+		return 0, fmt.Errorf("could not list users: %w", err)
+	}
+
+	uid := int64(MinUserUID)
+	for _, user := range users.Items {
+		if user.Status.UID >= uid {
+			uid = user.Status.UID + 1
+		}
+	}
+
+	return uid, nil
+}
+
+// assignUID assigns the user the next free UID if it does not have one yet. Assignments are serialized and each is
+// persisted before the next UID is chosen, with the patch failing if the user changed since it was read, so two users
+// are never given the same UID.
+func (r *UserReconciler) assignUID(ctx context.Context, user *marinacorev1.User) error {
+	if user.Status.UID != 0 {
+		return nil
+	}
+
+	r.uidMu.Lock()
+	defer r.uidMu.Unlock()
+
+	uid, err := r.nextUID(ctx)
+	if err != nil {
+		return err
+	}
+
+	original := user.DeepCopy()
+	user.Status.UID = uid
+
+	if err := r.Status().Patch(ctx, user, client.MergeFromWithOptions(original, client.MergeFromWithOptimisticLock{})); err != nil {
+		user.Status.UID = 0
+		return fmt.Errorf("could not assign uid: %w", err)
+	}
+
+	return nil
+}
+
+func (r *UserReconciler) reconcileStatus(ctx context.Context, user *marinacorev1.User) error {
+	grants, err := user.RoleGrants()
+	if err != nil {
+		return err
+	}
+
+	if r.pauseHonoured(user) {
+		meta.SetStatusCondition(&user.Status.Conditions, metav1.Condition{
+			Type:               marinacorev1.UserConditionPaused,
+			Status:             metav1.ConditionTrue,
+			Reason:             "Paused",
+			Message:            fmt.Sprintf("reconciliation is paused by the %s annotation", marinacorev1.UserPausedAnnotation),
+			ObservedGeneration: user.Generation,
+		})
+	} else {
+		meta.RemoveStatusCondition(&user.Status.Conditions, marinacorev1.UserConditionPaused)
+	}
+
+	// the expiry is reported as a fixed time rather than a countdown, so the status only changes along with the spec
+	if user.Spec.ExpiresAt != nil {
+		meta.SetStatusCondition(&user.Status.Conditions, metav1.Condition{
+			Type:               marinacorev1.UserConditionExpiring,
+			Status:             metav1.ConditionTrue,
+			Reason:             "ExpiresAt",
+			Message:            fmt.Sprintf("user expires at %s", user.Spec.ExpiresAt.UTC().Format(time.RFC3339)),
+			ObservedGeneration: user.Generation,
+		})
+	} else {
+		meta.RemoveStatusCondition(&user.Status.Conditions, marinacorev1.UserConditionExpiring)
+	}
+
+	user.Status.RoleGrants = nil
+	for _, role := range user.Spec.Roles {
+		if requester, found := grants[role]; found {
+			user.Status.RoleGrants = append(user.Status.RoleGrants, marinacorev1.RoleGrant{
+				Role:      role,
+				Requester: requester,
+			})
+		}
+	}
+
+	user.Status.ServiceAccountName = serviceAccountForUser(user).Name
+
+	bindings := &rbacv1.RoleBindingList{}
+	if err := r.List(ctx, bindings, client.MatchingLabels(labelsForUser(user))); err != nil {
+		return fmt.Errorf("could not list role bindings: %w", err)
+	}
+
+	shared, err := r.sharedRoleBindingsForUser(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	user.Status.RoleBindings = nil
+	for _, binding := range append(bindings.Items, shared...) {
+		user.Status.RoleBindings = append(user.Status.RoleBindings, binding.Name)
+	}
+	slices.Sort(user.Status.RoleBindings)
+
+	if user.Status.Suspended {
+		meta.SetStatusCondition(&user.Status.Conditions, metav1.Condition{
+			Type:               marinacorev1.UserConditionReady,
+			Status:             metav1.ConditionFalse,
+			Reason:             "Suspended",
+			Message:            "user roles are revoked ahead of the user's expiry",
+			ObservedGeneration: user.Generation,
+		})
+	} else {
+		meta.SetStatusCondition(&user.Status.Conditions, metav1.Condition{
+			Type:               marinacorev1.UserConditionReady,
+			Status:             metav1.ConditionTrue,
+			Reason:             "Reconciled",
+			ObservedGeneration: user.Generation,
+		})
+	}
+
+	return r.Status().Update(ctx, user)
+}
+
+func (r *UserReconciler) now() time.Time {
+	if r.Clock == nil {
+		return time.Now()
+	}
+
+	return r.Clock.Now()
+}
+
+// pauseHonoured reports whether the user is paused and its roles are not due to be revoked. Users which are suspended,
+// expired, or outside their access schedule are reconciled even while paused, so their roles are still revoked.
+func (r *UserReconciler) pauseHonoured(user *marinacorev1.User) bool {
+	if !userPaused(user) || r.isSuspended(user) {
+		return false
+	}
+
+	accessWindow, err := accessWindowForUser(user)
+
+	return err != nil || accessWindow == nil || accessWindow.Active(r.now())
+}
+
+// isSuspended reports whether the user is within the suspension window before their expiry.
+func (r *UserReconciler) isSuspended(user *marinacorev1.User) bool {
+	if user.Spec.ExpiresAt == nil {
+		return false
+	}
+
+	return !r.now().Before(user.Spec.ExpiresAt.Add(-r.ExpirySuspensionWindow))
+}
+
+// untilExpiryTransition returns how long until the user is next suspended or expires, or 0 if the user never expires.
+func (r *UserReconciler) untilExpiryTransition(user *marinacorev1.User) time.Duration {
+	if user.Spec.ExpiresAt == nil || user.GetDeletionTimestamp() != nil {
+		return 0
+	}
+
+	if suspendAt := user.Spec.ExpiresAt.Add(-r.ExpirySuspensionWindow); r.now().Before(suspendAt) {
+		return suspendAt.Sub(r.now())
+	}
+
+	return user.Spec.ExpiresAt.Sub(r.now())
+}
+
+// soonest returns the shortest of the given positive durations, or 0 if there are none.
+func soonest(durations ...time.Duration) time.Duration {
+	var result time.Duration
+
+	for _, d := range durations {
+		if d > 0 && (result == 0 || d < result) {
+			result = d
+		}
+	}
+
+	return result
+}
+
+// accessWindowForUser parses the user's access schedule, returning nil if the user has none.
+func accessWindowForUser(user *marinacorev1.User) (*MaintenanceWindow, error) {
+	if user.Spec.AccessSchedule == nil {
+		return nil, nil
+	}
+
+	window, err := ParseMaintenanceWindow(user.Spec.AccessSchedule.Schedule, user.Spec.AccessSchedule.Duration.Duration)
+	if err != nil {
+		return nil, fmt.Errorf("invalid access schedule: %w", err)
+	}
+
+	return window, nil
+}
+
+// userPaused reports whether the user has been paused with the UserPausedAnnotation.
+func userPaused(user *marinacorev1.User) bool {
+	return user.Annotations[marinacorev1.UserPausedAnnotation] == "true"
+}
+
 func (r *UserReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	defer observeReconcile(ctx, "user", time.Now())
+
+	result, err := r.reconcile(ctx, req)
+	if err != nil {
+		userReconcileErrors.Inc()
+		return r.retry(ctx, req, err)
+	}
+
+	r.retries.reset(req.NamespacedName)
+
+	return result, nil
+}
+
+// retry returns the error of a failed reconcile so the user is retried, unless the user has failed too many times in a
+// row. The user is then marked as failed and retried after FailedUserRequeueInterval rather than backing off, or sooner
+// if it is due to be suspended, expire, or leave its access window, so its access is still revoked on time.
+func (r *UserReconciler) retry(ctx context.Context, req ctrl.Request, reconcileErr error) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	// the count is only reset by a successful reconcile, so a failed user is not retried in a burst each time
+	attempts := r.retries.failed(req.NamespacedName)
+	if r.MaxReconcileRetries == 0 || attempts < r.MaxReconcileRetries {
+		reconcileRetries.WithLabelValues("user").Inc()
+		return ctrl.Result{}, reconcileErr
+	}
+
+	user := &marinacorev1.User{}
+	if err := r.Get(ctx, req.NamespacedName, user); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	meta.SetStatusCondition(&user.Status.Conditions, metav1.Condition{
+		Type:               marinacorev1.UserConditionFailed,
+		Status:             metav1.ConditionTrue,
+		Reason:             "RetriesExhausted",
+		Message:            fmt.Sprintf("gave up after %d attempts: %s", attempts, reconcileErr),
+		ObservedGeneration: user.Generation,
+	})
+
+	if err := r.Status().Update(ctx, user); err != nil {
+		return ctrl.Result{}, fmt.Errorf("could not mark user as failed: %w", err)
+	}
+
+	logger.Error(reconcileErr, "user failed to reconcile too many times, backing off", "user", req.NamespacedName, "attempts", attempts)
+	r.recordEvent(user, corev1.EventTypeWarning, "RetriesExhausted", "gave up after %d attempts: %s", attempts, reconcileErr)
+
+	requeueAfter := soonest(FailedUserRequeueInterval, r.untilExpiryTransition(user))
+	if accessWindow, err := accessWindowForUser(user); err == nil {
+		requeueAfter = soonest(requeueAfter, accessWindow.UntilTransition(r.now()))
+	}
+
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+}
+
+func (r *UserReconciler) reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, err error) {
 	logger := log.FromContext(ctx)
 	user := &marinacorev1.User{}
 
@@ -193,28 +1043,155 @@ func (r *UserReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
+	// the uid is persisted on its own before anything else is changed, since the patch overwrites the user in memory
+	if user.GetDeletionTimestamp() == nil {
+		if err := r.assignUID(ctx, user); err != nil {
+			logger.Error(err, "error assigning uid", "user", req.NamespacedName)
+			return ctrl.Result{}, err
+		}
+	}
+
+	// status is still kept up to date during maintenance since it does not touch any other resources
+	if r.MaintenanceWindow.Active(r.now()) {
+		logger.Info("maintenance window is active, skipping user", "user", req.NamespacedName)
+
+		if user.GetDeletionTimestamp() == nil {
+			if err := r.reconcileStatus(ctx, user); err != nil {
+				logger.Error(err, "error updating user status", "user", req.NamespacedName)
+				return ctrl.Result{}, err
+			}
+		}
+
+		return ctrl.Result{RequeueAfter: MaintenanceRequeueInterval}, nil
+	}
+
+	// removing the annotation triggers another reconcile, so paused users are only requeued for when their roles are
+	// next due to be revoked, since pausing a user must not extend their access
+	if r.pauseHonoured(user) {
+		logger.Info("user is paused, skipping user", "user", req.NamespacedName)
+
+		if user.GetDeletionTimestamp() == nil {
+			if err := r.reconcileStatus(ctx, user); err != nil {
+				logger.Error(err, "error updating user status", "user", req.NamespacedName)
+				return ctrl.Result{}, err
+			}
+		}
+
+		requeueAfter := r.untilExpiryTransition(user)
+		if accessWindow, err := accessWindowForUser(user); err == nil {
+			requeueAfter = soonest(requeueAfter, accessWindow.UntilTransition(r.now()))
+		}
+
+		return ctrl.Result{RequeueAfter: requeueAfter}, nil
+	}
+
+	if userPaused(user) {
+		logger.Info("user is paused but their roles are due to be revoked, reconciling anyway", "user", req.NamespacedName)
+	}
+
+	if user.GetDeletionTimestamp() == nil && user.Spec.ExpiresAt != nil && !r.now().Before(user.Spec.ExpiresAt.Time) {
+		logger.Info("user has expired, deleting", "user", req.NamespacedName)
+
+		if err := r.Delete(ctx, user); client.IgnoreNotFound(err) != nil {
+			logger.Error(err, "error deleting expired user", "user", req.NamespacedName)
+			return ctrl.Result{}, err
+		}
+
+		return ctrl.Result{}, nil
+	}
+
+	// the finalizers of a deleted user are persisted on their own, so those removed for children which were already
+	// cleaned up are kept when cleaning up another child fails
+	original := user.DeepCopy()
+	defer func() {
+		if finalizerErr := persistFinalizers(ctx, r.Client, "user", original, user); finalizerErr != nil && err == nil {
+			err = finalizerErr
+		}
+	}()
+
+	suspended := user.GetDeletionTimestamp() == nil && r.isSuspended(user)
+	if suspended && !user.Status.Suspended {
+		r.recordEvent(user, corev1.EventTypeWarning, "Suspended", "user roles are revoked until the user expires at %s", user.Spec.ExpiresAt.UTC().Format(time.RFC3339))
+	}
+
+	accessWindow, err := accessWindowForUser(user)
+	if err != nil {
+		logger.Error(err, "user is invalid", "user", req.NamespacedName)
+		return ctrl.Result{}, err
+	}
+
+	outsideAccessWindow := accessWindow != nil && !accessWindow.Active(r.now())
+	if outsideAccessWindow {
+		logger.Info("user is outside their access schedule, revoking roles", "user", req.NamespacedName)
+	}
+
+	// the namespace is reconciled first, since the user's service account is created in it
+	if err := r.reconcileNamespace(ctx, user); err != nil {
+		logger.Error(err, "error reconciling namespace", "user", req.NamespacedName)
+		return ctrl.Result{}, err
+	}
+
 	if err := r.reconcileServiceAccount(ctx, user); err != nil {
 		logger.Error(err, "error reconciling service account", "user", req.NamespacedName)
 		return ctrl.Result{}, err
 	}
 
+	untilKubeconfigRefresh, err := r.reconcileKubeconfig(ctx, user)
+	if err != nil {
+		logger.Error(err, "error reconciling kubeconfig", "user", req.NamespacedName)
+		return ctrl.Result{}, err
+	}
+
+	if err := r.reconcileCredentialsSecret(ctx, user); err != nil {
+		logger.Error(err, "error reconciling credentials secret", "user", req.NamespacedName)
+		return ctrl.Result{}, err
+	}
+
 	if err := r.reconcileUserSelfRole(ctx, user); err != nil {
 		logger.Error(err, "error reconciling self role", "user", req.NamespacedName)
 		return ctrl.Result{}, err
 	}
 
-	if err := r.reconcileRoleBindings(ctx, user); err != nil {
+	if err := r.reconcileRoleBindings(ctx, user, suspended || outsideAccessWindow); err != nil {
 		logger.Error(err, "error reconciling role bindings", "user", req.NamespacedName)
 		return ctrl.Result{}, err
 
 	}
 
+	if err := r.reconcileInlineRole(ctx, user, suspended || outsideAccessWindow); err != nil {
+		logger.Error(err, "error reconciling inline role", "user", req.NamespacedName)
+		return ctrl.Result{}, err
+	}
+
+	if err := r.reconcileClusterRoleBindings(ctx, user, suspended || outsideAccessWindow); err != nil {
+		logger.Error(err, "error reconciling cluster role bindings", "user", req.NamespacedName)
+		return ctrl.Result{}, err
+	}
+
+	if err := r.reconcileOIDCGroups(ctx, user); err != nil {
+		logger.Error(err, "error reconciling oidc groups", "user", req.NamespacedName)
+		return ctrl.Result{}, err
+	}
+
+	if user.GetDeletionTimestamp() != nil {
+		return ctrl.Result{}, nil
+	}
+
 	if err := r.Update(ctx, user); err != nil {
 		logger.Error(err, "error updating user", "user", req.NamespacedName)
 		return ctrl.Result{}, err
 	}
 
-	return ctrl.Result{}, nil
+	user.Status.Suspended = suspended
+
+	meta.RemoveStatusCondition(&user.Status.Conditions, marinacorev1.UserConditionFailed)
+
+	if err := r.reconcileStatus(ctx, user); err != nil {
+		logger.Error(err, "error updating user status", "user", req.NamespacedName)
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{RequeueAfter: soonest(r.untilExpiryTransition(user), accessWindow.UntilTransition(r.now()), untilKubeconfigRefresh)}, nil
 }
 
 // SetupWithManager sets up the controller with the Manager.
@@ -222,7 +1199,9 @@ func (r *UserReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&marinacorev1.User{}).
 		Owns(&corev1.ServiceAccount{}).
+		Owns(&corev1.Secret{}).
 		Owns(&rbacv1.Role{}).
 		Owns(&rbacv1.RoleBinding{}).
+		WithOptions(controllerOptions(r.MaxConcurrentReconciles)).
 		Complete(r)
 }
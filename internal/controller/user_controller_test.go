@@ -2,15 +2,28 @@ package controller
 
 import (
 	"context"
+	"fmt"
+	"slices"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	dto "github.com/prometheus/client_model/go"
+	"golang.org/x/crypto/bcrypt"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/record"
+	clocktesting "k8s.io/utils/clock/testing"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	marinacorev1 "github.com/joshmeranda/marina-operator/api/v1"
 )
@@ -25,6 +38,7 @@ var _ = Describe("User Controller", func() {
 
 		reconciler = &UserReconciler{
 			Client: k8sClient,
+			Scheme: k8sClient.Scheme(),
 		}
 
 		namespace = &corev1.Namespace{
@@ -136,6 +150,15 @@ var _ = Describe("User Controller", func() {
 			Expect(err).NotTo(HaveOccurred())
 		})
 
+		It("should report the created resources in the status", func() {
+			err := k8sClient.Get(ctx, client.ObjectKeyFromObject(user), user)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(user.Status.ServiceAccountName).To(Equal(user.Name))
+			Expect(user.Status.RoleBindings).To(ContainElements(user.Name+"-SomeRole", user.Name+"-AnotherRole"))
+			Expect(meta.IsStatusConditionTrue(user.Status.Conditions, marinacorev1.UserConditionReady)).To(BeTrue())
+		})
+
 		It("should clean up user resources", func() {
 			err := k8sClient.Delete(ctx, user)
 			Expect(err).NotTo(HaveOccurred())
@@ -150,43 +173,1693 @@ var _ = Describe("User Controller", func() {
 			Expect(err).NotTo(HaveOccurred())
 			Expect(result).To(Equal(ctrl.Result{}))
 
-			var serviceaccount corev1.ServiceAccount
-			err = k8sClient.Get(ctx, types.NamespacedName{
-				Name:      "user-" + user.Name,
-				Namespace: user.Namespace,
-			}, &serviceaccount)
-			Expect(err).To(HaveOccurred())
-			Expect(serviceaccount).To(BeZero())
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(serviceAccountForUser(user)), &corev1.ServiceAccount{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
 
-			var roleBinding rbacv1.RoleBinding
-			err = k8sClient.Get(ctx, types.NamespacedName{
-				Name:      "user-" + user.Name + "-" + "SomeRole",
-				Namespace: user.Namespace,
-			}, &roleBinding)
-			Expect(err).To(HaveOccurred())
-			Expect(roleBinding).To(BeZero())
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(userRoleBindingForRole(user, "SomeRole")), &rbacv1.RoleBinding{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
 
-			err = k8sClient.Get(ctx, types.NamespacedName{
-				Name:      "user-" + user.Name + "-" + "AnotherRole",
-				Namespace: user.Namespace,
-			}, &roleBinding)
-			Expect(err).To(HaveOccurred())
-			Expect(roleBinding).To(BeZero())
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(userRoleBindingForRole(user, "AnotherRole")), &rbacv1.RoleBinding{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
 
-			err = k8sClient.Get(ctx, types.NamespacedName{
-				Name:      user.Name + "-" + "self",
-				Namespace: user.Namespace,
-			}, &roleBinding)
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(userRoleBindingForRole(user, selfRoleForUser(user).Name)), &rbacv1.RoleBinding{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(selfRoleForUser(user)), &rbacv1.Role{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+
+	When("User with many roles is created", Ordered, func() {
+		var user *marinacorev1.User
+
+		BeforeAll(func() {
+			roles := make([]string, 0, 3*MaxConcurrentRoleBindings)
+
+			for i := 0; i < cap(roles); i++ {
+				role := rbacv1.Role{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      fmt.Sprintf("ManyRole%d", i),
+						Namespace: namespace.Name,
+					},
+				}
+
+				err := k8sClient.Create(ctx, &role)
+				if !errors.IsAlreadyExists(err) {
+					Expect(err).NotTo(HaveOccurred())
+				}
+
+				roles = append(roles, role.Name)
+			}
+
+			user = &marinacorev1.User{
+				ObjectMeta: metav1.ObjectMeta{Name: "user-many-roles", Namespace: namespace.Name},
+				Spec: marinacorev1.UserSpec{
+					Name:     "frodo",
+					Password: []byte("baggins"),
+					Roles:    roles,
+				},
+			}
+
+			err := k8sClient.Create(ctx, user)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should create all role bindings", func() {
+			req := ctrl.Request{
+				NamespacedName: types.NamespacedName{
+					Namespace: user.Namespace,
+					Name:      user.Name,
+				},
+			}
+			result, err := reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(Equal(ctrl.Result{}))
+
+			for _, role := range user.Spec.Roles {
+				var roleBinding rbacv1.RoleBinding
+				err = k8sClient.Get(ctx, types.NamespacedName{
+					Name:      user.Name + "-" + role,
+					Namespace: user.Namespace,
+				}, &roleBinding)
+				Expect(err).NotTo(HaveOccurred())
+			}
+		})
+
+		It("should clean up all role bindings", func() {
+			err := k8sClient.Delete(ctx, user)
+			Expect(err).NotTo(HaveOccurred())
+
+			req := ctrl.Request{
+				NamespacedName: types.NamespacedName{
+					Namespace: user.Namespace,
+					Name:      user.Name,
+				},
+			}
+			result, err := reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(Equal(ctrl.Result{}))
+
+			for _, role := range user.Spec.Roles {
+				var roleBinding rbacv1.RoleBinding
+				err = k8sClient.Get(ctx, types.NamespacedName{
+					Name:      user.Name + "-" + role,
+					Namespace: user.Namespace,
+				}, &roleBinding)
+				Expect(errors.IsNotFound(err)).To(BeTrue())
+			}
+		})
+	})
+
+	When("User with invalid roles is created", Ordered, func() {
+		var user *marinacorev1.User
+
+		BeforeAll(func() {
+			user = &marinacorev1.User{
+				ObjectMeta: metav1.ObjectMeta{Name: "user-invalid-roles", Namespace: namespace.Name},
+				Spec: marinacorev1.UserSpec{
+					Name:     "samwise",
+					Password: []byte("gamgee"),
+					Roles:    []string{"Invalid/Role", "Another/Invalid/Role"},
+				},
+			}
+
+			err := k8sClient.Create(ctx, user)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterAll(func() {
+			err := k8sClient.Get(ctx, types.NamespacedName{Namespace: user.Namespace, Name: user.Name}, user)
+			Expect(err).NotTo(HaveOccurred())
+
+			user.Finalizers = nil
+			err = k8sClient.Update(ctx, user)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = k8sClient.Delete(ctx, user)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should aggregate role binding errors", func() {
+			req := ctrl.Request{
+				NamespacedName: types.NamespacedName{
+					Namespace: user.Namespace,
+					Name:      user.Name,
+				},
+			}
+			_, err := reconciler.Reconcile(ctx, req)
 			Expect(err).To(HaveOccurred())
-			Expect(roleBinding).To(BeZero())
 
-			var role rbacv1.Role
-			err = k8sClient.Get(ctx, types.NamespacedName{
-				Name:      user.Name + "-" + user.Name + "-" + "self",
+			aggregate, ok := err.(utilerrors.Aggregate)
+			Expect(ok).To(BeTrue())
+			Expect(aggregate.Errors()).To(HaveLen(2))
+		})
+	})
+
+	When("User with recorded role grants is created", Ordered, func() {
+		var user *marinacorev1.User
+		var req ctrl.Request
+
+		BeforeAll(func() {
+			user = &marinacorev1.User{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "user-granted-roles",
+					Namespace: namespace.Name,
+					Annotations: map[string]string{
+						marinacorev1.UserRoleGrantsAnnotation: `{"SomeRole":"gandalf"}`,
+					},
+				},
+				Spec: marinacorev1.UserSpec{
+					Name:     "pippin",
+					Password: []byte("took"),
+					Roles:    []string{"SomeRole"},
+				},
+			}
+
+			req = ctrl.Request{
+				NamespacedName: types.NamespacedName{
+					Namespace: user.Namespace,
+					Name:      user.Name,
+				},
+			}
+
+			err := k8sClient.Create(ctx, user)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterAll(func() {
+			err := k8sClient.Delete(ctx, user)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should surface the requester in the status", func() {
+			_, err := reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = k8sClient.Get(ctx, req.NamespacedName, user)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(user.Status.RoleGrants).To(ContainElement(marinacorev1.RoleGrant{
+				Role:      "SomeRole",
+				Requester: "gandalf",
+			}))
+		})
+	})
+
+	When("User with oidc groups is created", Ordered, func() {
+		var user *marinacorev1.User
+		var req ctrl.Request
+		var configMapKey types.NamespacedName
+
+		BeforeAll(func() {
+			user = &marinacorev1.User{
+				ObjectMeta: metav1.ObjectMeta{Name: "user-oidc-groups", Namespace: namespace.Name},
+				Spec: marinacorev1.UserSpec{
+					Name:       "merry",
+					Password:   []byte("brandybuck"),
+					OIDCGroups: []string{"hobbits", "fellowship"},
+				},
+			}
+
+			req = ctrl.Request{
+				NamespacedName: types.NamespacedName{
+					Namespace: user.Namespace,
+					Name:      user.Name,
+				},
+			}
+
+			configMapKey = types.NamespacedName{
 				Namespace: user.Namespace,
-			}, &role)
-			Expect(err).To(HaveOccurred())
-			Expect(role).To(BeZero())
+				Name:      OIDCGroupsConfigMapName,
+			}
+
+			err := k8sClient.Create(ctx, user)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should record the oidc groups", func() {
+			_, err := reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+
+			var configMap corev1.ConfigMap
+			err = k8sClient.Get(ctx, configMapKey, &configMap)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(configMap.Data).To(HaveKeyWithValue(user.Name, "hobbits,fellowship"))
+		})
+
+		It("should remove the oidc groups when deleted", func() {
+			err := k8sClient.Delete(ctx, user)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+
+			var configMap corev1.ConfigMap
+			err = k8sClient.Get(ctx, configMapKey, &configMap)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(configMap.Data).NotTo(HaveKey(user.Name))
 		})
 	})
-})
+
+	When("User with token secrets is deleted", Ordered, func() {
+		var user *marinacorev1.User
+		var secret *corev1.Secret
+		var req ctrl.Request
+
+		BeforeAll(func() {
+			user = &marinacorev1.User{
+				ObjectMeta: metav1.ObjectMeta{Name: "user-token-secrets", Namespace: namespace.Name},
+				Spec: marinacorev1.UserSpec{
+					Name:     "gimli",
+					Password: []byte("gloin"),
+				},
+			}
+
+			req = ctrl.Request{
+				NamespacedName: types.NamespacedName{
+					Namespace: user.Namespace,
+					Name:      user.Name,
+				},
+			}
+
+			err := k8sClient.Create(ctx, user)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+
+			secret = &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      user.Name + "-token",
+					Namespace: user.Namespace,
+					Annotations: map[string]string{
+						corev1.ServiceAccountNameKey: user.Name,
+					},
+				},
+				Type: corev1.SecretTypeServiceAccountToken,
+			}
+
+			err = k8sClient.Create(ctx, secret)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should delete the token secrets", func() {
+			reconciler.CleanupTokenSecrets = true
+
+			err := k8sClient.Delete(ctx, user)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(secret), &corev1.Secret{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+
+	When("User is reconciled during a maintenance window", Ordered, func() {
+		var user *marinacorev1.User
+		var req ctrl.Request
+
+		BeforeAll(func() {
+			user = &marinacorev1.User{
+				ObjectMeta: metav1.ObjectMeta{Name: "user-maintenance", Namespace: namespace.Name},
+				Spec: marinacorev1.UserSpec{
+					Name:     "bofur",
+					Password: []byte("bombur"),
+				},
+			}
+
+			req = ctrl.Request{
+				NamespacedName: types.NamespacedName{
+					Namespace: user.Namespace,
+					Name:      user.Name,
+				},
+			}
+
+			err := k8sClient.Create(ctx, user)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should not mutate any children", func() {
+			window, err := ParseMaintenanceWindow("* * * * *", time.Hour)
+			Expect(err).NotTo(HaveOccurred())
+
+			reconciler.MaintenanceWindow = window
+
+			result, err := reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(MaintenanceRequeueInterval))
+
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(serviceAccountForUser(user)), &corev1.ServiceAccount{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("should resume mutations outside the window", func() {
+			_, err := reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(serviceAccountForUser(user)), &corev1.ServiceAccount{})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	When("User approaches their expiry", Ordered, func() {
+		var user *marinacorev1.User
+		var req ctrl.Request
+		var clock *clocktesting.FakePassiveClock
+		var recorder *record.FakeRecorder
+		var bindingKey types.NamespacedName
+
+		BeforeAll(func() {
+			now := time.Now().Truncate(time.Second)
+			clock = clocktesting.NewFakePassiveClock(now)
+			recorder = record.NewFakeRecorder(10)
+
+			user = &marinacorev1.User{
+				ObjectMeta: metav1.ObjectMeta{Name: "user-expiry", Namespace: namespace.Name},
+				Spec: marinacorev1.UserSpec{
+					Name:      "thorin",
+					Password:  []byte("oakenshield"),
+					Roles:     []string{"SomeRole"},
+					ExpiresAt: &metav1.Time{Time: now.Add(2 * time.Hour)},
+				},
+			}
+
+			req = ctrl.Request{
+				NamespacedName: types.NamespacedName{
+					Namespace: user.Namespace,
+					Name:      user.Name,
+				},
+			}
+
+			bindingKey = types.NamespacedName{
+				Name:      user.Name + "-SomeRole",
+				Namespace: user.Namespace,
+			}
+
+			err := k8sClient.Create(ctx, user)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		BeforeEach(func() {
+			reconciler.Clock = clock
+			reconciler.Recorder = recorder
+			reconciler.ExpirySuspensionWindow = time.Hour
+		})
+
+		It("should grant roles before the suspension window", func() {
+			result, err := reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(time.Hour))
+
+			err = k8sClient.Get(ctx, bindingKey, &rbacv1.RoleBinding{})
+			Expect(err).NotTo(HaveOccurred())
+
+			err = k8sClient.Get(ctx, req.NamespacedName, user)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(user.Status.Suspended).To(BeFalse())
+			Expect(meta.IsStatusConditionTrue(user.Status.Conditions, marinacorev1.UserConditionExpiring)).To(BeTrue())
+		})
+
+		It("should suspend the user during the suspension window", func() {
+			clock.SetTime(clock.Now().Add(90 * time.Minute))
+
+			result, err := reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(30 * time.Minute))
+
+			err = k8sClient.Get(ctx, bindingKey, &rbacv1.RoleBinding{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+
+			err = k8sClient.Get(ctx, req.NamespacedName, user)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(user.Status.Suspended).To(BeTrue())
+			Expect(meta.IsStatusConditionFalse(user.Status.Conditions, marinacorev1.UserConditionReady)).To(BeTrue())
+
+			Eventually(recorder.Events).Should(Receive(ContainSubstring("Suspended")))
+		})
+
+		It("should delete the user once expired", func() {
+			clock.SetTime(clock.Now().Add(30 * time.Minute))
+
+			_, err := reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = k8sClient.Get(ctx, req.NamespacedName, user)
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+
+	When("a paused user approaches their expiry", Ordered, func() {
+		var user *marinacorev1.User
+		var req ctrl.Request
+		var clock *clocktesting.FakePassiveClock
+		var bindingKey types.NamespacedName
+
+		BeforeAll(func() {
+			now := time.Now().Truncate(time.Second)
+			clock = clocktesting.NewFakePassiveClock(now)
+
+			user = &marinacorev1.User{
+				ObjectMeta: metav1.ObjectMeta{Name: "user-paused-expiry", Namespace: namespace.Name},
+				Spec: marinacorev1.UserSpec{
+					Name:      "dain",
+					Password:  []byte("ironfoot"),
+					Roles:     []string{"SomeRole"},
+					ExpiresAt: &metav1.Time{Time: now.Add(2 * time.Hour)},
+				},
+			}
+
+			req = ctrl.Request{NamespacedName: client.ObjectKeyFromObject(user)}
+			bindingKey = types.NamespacedName{Name: user.Name + "-SomeRole", Namespace: user.Namespace}
+
+			err := k8sClient.Create(ctx, user)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		BeforeEach(func() {
+			reconciler.Clock = clock
+			reconciler.ExpirySuspensionWindow = time.Hour
+		})
+
+		AfterAll(func() {
+			err := k8sClient.Get(ctx, req.NamespacedName, user)
+			Expect(err).NotTo(HaveOccurred())
+
+			delete(user.Annotations, marinacorev1.UserPausedAnnotation)
+			err = k8sClient.Update(ctx, user)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = k8sClient.Delete(ctx, user)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should requeue the paused user for its suspension", func() {
+			_, err := reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = k8sClient.Get(ctx, bindingKey, &rbacv1.RoleBinding{})
+			Expect(err).NotTo(HaveOccurred())
+
+			err = k8sClient.Get(ctx, req.NamespacedName, user)
+			Expect(err).NotTo(HaveOccurred())
+
+			user.Annotations = map[string]string{marinacorev1.UserPausedAnnotation: "true"}
+			err = k8sClient.Update(ctx, user)
+			Expect(err).NotTo(HaveOccurred())
+
+			result, err := reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(time.Hour))
+
+			err = k8sClient.Get(ctx, req.NamespacedName, user)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(meta.IsStatusConditionTrue(user.Status.Conditions, marinacorev1.UserConditionPaused)).To(BeTrue())
+		})
+
+		It("should still revoke the roles of the paused user once suspended", func() {
+			clock.SetTime(clock.Now().Add(90 * time.Minute))
+
+			_, err := reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = k8sClient.Get(ctx, bindingKey, &rbacv1.RoleBinding{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+
+			err = k8sClient.Get(ctx, req.NamespacedName, user)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(user.Status.Suspended).To(BeTrue())
+			Expect(meta.FindStatusCondition(user.Status.Conditions, marinacorev1.UserConditionPaused)).To(BeNil())
+		})
+	})
+
+	When("User with a grant namespace is created", Ordered, func() {
+		var user *marinacorev1.User
+		var grantNamespace *corev1.Namespace
+		var req ctrl.Request
+
+		BeforeAll(func() {
+			grantNamespace = &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{Name: "marina-grants"},
+			}
+
+			err := k8sClient.Create(ctx, grantNamespace)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = k8sClient.Create(ctx, &rbacv1.Role{
+				ObjectMeta: metav1.ObjectMeta{Name: "SomeRole", Namespace: grantNamespace.Name},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			user = &marinacorev1.User{
+				ObjectMeta: metav1.ObjectMeta{Name: "user-grant-namespace", Namespace: namespace.Name},
+				Spec: marinacorev1.UserSpec{
+					Name:           "balin",
+					Password:       []byte("fundin"),
+					Roles:          []string{"SomeRole"},
+					GrantNamespace: grantNamespace.Name,
+				},
+			}
+
+			req = ctrl.Request{
+				NamespacedName: types.NamespacedName{
+					Namespace: user.Namespace,
+					Name:      user.Name,
+				},
+			}
+
+			err = k8sClient.Create(ctx, user)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should bind roles in the grant namespace", func() {
+			binding := rbacv1.RoleBinding{}
+			err := k8sClient.Get(ctx, types.NamespacedName{
+				Name:      user.Name + "-SomeRole",
+				Namespace: grantNamespace.Name,
+			}, &binding)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(binding.Subjects).To(ConsistOf(rbacv1.Subject{
+				Kind:      rbacv1.ServiceAccountKind,
+				Name:      user.Name,
+				Namespace: user.Namespace,
+			}))
+		})
+
+		It("should bind the self role in the user namespace", func() {
+			err := k8sClient.Get(ctx, types.NamespacedName{
+				Name:      user.Name + "-" + user.Name + "-self",
+				Namespace: user.Namespace,
+			}, &rbacv1.RoleBinding{})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should clean up the bindings in the grant namespace", func() {
+			err := k8sClient.Delete(ctx, user)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = k8sClient.Get(ctx, types.NamespacedName{
+				Name:      user.Name + "-SomeRole",
+				Namespace: grantNamespace.Name,
+			}, &rbacv1.RoleBinding{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+
+	When("User with a provisioned namespace is created", Ordered, func() {
+		var user *marinacorev1.User
+		var req ctrl.Request
+
+		BeforeAll(func() {
+			user = &marinacorev1.User{
+				ObjectMeta: metav1.ObjectMeta{Name: "user-provisioned", Namespace: namespace.Name},
+				Spec: marinacorev1.UserSpec{
+					Name:               "dwalin",
+					Password:           []byte("fundin"),
+					ProvisionNamespace: true,
+				},
+			}
+
+			req = ctrl.Request{
+				NamespacedName: types.NamespacedName{
+					Namespace: user.Namespace,
+					Name:      user.Name,
+				},
+			}
+
+			err := k8sClient.Create(ctx, user)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should create a namespace for the user", func() {
+			provisioned := corev1.Namespace{}
+			err := k8sClient.Get(ctx, types.NamespacedName{Name: "marina-user-" + user.Name}, &provisioned)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(provisioned.Labels).To(HaveKeyWithValue(UserNameLabel, user.Name))
+			Expect(provisioned.Labels).To(HaveKeyWithValue(UserNamespaceLabel, user.Namespace))
+		})
+
+		It("should create the service account in the provisioned namespace", func() {
+			err := k8sClient.Get(ctx, types.NamespacedName{
+				Name:      user.Name,
+				Namespace: "marina-user-" + user.Name,
+			}, &corev1.ServiceAccount{})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should make the user an admin of the provisioned namespace", func() {
+			binding := rbacv1.RoleBinding{}
+			err := k8sClient.Get(ctx, types.NamespacedName{
+				Name:      "marina-user-admin",
+				Namespace: "marina-user-" + user.Name,
+			}, &binding)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(binding.RoleRef.Name).To(Equal(UserNamespaceClusterRole))
+			Expect(binding.Subjects).To(ConsistOf(rbacv1.Subject{
+				Kind:      rbacv1.ServiceAccountKind,
+				Name:      user.Name,
+				Namespace: "marina-user-" + user.Name,
+			}))
+		})
+
+		It("should delete the provisioned namespace with the user", func() {
+			err := k8sClient.Delete(ctx, user)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+
+			// namespaces are only removed once their contents are, so they may still be terminating
+			provisioned := corev1.Namespace{}
+			err = k8sClient.Get(ctx, types.NamespacedName{Name: "marina-user-" + user.Name}, &provisioned)
+			if err == nil {
+				Expect(provisioned.GetDeletionTimestamp()).NotTo(BeNil())
+			} else {
+				Expect(errors.IsNotFound(err)).To(BeTrue())
+			}
+
+			err = k8sClient.Get(ctx, req.NamespacedName, user)
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+
+	When("User with inline roles is created", Ordered, func() {
+		var user *marinacorev1.User
+		var req ctrl.Request
+
+		BeforeAll(func() {
+			user = &marinacorev1.User{
+				ObjectMeta: metav1.ObjectMeta{Name: "user-inline", Namespace: namespace.Name},
+				Spec: marinacorev1.UserSpec{
+					Name:     "oin",
+					Password: []byte("groin"),
+					InlineRoles: []rbacv1.PolicyRule{
+						{APIGroups: []string{""}, Resources: []string{"configmaps"}, Verbs: []string{"get", "list"}},
+					},
+				},
+			}
+
+			req = ctrl.Request{
+				NamespacedName: types.NamespacedName{
+					Namespace: user.Namespace,
+					Name:      user.Name,
+				},
+			}
+
+			reconciler.InlineRolesReviewed = true
+
+			err := k8sClient.Create(ctx, user)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = k8sClient.Get(ctx, req.NamespacedName, user)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		BeforeEach(func() {
+			reconciler.InlineRolesReviewed = true
+		})
+
+		It("should create a role from the inline rules", func() {
+			role := rbacv1.Role{}
+			err := k8sClient.Get(ctx, types.NamespacedName{
+				Name:      user.Name + "-inline",
+				Namespace: user.Namespace,
+			}, &role)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(role.Rules).To(Equal(user.Spec.InlineRoles))
+			Expect(metav1.IsControlledBy(&role, user)).To(BeTrue())
+		})
+
+		It("should bind the user to the inline role", func() {
+			binding := rbacv1.RoleBinding{}
+			err := k8sClient.Get(ctx, types.NamespacedName{
+				Name:      user.Name + "-" + user.Name + "-inline",
+				Namespace: user.Namespace,
+			}, &binding)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(binding.RoleRef.Name).To(Equal(user.Name + "-inline"))
+			Expect(binding.Subjects).To(ConsistOf(rbacv1.Subject{
+				Kind:      rbacv1.ServiceAccountKind,
+				Name:      user.Name,
+				Namespace: user.Namespace,
+			}))
+			Expect(metav1.IsControlledBy(&binding, user)).To(BeTrue())
+		})
+
+		It("should delete the inline role with the user", func() {
+			err := k8sClient.Delete(ctx, user)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = k8sClient.Get(ctx, types.NamespacedName{
+				Name:      user.Name + "-inline",
+				Namespace: user.Namespace,
+			}, &rbacv1.Role{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+
+			err = k8sClient.Get(ctx, types.NamespacedName{
+				Name:      user.Name + "-" + user.Name + "-inline",
+				Namespace: user.Namespace,
+			}, &rbacv1.RoleBinding{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+
+	When("User with inline roles is created without the user webhook", Ordered, func() {
+		var user *marinacorev1.User
+		var req ctrl.Request
+		var recorder *record.FakeRecorder
+
+		BeforeAll(func() {
+			recorder = record.NewFakeRecorder(100)
+
+			user = &marinacorev1.User{
+				ObjectMeta: metav1.ObjectMeta{Name: "user-inline-unreviewed", Namespace: namespace.Name},
+				Spec: marinacorev1.UserSpec{
+					Name:     "gloin",
+					Password: []byte("groin"),
+					InlineRoles: []rbacv1.PolicyRule{
+						{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"get", "list"}},
+					},
+				},
+			}
+
+			req = ctrl.Request{NamespacedName: client.ObjectKeyFromObject(user)}
+
+			err := k8sClient.Create(ctx, user)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterAll(func() {
+			err := k8sClient.Delete(ctx, user)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		BeforeEach(func() {
+			reconciler.Recorder = recorder
+		})
+
+		It("should refuse the inline roles", func() {
+			_, err := reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = k8sClient.Get(ctx, types.NamespacedName{
+				Name:      user.Name + "-inline",
+				Namespace: user.Namespace,
+			}, &rbacv1.Role{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+
+			err = k8sClient.Get(ctx, types.NamespacedName{
+				Name:      user.Name + "-" + user.Name + "-inline",
+				Namespace: user.Namespace,
+			}, &rbacv1.RoleBinding{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+
+			Eventually(recorder.Events).Should(Receive(ContainSubstring("InlineRolesRefused")))
+		})
+	})
+
+	When("Users are assigned UIDs", Ordered, func() {
+		var users []*marinacorev1.User
+
+		BeforeAll(func() {
+			users = []*marinacorev1.User{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "user-uid-fili", Namespace: namespace.Name},
+					Spec:       marinacorev1.UserSpec{Name: "fili", Password: []byte("durin")},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "user-uid-kili", Namespace: namespace.Name},
+					Spec:       marinacorev1.UserSpec{Name: "kili", Password: []byte("durin")},
+				},
+			}
+
+			for _, user := range users {
+				err := k8sClient.Create(ctx, user)
+				Expect(err).NotTo(HaveOccurred())
+			}
+		})
+
+		It("should assign each user a distinct UID", func() {
+			for _, user := range users {
+				_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(user)})
+				Expect(err).NotTo(HaveOccurred())
+
+				err = k8sClient.Get(ctx, client.ObjectKeyFromObject(user), user)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(user.Status.UID).To(BeNumerically(">=", MinUserUID))
+			}
+
+			Expect(users[0].Status.UID).NotTo(Equal(users[1].Status.UID))
+		})
+
+		It("should keep the assigned UID", func() {
+			uid := users[0].Status.UID
+
+			_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(users[0])})
+			Expect(err).NotTo(HaveOccurred())
+
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(users[0]), users[0])
+			Expect(err).NotTo(HaveOccurred())
+			Expect(users[0].Status.UID).To(Equal(uid))
+		})
+
+		It("should choose UIDs from the api server rather than the cache", func() {
+			assigned := &marinacorev1.User{
+				ObjectMeta: metav1.ObjectMeta{Name: "user-uid-oin", Namespace: namespace.Name},
+				Status:     marinacorev1.UserStatus{UID: 20000},
+			}
+			reconciler.APIReader = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(assigned).Build()
+
+			user := &marinacorev1.User{
+				ObjectMeta: metav1.ObjectMeta{Name: "user-uid-gloin", Namespace: namespace.Name},
+				Spec:       marinacorev1.UserSpec{Name: "gloin", Password: []byte("durin")},
+			}
+
+			err := k8sClient.Create(ctx, user)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = reconciler.assignUID(ctx, user)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(user), user)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(user.Status.UID).To(Equal(int64(20001)))
+
+			err = k8sClient.Delete(ctx, user)
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	When("User without a username is created", func() {
+		It("should use the name of the user as the username", func() {
+			user := &marinacorev1.User{
+				ObjectMeta: metav1.ObjectMeta{Name: "dwalin", Namespace: namespace.Name},
+				Spec:       marinacorev1.UserSpec{Password: []byte("fundin")},
+			}
+			req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(user)}
+
+			err := k8sClient.Create(ctx, user)
+			Expect(err).NotTo(HaveOccurred())
+
+			reconciler.PasswordHashCost = bcrypt.MinCost
+
+			_, err = reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+
+			secret := corev1.Secret{}
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(credentialsSecretForUser(user)), &secret)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(secret.Data[UserUsernameKey]).To(Equal([]byte("dwalin")))
+
+			err = k8sClient.Delete(ctx, user)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	When("User with a password is created", Ordered, func() {
+		var user *marinacorev1.User
+		var req ctrl.Request
+
+		BeforeAll(func() {
+			user = &marinacorev1.User{
+				ObjectMeta: metav1.ObjectMeta{Name: "user-password", Namespace: namespace.Name},
+				Spec: marinacorev1.UserSpec{
+					Name:     "balin",
+					Password: []byte("fundin"),
+				},
+			}
+
+			req = ctrl.Request{NamespacedName: client.ObjectKeyFromObject(user)}
+
+			err := k8sClient.Create(ctx, user)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should store a hash of the password", func() {
+			reconciler.PasswordHashCost = bcrypt.MinCost
+
+			_, err := reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+
+			secret := corev1.Secret{}
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(credentialsSecretForUser(user)), &secret)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(metav1.IsControlledBy(&secret, user)).To(BeTrue())
+			Expect(secret.Data[UserUsernameKey]).To(Equal([]byte("balin")))
+
+			hash := secret.Data[UserPasswordHashKey]
+			Expect(bcrypt.CompareHashAndPassword(hash, []byte("fundin"))).To(Succeed())
+
+			cost, err := bcrypt.Cost(hash)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cost).To(Equal(bcrypt.MinCost))
+		})
+
+		It("should clear the plaintext password", func() {
+			err := k8sClient.Get(ctx, req.NamespacedName, user)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(user.Spec.Password).To(BeEmpty())
+			Expect(user.Status.PasswordRotatedAt).NotTo(BeNil())
+		})
+
+		It("should rotate the password when it is set again", func() {
+			err := k8sClient.Get(ctx, req.NamespacedName, user)
+			Expect(err).NotTo(HaveOccurred())
+
+			user.Spec.Password = []byte("thorin")
+			err = k8sClient.Update(ctx, user)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+
+			secret := corev1.Secret{}
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(credentialsSecretForUser(user)), &secret)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(bcrypt.CompareHashAndPassword(secret.Data[UserPasswordHashKey], []byte("thorin"))).To(Succeed())
+		})
+
+		It("should keep the password hash once the password is cleared", func() {
+			_, err := reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+
+			secret := corev1.Secret{}
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(credentialsSecretForUser(user)), &secret)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(bcrypt.CompareHashAndPassword(secret.Data[UserPasswordHashKey], []byte("thorin"))).To(Succeed())
+		})
+
+		It("should store the authorized keys", func() {
+			keys := []string{
+				"ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIJh/IXTQ5wGPpOjzL0VvMrrKJStj8v0EM5KS5iWqxeAL balin@erebor",
+				"ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIJh/IXTQ5wGPpOjzL0VvMrrKJStj8v0EM5KS5iWqxeAL balin@moria",
+			}
+
+			err := k8sClient.Get(ctx, req.NamespacedName, user)
+			Expect(err).NotTo(HaveOccurred())
+
+			user.Spec.AuthorizedKeys = keys
+			err = k8sClient.Update(ctx, user)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+
+			secret := corev1.Secret{}
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(credentialsSecretForUser(user)), &secret)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(secret.Data[UserAuthorizedKeysKey])).To(Equal(keys[0] + "\n" + keys[1] + "\n"))
+			Expect(secret.Data).To(HaveKey(UserPasswordHashKey))
+		})
+
+		It("should remove the authorized keys once they are cleared", func() {
+			err := k8sClient.Get(ctx, req.NamespacedName, user)
+			Expect(err).NotTo(HaveOccurred())
+
+			user.Spec.AuthorizedKeys = nil
+			err = k8sClient.Update(ctx, user)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+
+			secret := corev1.Secret{}
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(credentialsSecretForUser(user)), &secret)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(secret.Data).NotTo(HaveKey(UserAuthorizedKeysKey))
+		})
+
+		It("should delete the credentials secret with the user", func() {
+			err := k8sClient.Delete(ctx, user)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(credentialsSecretForUser(user)), &corev1.Secret{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+
+			err = k8sClient.Get(ctx, req.NamespacedName, &marinacorev1.User{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+
+	When("User has an access schedule", Ordered, func() {
+		var user *marinacorev1.User
+		var req ctrl.Request
+		var clock *clocktesting.FakePassiveClock
+		var bindingKey types.NamespacedName
+
+		BeforeAll(func() {
+			// a monday morning
+			clock = clocktesting.NewFakePassiveClock(time.Date(2024, time.June, 3, 10, 0, 0, 0, time.UTC))
+
+			user = &marinacorev1.User{
+				ObjectMeta: metav1.ObjectMeta{Name: "user-access-schedule", Namespace: namespace.Name},
+				Spec: marinacorev1.UserSpec{
+					Name:     "dwalin",
+					Password: []byte("fundin"),
+					Roles:    []string{"SomeRole"},
+					AccessSchedule: &marinacorev1.AccessSchedule{
+						Schedule: "0 9 * * 1-5",
+						Duration: metav1.Duration{Duration: 8 * time.Hour},
+					},
+				},
+			}
+
+			req = ctrl.Request{NamespacedName: client.ObjectKeyFromObject(user)}
+
+			bindingKey = types.NamespacedName{
+				Name:      user.Name + "-SomeRole",
+				Namespace: user.Namespace,
+			}
+
+			err := k8sClient.Create(ctx, user)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterAll(func() {
+			err := k8sClient.Delete(ctx, user)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		BeforeEach(func() {
+			reconciler.Clock = clock
+		})
+
+		It("should grant roles during the window", func() {
+			result, err := reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(7 * time.Hour))
+
+			err = k8sClient.Get(ctx, bindingKey, &rbacv1.RoleBinding{})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should revoke roles outside the window", func() {
+			clock.SetTime(time.Date(2024, time.June, 3, 18, 0, 0, 0, time.UTC))
+
+			result, err := reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(15 * time.Hour))
+
+			err = k8sClient.Get(ctx, bindingKey, &rbacv1.RoleBinding{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("should restore roles when the window opens", func() {
+			clock.SetTime(time.Date(2024, time.June, 4, 9, 0, 0, 0, time.UTC))
+
+			_, err := reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = k8sClient.Get(ctx, bindingKey, &rbacv1.RoleBinding{})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	When("User references a role which does not exist", func() {
+		var user *marinacorev1.User
+
+		BeforeEach(func() {
+			user = &marinacorev1.User{
+				ObjectMeta: metav1.ObjectMeta{Name: "user-missing-role", Namespace: namespace.Name},
+				Spec: marinacorev1.UserSpec{
+					Name:     "oin",
+					Password: []byte("groin"),
+					Roles:    []string{"MissingRole"},
+				},
+			}
+
+			err := k8sClient.Create(ctx, user)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			err := k8sClient.Delete(ctx, user)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should fail to reconcile the user", func() {
+			_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(user)})
+			Expect(err).To(MatchError(ContainSubstring("role 'MissingRole' does not exist")))
+
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(userRoleBindingForRole(user, "MissingRole")), &rbacv1.RoleBinding{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("should record a warning event", func() {
+			recorder := record.NewFakeRecorder(10)
+			reconciler.Recorder = recorder
+
+			_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(user)})
+			Expect(err).To(HaveOccurred())
+
+			Eventually(recorder.Events).Should(Receive(Equal("Warning RoleNotFound role 'MissingRole' does not exist in namespace '" + user.Namespace + "'")))
+		})
+
+		It("should still clear the plaintext password", func() {
+			reconciler.PasswordHashCost = bcrypt.MinCost
+
+			_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(user)})
+			Expect(err).To(HaveOccurred())
+
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(user), user)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(user.Spec.Password).To(BeEmpty())
+			Expect(user.Status.PasswordRotatedAt).NotTo(BeNil())
+
+			secret := corev1.Secret{}
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(credentialsSecretForUser(user)), &secret)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(bcrypt.CompareHashAndPassword(secret.Data[UserPasswordHashKey], []byte("groin"))).To(Succeed())
+		})
+
+		It("should mark the user as failed once it runs out of retries", func() {
+			reconciler.MaxReconcileRetries = 3
+			req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(user)}
+
+			retries := func() float64 {
+				metric := &dto.Metric{}
+				err := reconcileRetries.WithLabelValues("user").Write(metric)
+				Expect(err).NotTo(HaveOccurred())
+
+				return metric.GetCounter().GetValue()
+			}
+			before := retries()
+
+			for range 2 {
+				_, err := reconciler.Reconcile(ctx, req)
+				Expect(err).To(HaveOccurred())
+			}
+
+			Expect(retries()).To(Equal(before + 2))
+
+			_, err := reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = k8sClient.Get(ctx, req.NamespacedName, user)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(meta.IsStatusConditionTrue(user.Status.Conditions, marinacorev1.UserConditionFailed)).To(BeTrue())
+
+			result, err := reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(FailedUserRequeueInterval))
+			Expect(retries()).To(Equal(before + 2))
+		})
+
+		It("should retry a failed user in time to enforce its expiry", func() {
+			reconciler.MaxReconcileRetries = 1
+			req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(user)}
+
+			err := k8sClient.Get(ctx, req.NamespacedName, user)
+			Expect(err).NotTo(HaveOccurred())
+
+			user.Spec.ExpiresAt = &metav1.Time{Time: reconciler.now().Add(5 * time.Minute)}
+			err = k8sClient.Update(ctx, user)
+			Expect(err).NotTo(HaveOccurred())
+
+			result, err := reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(BeNumerically("<=", 5*time.Minute))
+		})
+	})
+
+	When("User has a role removed", Ordered, func() {
+		var user *marinacorev1.User
+		var req ctrl.Request
+
+		BeforeAll(func() {
+			user = &marinacorev1.User{
+				ObjectMeta: metav1.ObjectMeta{Name: "user-role-removed", Namespace: namespace.Name},
+				Spec: marinacorev1.UserSpec{
+					Name:     "bifur",
+					Password: []byte("bofur"),
+					Roles:    []string{"SomeRole", "AnotherRole"},
+				},
+			}
+
+			req = ctrl.Request{NamespacedName: client.ObjectKeyFromObject(user)}
+
+			err := k8sClient.Create(ctx, user)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterAll(func() {
+			err := k8sClient.Delete(ctx, user)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should delete the binding for the removed role", func() {
+			err := k8sClient.Get(ctx, req.NamespacedName, user)
+			Expect(err).NotTo(HaveOccurred())
+
+			user.Spec.Roles = slices.DeleteFunc(user.Spec.Roles, func(role string) bool {
+				return role == "AnotherRole"
+			})
+			err = k8sClient.Update(ctx, user)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(userRoleBindingForRole(user, "AnotherRole")), &rbacv1.RoleBinding{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(userRoleBindingForRole(user, "SomeRole")), &rbacv1.RoleBinding{})
+			Expect(err).NotTo(HaveOccurred())
+
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(userRoleBindingForRole(user, user.Name+"-self")), &rbacv1.RoleBinding{})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	When("User with cluster roles is created", Ordered, func() {
+		var user *marinacorev1.User
+		var clusterRole *rbacv1.ClusterRole
+		var req ctrl.Request
+
+		BeforeAll(func() {
+			clusterRole = &rbacv1.ClusterRole{
+				ObjectMeta: metav1.ObjectMeta{Name: "marina-test-cluster-role"},
+			}
+
+			err := k8sClient.Create(ctx, clusterRole)
+			Expect(err).NotTo(HaveOccurred())
+
+			user = &marinacorev1.User{
+				ObjectMeta: metav1.ObjectMeta{Name: "user-cluster-roles", Namespace: namespace.Name},
+				Spec: marinacorev1.UserSpec{
+					Name:         "nori",
+					Password:     []byte("dori"),
+					ClusterRoles: []string{clusterRole.Name},
+				},
+			}
+
+			req = ctrl.Request{NamespacedName: client.ObjectKeyFromObject(user)}
+
+			err = k8sClient.Create(ctx, user)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterAll(func() {
+			err := k8sClient.Delete(ctx, clusterRole)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should create a cluster role binding", func() {
+			_, err := reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+
+			binding := rbacv1.ClusterRoleBinding{}
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(userClusterRoleBindingForClusterRole(user, clusterRole.Name)), &binding)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(binding.RoleRef.Kind).To(Equal("ClusterRole"))
+			Expect(binding.RoleRef.Name).To(Equal(clusterRole.Name))
+			Expect(binding.Subjects).To(ConsistOf(rbacv1.Subject{
+				Kind:      rbacv1.ServiceAccountKind,
+				Name:      user.Name,
+				Namespace: user.Namespace,
+			}))
+		})
+
+		It("should delete the cluster role binding with the user", func() {
+			err := k8sClient.Delete(ctx, user)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(userClusterRoleBindingForClusterRole(user, clusterRole.Name)), &rbacv1.ClusterRoleBinding{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+
+			err = k8sClient.Get(ctx, req.NamespacedName, &marinacorev1.User{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+
+	When("Users are given kubeconfigs", Ordered, func() {
+		var user *marinacorev1.User
+		var req ctrl.Request
+
+		BeforeAll(func() {
+			user = &marinacorev1.User{
+				ObjectMeta: metav1.ObjectMeta{Name: "user-kubeconfig", Namespace: namespace.Name},
+				Spec: marinacorev1.UserSpec{
+					Name:     "gloin",
+					Password: []byte("groin"),
+				},
+			}
+
+			req = ctrl.Request{NamespacedName: client.ObjectKeyFromObject(user)}
+
+			err := k8sClient.Create(ctx, user)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		BeforeEach(func() {
+			reconciler.KubeconfigServer = "https://marina.example.com:6443"
+		})
+
+		AfterAll(func() {
+			err := k8sClient.Delete(ctx, user)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should create a kubeconfig for the user's service account", func() {
+			result, err := reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+
+			secret := corev1.Secret{}
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(kubeconfigSecretForUser(user)), &secret)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(secret.Annotations).To(HaveKeyWithValue(corev1.ServiceAccountNameKey, serviceAccountForUser(user).Name))
+
+			config, err := clientcmd.Load(secret.Data[UserKubeconfigKey])
+			Expect(err).NotTo(HaveOccurred())
+
+			current := config.Contexts[config.CurrentContext]
+			Expect(current).NotTo(BeNil())
+			Expect(current.Namespace).To(Equal(user.Namespace))
+			Expect(config.Clusters[current.Cluster].Server).To(Equal("https://marina.example.com:6443"))
+			Expect(config.AuthInfos[current.AuthInfo].Token).NotTo(BeEmpty())
+		})
+
+		It("should not reissue a token which is still valid", func() {
+			secret := corev1.Secret{}
+			err := k8sClient.Get(ctx, client.ObjectKeyFromObject(kubeconfigSecretForUser(user)), &secret)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+
+			reconciled := corev1.Secret{}
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(kubeconfigSecretForUser(user)), &reconciled)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reconciled.ResourceVersion).To(Equal(secret.ResourceVersion))
+		})
+	})
+
+	When("Users share role bindings", Ordered, func() {
+		var users []*marinacorev1.User
+		var bindingKey types.NamespacedName
+
+		BeforeAll(func() {
+			users = []*marinacorev1.User{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "user-shared-kili", Namespace: namespace.Name},
+					Spec:       marinacorev1.UserSpec{Name: "kili", Roles: []string{"SomeRole"}},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "user-shared-fili", Namespace: namespace.Name},
+					Spec:       marinacorev1.UserSpec{Name: "fili", Roles: []string{"SomeRole"}},
+				},
+			}
+
+			bindingKey = types.NamespacedName{Name: "marina-SomeRole", Namespace: namespace.Name}
+
+			for _, user := range users {
+				err := k8sClient.Create(ctx, user)
+				Expect(err).NotTo(HaveOccurred())
+			}
+		})
+
+		BeforeEach(func() {
+			reconciler.SharedRoleBindings = true
+		})
+
+		It("should bind every user with the same role in a single role binding", func() {
+			for _, user := range users {
+				_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(user)})
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			binding := rbacv1.RoleBinding{}
+			err := k8sClient.Get(ctx, bindingKey, &binding)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(binding.Subjects).To(ConsistOf(subjectForUser(users[0]), subjectForUser(users[1])))
+
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(userRoleBindingForRole(users[0], "SomeRole")), &rbacv1.RoleBinding{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(users[0]), users[0])
+			Expect(err).NotTo(HaveOccurred())
+			Expect(users[0].Status.RoleBindings).To(ContainElement(bindingKey.Name))
+		})
+
+		It("should remove a deleted user from the shared role binding", func() {
+			err := k8sClient.Delete(ctx, users[0])
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(users[0])})
+			Expect(err).NotTo(HaveOccurred())
+
+			binding := rbacv1.RoleBinding{}
+			err = k8sClient.Get(ctx, bindingKey, &binding)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(binding.Subjects).To(ConsistOf(subjectForUser(users[1])))
+		})
+
+		It("should delete the shared role binding with its last user", func() {
+			err := k8sClient.Delete(ctx, users[1])
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(users[1])})
+			Expect(err).NotTo(HaveOccurred())
+
+			err = k8sClient.Get(ctx, bindingKey, &rbacv1.RoleBinding{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+
+	When("A role binding with the name of a shared role binding already exists", Ordered, func() {
+		var user *marinacorev1.User
+		var req ctrl.Request
+		var recorder *record.FakeRecorder
+		var existing *rbacv1.RoleBinding
+
+		BeforeAll(func() {
+			recorder = record.NewFakeRecorder(100)
+
+			existing = &rbacv1.RoleBinding{
+				ObjectMeta: metav1.ObjectMeta{Name: "marina-AnotherRole", Namespace: namespace.Name},
+				Subjects: []rbacv1.Subject{
+					{Kind: rbacv1.ServiceAccountKind, Name: "balin", Namespace: namespace.Name},
+				},
+				RoleRef: rbacv1.RoleRef{Kind: "Role", Name: "SomeRole", APIGroup: "rbac.authorization.k8s.io"},
+			}
+
+			err := k8sClient.Create(ctx, existing)
+			Expect(err).NotTo(HaveOccurred())
+
+			user = &marinacorev1.User{
+				ObjectMeta: metav1.ObjectMeta{Name: "user-shared-dwalin", Namespace: namespace.Name},
+				Spec:       marinacorev1.UserSpec{Name: "dwalin", Roles: []string{"AnotherRole"}},
+			}
+
+			req = ctrl.Request{NamespacedName: client.ObjectKeyFromObject(user)}
+
+			err = k8sClient.Create(ctx, user)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterAll(func() {
+			err := k8sClient.Delete(ctx, user)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = k8sClient.Delete(ctx, existing)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		BeforeEach(func() {
+			reconciler.SharedRoleBindings = true
+			reconciler.Recorder = recorder
+		})
+
+		It("should not add the user to the existing role binding", func() {
+			_, err := reconciler.Reconcile(ctx, req)
+			Expect(err).To(MatchError(ContainSubstring("role binding 'marina-AnotherRole' already exists")))
+
+			binding := rbacv1.RoleBinding{}
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(existing), &binding)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(binding.Subjects).To(Equal(existing.Subjects))
+
+			Eventually(recorder.Events).Should(Receive(HavePrefix("Warning RoleBindingConflict")))
+		})
+	})
+
+	When("User children are created", Ordered, func() {
+		var user *marinacorev1.User
+		var req ctrl.Request
+
+		BeforeAll(func() {
+			user = &marinacorev1.User{
+				ObjectMeta: metav1.ObjectMeta{Name: "user-owned", Namespace: namespace.Name},
+				Spec:       marinacorev1.UserSpec{Name: "dori", Roles: []string{"SomeRole"}},
+			}
+
+			req = ctrl.Request{NamespacedName: client.ObjectKeyFromObject(user)}
+
+			err := k8sClient.Create(ctx, user)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should make the user the controller of its children", func() {
+			_, err := reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+
+			children := []client.Object{
+				serviceAccountForUser(user),
+				selfRoleForUser(user),
+				userRoleBindingForRole(user, "SomeRole"),
+				userRoleBindingForRole(user, selfRoleForUser(user).Name),
+				credentialsSecretForUser(user),
+			}
+
+			for _, child := range children {
+				err := k8sClient.Get(ctx, client.ObjectKeyFromObject(child), child)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(metav1.IsControlledBy(child, user)).To(BeTrue(), "%T %s is not controlled by the user", child, child.GetName())
+			}
+		})
+
+		It("should finalize the user after its children were garbage collected", func() {
+			err := k8sClient.Delete(ctx, serviceAccountForUser(user))
+			Expect(err).NotTo(HaveOccurred())
+
+			err = k8sClient.Delete(ctx, selfRoleForUser(user))
+			Expect(err).NotTo(HaveOccurred())
+
+			err = k8sClient.Delete(ctx, user)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = k8sClient.Get(ctx, req.NamespacedName, &marinacorev1.User{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+
+	When("User has many stale role bindings", Ordered, func() {
+		var user *marinacorev1.User
+		var req ctrl.Request
+		var reader *listCounter
+
+		BeforeAll(func() {
+			reader = &listCounter{Reader: k8sClient}
+
+			user = &marinacorev1.User{
+				ObjectMeta: metav1.ObjectMeta{Name: "user-many-bindings", Namespace: namespace.Name},
+				Spec: marinacorev1.UserSpec{
+					Name:     "thorin",
+					Password: []byte("oakenshield"),
+					Roles:    []string{"SomeRole"},
+				},
+			}
+
+			req = ctrl.Request{NamespacedName: client.ObjectKeyFromObject(user)}
+
+			err := k8sClient.Create(ctx, user)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+
+			for i := range 10 {
+				binding := userRoleBindingForRole(user, fmt.Sprintf("stale-role-%d", i))
+				err := k8sClient.Create(ctx, binding)
+				Expect(err).NotTo(HaveOccurred())
+			}
+		})
+
+		AfterAll(func() {
+			err := k8sClient.Delete(ctx, user)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should delete every stale binding across pages", func() {
+			reconciler.APIReader = reader
+			reconciler.RoleBindingPageSize = 4
+			reader.lists = 0
+
+			_, err := reconciler.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reader.lists).To(BeNumerically(">", 1))
+
+			bindings := &rbacv1.RoleBindingList{}
+			err = k8sClient.List(ctx, bindings, client.MatchingLabels(labelsForUser(user)))
+			Expect(err).NotTo(HaveOccurred())
+
+			names := make([]string, 0, len(bindings.Items))
+			for _, binding := range bindings.Items {
+				names = append(names, binding.Name)
+			}
+
+			Expect(names).To(ConsistOf(user.Name+"-SomeRole", user.Name+"-"+user.Name+"-self"))
+		})
+	})
+})
+
+// listCounter counts the lists read through it, so paging can be asserted against the api server.
+type listCounter struct {
+	client.Reader
+
+	lists int
+}
+
+func (r *listCounter) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	r.lists++
+
+	return r.Reader.List(ctx, list, opts...)
+}
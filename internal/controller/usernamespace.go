@@ -0,0 +1,120 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"maps"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	marinacorev1 "github.com/joshmeranda/marina-operator/api/v1"
+)
+
+const (
+	// UserNamespaceClusterRole is the cluster role users are bound to in their provisioned namespace.
+	UserNamespaceClusterRole = "admin"
+)
+
+// provisionedNamespaceName returns the name of the namespace provisioned for the given user.
+func provisionedNamespaceName(user *marinacorev1.User) string {
+	return user.ProvisionedNamespaceName()
+}
+
+// serviceAccountNamespace returns the namespace of the user's service account, which is the user's provisioned
+// namespace when they have one.
+func serviceAccountNamespace(user *marinacorev1.User) string {
+	return user.ServiceAccountNamespace()
+}
+
+// namespaceForUser returns the namespace provisioned for the given user. Namespaces are not namespaced, so they cannot
+// be owned by the user and are tied to it by its labels instead.
+func namespaceForUser(user *marinacorev1.User) *corev1.Namespace {
+	return &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: provisionedNamespaceName(user),
+		},
+	}
+}
+
+// namespaceRoleBindingForUser returns the role binding making the user an admin of their provisioned namespace. It is
+// removed along with the namespace, so it is not labelled for the user like the bindings of the user's roles, which
+// would have it pruned.
+func namespaceRoleBindingForUser(user *marinacorev1.User) *rbacv1.RoleBinding {
+	return &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "marina-user-" + UserNamespaceClusterRole,
+			Namespace: provisionedNamespaceName(user),
+		},
+		Subjects: []rbacv1.Subject{subjectForUser(user)},
+		RoleRef: rbacv1.RoleRef{
+			Kind:     "ClusterRole",
+			Name:     UserNamespaceClusterRole,
+			APIGroup: "rbac.authorization.k8s.io",
+		},
+	}
+}
+
+// reconcileNamespace provisions the user's namespace and binds the user as its admin, deleting the namespace along with
+// everything in it once the user is deleted.
+func (r *UserReconciler) reconcileNamespace(ctx context.Context, user *marinacorev1.User) error {
+	logger := log.FromContext(ctx)
+	namespace := namespaceForUser(user)
+
+	if user.GetDeletionTimestamp() != nil || !user.Spec.ProvisionNamespace {
+		if controllerutil.ContainsFinalizer(user, UserNamespaceFinalizer) {
+			if err := r.Delete(ctx, namespace); client.IgnoreNotFound(err) != nil {
+				logger.Error(err, "could not delete namespace", "namespace", namespace.Name)
+				return err
+			}
+
+			logger.Info("deleted namespace", "namespace", namespace.Name)
+			r.recordEvent(user, corev1.EventTypeNormal, "Deleted", "deleted namespace %s", namespace.Name)
+
+			controllerutil.RemoveFinalizer(user, UserNamespaceFinalizer)
+		}
+
+		return nil
+	}
+
+	_ = controllerutil.AddFinalizer(user, UserNamespaceFinalizer)
+
+	result, err := controllerutil.CreateOrUpdate(ctx, r.Client, namespace, func() error {
+		labels := labelsForUser(user)
+
+		// users in different namespaces may share a name, so a namespace provisioned for another user is never adopted
+		if namespace.ResourceVersion != "" && (namespace.Labels[UserNameLabel] != labels[UserNameLabel] || namespace.Labels[UserNamespaceLabel] != labels[UserNamespaceLabel]) {
+			return fmt.Errorf("namespace '%s' already exists and does not belong to the user", namespace.Name)
+		}
+
+		if namespace.Labels == nil {
+			namespace.Labels = make(map[string]string, len(labels))
+		}
+
+		maps.Copy(namespace.Labels, labels)
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("could not reconcile namespace: %w", err)
+	}
+
+	if result == controllerutil.OperationResultCreated {
+		logger.Info("created namespace", "namespace", namespace.Name)
+		r.recordEvent(user, corev1.EventTypeNormal, "Created", "created namespace %s", namespace.Name)
+	}
+
+	binding := namespaceRoleBindingForUser(user)
+	if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, binding, func() error {
+		binding.Subjects = []rbacv1.Subject{subjectForUser(user)}
+		return nil
+	}); err != nil {
+		return fmt.Errorf("could not reconcile namespace role binding: %w", err)
+	}
+
+	return nil
+}